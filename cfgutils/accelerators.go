@@ -0,0 +1,83 @@
+package cfgutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DEFAULT_NODE_LABEL_PREFIX Prefix of node labels with accelerator counts
+const DEFAULT_NODE_LABEL_PREFIX = "cohdi.io"
+
+// Suffixes of node labels with minimal and maximal count of an accelerator model
+const (
+	acceleratorLabelMinSuffix = "-size-min"
+	acceleratorLabelMaxSuffix = "-size-max"
+)
+
+// AcceleratorModels maps resource type and FM model string to the name used in node labels, e.g. gpu -> h100 -> nvidia-h100
+type AcceleratorModels map[string]map[string]string
+
+// AcceleratorLabels holds configuration of node labels generated for accelerators of the machine
+type AcceleratorLabels struct {
+	Prefix string // Prefix of label keys without '/'; DEFAULT_NODE_LABEL_PREFIX when empty
+	Models AcceleratorModels
+}
+
+// DefaultAcceleratorModels Returns accelerator models labelled when no table is configured
+func DefaultAcceleratorModels() AcceleratorModels {
+	return AcceleratorModels{
+		"gpu": {
+			"nvidia-a100-40g": "nvidia-a100-40g",
+			"nvidia-a100-80g": "nvidia-a100-80g",
+			"nvidia-h100":     "nvidia-h100",
+			"a100-40g":        "nvidia-a100-40g",
+			"a100-80g":        "nvidia-a100-80g",
+			"h100":            "nvidia-h100",
+		},
+	}
+}
+
+// DefaultAcceleratorLabels Returns configuration of node labels with the default prefix and models
+func DefaultAcceleratorLabels() AcceleratorLabels {
+	return AcceleratorLabels{Prefix: DEFAULT_NODE_LABEL_PREFIX, Models: DefaultAcceleratorModels()}
+}
+
+// ValidateNodeLabelPrefix Verify that prefix is a DNS subdomain the kubelet may set labels in
+func ValidateNodeLabelPrefix(prefix string) error {
+	if strings.Contains(prefix, "/") {
+		return fmt.Errorf("prefix '%s' must not contain '/'", prefix)
+	}
+	key := prefix + "/" + strings.Repeat("x", labelNameMaxLength-len(acceleratorLabelMinSuffix))
+	if err := validateLabelKey(key); err != nil {
+		return fmt.Errorf("prefix '%s' must be a lowercase DNS subdomain of at most %d characters", prefix, labelPrefixMaxLength)
+	}
+	return validateKubeletLabelKey(key)
+}
+
+/*
+ParseAcceleratorModels Returns accelerator models from JSON object mapping resource type to FM model strings and their
+label names, e.g. {"gpu": {"h100": "nvidia-h100"}, "fpga": {"agilex7": "intel-agilex7"}}. Empty content means default models
+*/
+func ParseAcceleratorModels(content string) (AcceleratorModels, error) {
+	if strings.TrimSpace(content) == "" {
+		return DefaultAcceleratorModels(), nil
+	}
+
+	var models AcceleratorModels
+	if err := json.Unmarshal([]byte(content), &models); err != nil {
+		return nil, fmt.Errorf("accelerator models must be a JSON object mapping resource type to models and their label names: %w", err)
+	}
+	for resourceType, names := range models {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no models given for resource type '%s'", resourceType)
+		}
+		for model, name := range names {
+			if name == "" || len(name+acceleratorLabelMinSuffix) > labelNameMaxLength || !labelNameRegexp.MatchString(name) {
+				return nil, fmt.Errorf("label name '%s' of %s model '%s' must be at most %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character",
+					name, resourceType, model, labelNameMaxLength-len(acceleratorLabelMinSuffix))
+			}
+		}
+	}
+	return models, nil
+}
@@ -0,0 +1,48 @@
+package cfgutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAcceleratorModels(t *testing.T) {
+	models, err := ParseAcceleratorModels("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAcceleratorModels(), models)
+
+	models, err = ParseAcceleratorModels(`{"gpu": {"mi300x": "amd-mi300x"}, "fpga": {"agilex7": "intel-agilex7"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, AcceleratorModels{"gpu": {"mi300x": "amd-mi300x"}, "fpga": {"agilex7": "intel-agilex7"}}, models)
+}
+
+func TestParseAcceleratorModels_Fail(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		error   string
+	}{
+		{name: "not JSON", content: "gpu=h100", error: "accelerator models must be a JSON object"},
+		{name: "flat map", content: `{"h100": "nvidia-h100"}`, error: "accelerator models must be a JSON object"},
+		{name: "no models", content: `{"fpga": {}}`, error: "no models given for resource type 'fpga'"},
+		{name: "invalid label name", content: `{"gpu": {"h100": "nvidia/h100"}}`, error: "label name 'nvidia/h100' of gpu model 'h100'"},
+		{name: "empty label name", content: `{"gpu": {"h100": ""}}`, error: "label name '' of gpu model 'h100'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseAcceleratorModels(tc.content)
+			assert.ErrorContains(t, err, tc.error)
+		})
+	}
+}
+
+func TestValidateNodeLabelPrefix(t *testing.T) {
+	assert.NoError(t, ValidateNodeLabelPrefix("cohdi.io"))
+	assert.NoError(t, ValidateNodeLabelPrefix("accelerators.example.com"))
+
+	assert.ErrorContains(t, ValidateNodeLabelPrefix("cohdi.io/"), "must not contain '/'")
+	assert.ErrorContains(t, ValidateNodeLabelPrefix("Example.com"), "must be a lowercase DNS subdomain")
+	assert.ErrorContains(t, ValidateNodeLabelPrefix("node-role.kubernetes.io"), "reserved for Kubernetes")
+}
@@ -16,26 +16,34 @@ var (
 // CfgManager interface defines the methods for interacting with the Configuration Manager.
 type CfgManager interface {
 	IsInit() bool
-	PrepareMetadata(instanceId, hostname string) string
-	PrepareRke2ConfigScript(configName, machineUUID string) string
+	PrepareMetadata(metadata Metadata) (string, error)
+	PrepareNetworkConfig(lanports []models.Lanport, roles []NetworkRole) (string, error)
+	PrepareRke2ConfigScript(configName, machineUUID string, nodeLabels, nodeTaints []string) string
 }
 
 // StandardCfgManager struct holds configuration for Configuration Manager interaction.
 type StandardCfgManager struct {
-	resources []models.Resource
+	resources   []models.Resource
+	accelerator AcceleratorLabels
 }
 
 var _ CfgManager = (*StandardCfgManager)(nil)
 
-// NewStandardCfgManager Returns new instance of Standard Configuration Manager
-func NewStandardCfgManager(devicesSpecJson string) *StandardCfgManager {
+// NewStandardCfgManager Returns new instance of Standard Configuration Manager labelling accelerators according to acceleratorLabels
+func NewStandardCfgManager(devicesSpecJson string, acceleratorLabels AcceleratorLabels) *StandardCfgManager {
 	var resources []models.Resource
 	if err := json.Unmarshal([]byte(devicesSpecJson), &resources); err != nil {
 		slog.Warn("Failed to parse DevicesSpecJson, proceeding with empty resources: ", "err", err)
 		resources = []models.Resource{}
 	}
+	if acceleratorLabels.Prefix == "" {
+		acceleratorLabels.Prefix = DEFAULT_NODE_LABEL_PREFIX
+	}
+	if acceleratorLabels.Models == nil {
+		acceleratorLabels.Models = DefaultAcceleratorModels()
+	}
 	isInit = true
-	return &StandardCfgManager{resources: resources}
+	return &StandardCfgManager{resources: resources, accelerator: acceleratorLabels}
 }
 
 // IsInit Returns true if constructor succeed else false
@@ -43,28 +51,17 @@ func (sc *StandardCfgManager) IsInit() bool {
 	return isInit
 }
 
-const metadataContent = `dsmode: local
-instance-id: %s
-hostname: %s`
-
-// PrepareMetadata Returns multiline string with metadata containing instanceId and hostname
-func (sc *StandardCfgManager) PrepareMetadata(instanceId, hostname string) string {
-	content := fmt.Sprintf(metadataContent, instanceId, hostname)
-	return content
-}
-
-// prepareRke2ConfigScript Prepares script for RKE2
-func (sc *StandardCfgManager) PrepareRke2ConfigScript(configName, machineUUID string) string {
+// PrepareRke2ConfigScript Prepares script for RKE2 with provider ID, node labels merged with GPU labels and node taints
+func (sc *StandardCfgManager) PrepareRke2ConfigScript(configName, machineUUID string, nodeLabels, nodeTaints []string) string {
 	slog.Debug(fmt.Sprintf("Prepare RKE2 Config Script: %s", configName))
-	providerIdEntry := sc.prepareRke2ConfigProviderId(machineUUID)
-	nodeLabelEntry := sc.prepareRke2ConfigNodeLabelsForGpu()
-	var configContent string
-	if nodeLabelEntry != "" {
-		configContent = fmt.Sprintf("%s\n%s", providerIdEntry, nodeLabelEntry)
-	} else {
-		configContent = providerIdEntry
+	entries := []string{sc.prepareRke2ConfigProviderId(machineUUID)}
+	if nodeLabelEntry := sc.prepareRke2ConfigNodeLabels(nodeLabels); nodeLabelEntry != "" {
+		entries = append(entries, nodeLabelEntry)
+	}
+	if nodeTaintEntry := sc.prepareRke2ConfigNodeTaints(nodeTaints); nodeTaintEntry != "" {
+		entries = append(entries, nodeTaintEntry)
 	}
-	return fmt.Sprintf(rke2ConfigScriptContent, configName, configContent)
+	return fmt.Sprintf(rke2ConfigScriptContent, configName, strings.Join(entries, "\n"))
 }
 
 /*
@@ -88,21 +85,52 @@ func (sc *StandardCfgManager) prepareRke2ConfigProviderId(MachineUUID string) st
 	return providerIdEntry
 }
 
-// prepareRke2ConfigNodeLabelsForGpu returns a string with node labels
-func (sc *StandardCfgManager) prepareRke2ConfigNodeLabelsForGpu() string {
+/*
+prepareRke2ConfigNodeLabels Returns a string with accelerator labels followed by user-defined node labels.
+A user-defined label with the key of an accelerator label is skipped, so that the labels stay consistent with the machine
+*/
+func (sc *StandardCfgManager) prepareRke2ConfigNodeLabels(nodeLabels []string) string {
 	slog.Debug("Prepare RKE2 Config Node Labels")
-	// GPU map (short names to full names)
-	allowedGPUs := map[string]string{
-		"nvidia-a100-40g": "nvidia-a100-40g",
-		"nvidia-a100-80g": "nvidia-a100-80g",
-		"nvidia-h100":     "nvidia-h100",
-		"a100-40g":        "nvidia-a100-40g",
-		"a100-80g":        "nvidia-a100-80g",
-		"h100":            "nvidia-h100",
+	labels := sc.prepareNodeLabelsForAccelerators()
+	acceleratorKeys := map[string]bool{}
+	for _, label := range labels {
+		key, _, _ := strings.Cut(label, "=")
+		acceleratorKeys[key] = true
+	}
+	for _, label := range nodeLabels {
+		key, _, _ := strings.Cut(label, "=")
+		if acceleratorKeys[key] {
+			slog.Warn("Skipping node label because it is generated for accelerator: ", "key", key)
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(labels) == 0 {
+		slog.Debug("No node labels generated")
+		return ""
+	}
+	return fmt.Sprintf(`kubelet-arg+: "node-labels=%s"`, strings.Join(labels, ","))
+}
+
+// prepareRke2ConfigNodeTaints Returns a string with node taints registered by RKE2
+func (sc *StandardCfgManager) prepareRke2ConfigNodeTaints(nodeTaints []string) string {
+	if len(nodeTaints) == 0 {
+		return ""
+	}
+	slog.Debug("Prepare RKE2 Config Node Taints")
+	entry := "node-taint+:"
+	for _, taint := range nodeTaints {
+		entry += fmt.Sprintf("\n  - \"%s\"", taint)
 	}
+	return entry
+}
+
+// prepareNodeLabelsForAccelerators Returns node labels with counts of accelerators of the machine whose models are labelled
+func (sc *StandardCfgManager) prepareNodeLabelsForAccelerators() []string {
 	labels := []string{}
 	for _, res := range sc.resources {
-		if res.ResourceType != "gpu" || res.ResourceSpec == nil {
+		labelNames, ok := sc.accelerator.Models[res.ResourceType]
+		if !ok || res.ResourceSpec == nil {
 			continue
 		}
 		model := ""
@@ -112,29 +140,28 @@ func (sc *StandardCfgManager) prepareRke2ConfigNodeLabelsForGpu() string {
 				break
 			}
 		}
-		fullModel, ok := allowedGPUs[model]
+		fullModel, ok := labelNames[model]
 		if !ok {
-			slog.Warn("Skipping labels because GPU model not allowed: ", "value", model)
+			slog.Warn("Skipping labels because accelerator model not allowed: ", "type", res.ResourceType, "value", model)
 			continue
 		}
 		if res.MinResourceCount > res.MaxResourceCount {
-			slog.Warn("Invalid GPU config: MinResourceCount > MaxResourceCount ", "model", fullModel, "min", res.MinResourceCount, "max", res.MaxResourceCount)
+			slog.Warn("Invalid accelerator config: MinResourceCount > MaxResourceCount ", "type", res.ResourceType, "model", fullModel, "min", res.MinResourceCount, "max", res.MaxResourceCount)
 			continue
 		}
 		if res.MinResourceCount > 0 {
-			labels = append(labels, fmt.Sprintf("cohdi.io/%s-size-min=%d", fullModel, res.MinResourceCount))
+			labels = append(labels, fmt.Sprintf("%s/%s%s=%d", sc.accelerator.Prefix, fullModel, acceleratorLabelMinSuffix, res.MinResourceCount))
 		} else {
-			slog.Warn("MinResourceCount missing for GPU: ", "model", fullModel)
+			slog.Warn("MinResourceCount missing for accelerator: ", "type", res.ResourceType, "model", fullModel)
 		}
 		if res.MaxResourceCount > 0 {
-			labels = append(labels, fmt.Sprintf("cohdi.io/%s-size-max=%d", fullModel, res.MaxResourceCount))
+			labels = append(labels, fmt.Sprintf("%s/%s%s=%d", sc.accelerator.Prefix, fullModel, acceleratorLabelMaxSuffix, res.MaxResourceCount))
 		} else {
-			slog.Warn("MaxResourceCount missing for GPU: ", "model", fullModel)
+			slog.Warn("MaxResourceCount missing for accelerator: ", "type", res.ResourceType, "model", fullModel)
 		}
 	}
 	if len(labels) == 0 {
-		slog.Debug("No GPU labels generated because of empty GPU resources")
-		return ""
+		slog.Debug("No accelerator labels generated because of empty accelerator resources")
 	}
-	return fmt.Sprintf(`kubelet-arg+: "node-labels=%s"`, strings.Join(labels, ","))
+	return labels
 }
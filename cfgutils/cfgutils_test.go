@@ -16,41 +16,11 @@ func TestIsInit_Fail(t *testing.T) {
 }
 
 func TestIsInit_Success(t *testing.T) {
-	manager := NewStandardCfgManager("[]")
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
 	observed := manager.IsInit()
 	assert.Equal(t, true, observed)
 }
 
-func TestPrepareMetadata(t *testing.T) {
-
-	testCases := []struct {
-		instanceId string
-		hostname   string
-		expected   string
-	}{
-		{instanceId: "12345678-1234-1234-1234-123456789012", hostname: "host1",
-			expected: `dsmode: local
-instance-id: 12345678-1234-1234-1234-123456789012
-hostname: host1`,
-		},
-		{instanceId: "12345678-1234-1234-1234-123456789012", hostname: "",
-			expected: `dsmode: local
-instance-id: 12345678-1234-1234-1234-123456789012
-hostname: `,
-		},
-		{instanceId: "", hostname: "host1", expected: "dsmode: local\ninstance-id: \nhostname: host1"},
-		{instanceId: "", hostname: "", expected: "dsmode: local\ninstance-id: \nhostname: "},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.hostname, func(t *testing.T) {
-			manager := NewStandardCfgManager("[]")
-			observed := manager.PrepareMetadata(tc.instanceId, tc.hostname)
-			assert.Equal(t, tc.expected, observed)
-		})
-	}
-}
-
 func Test_prepareRke2ConfigProviderId(t *testing.T) {
 	testCases := []struct {
 		machineUUID string
@@ -62,7 +32,7 @@ func Test_prepareRke2ConfigProviderId(t *testing.T) {
 			expected: `kubelet-arg+: "provider-id=fsas-cdi://"`},
 	}
 
-	manager := NewStandardCfgManager("[]")
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
 
 	for _, tc := range testCases {
 		t.Run(tc.machineUUID, func(t *testing.T) {
@@ -72,7 +42,7 @@ func Test_prepareRke2ConfigProviderId(t *testing.T) {
 	}
 }
 
-func Test_prepareRke2ConfigNodeLabelsForGpu(t *testing.T) {
+func Test_prepareRke2ConfigNodeLabels(t *testing.T) {
 	testCases := []struct {
 		name     string
 		expected string
@@ -80,10 +50,10 @@ func Test_prepareRke2ConfigNodeLabelsForGpu(t *testing.T) {
 		{name: "no GPU resources",
 			expected: ""},
 	}
-	manager := NewStandardCfgManager("[]")
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			observed := manager.prepareRke2ConfigNodeLabelsForGpu()
+			observed := manager.prepareRke2ConfigNodeLabels(nil)
 			assert.Equal(t, tc.expected, observed)
 		})
 	}
@@ -113,8 +83,8 @@ func Test_prepareRke2ConfigNodeLabels_Dynamic(t *testing.T) {
 			"max_resource_count": 3
 		}
 	]`
-	manager := NewStandardCfgManager(devicesSpecJson)
-	labelStr := manager.prepareRke2ConfigNodeLabelsForGpu()
+	manager := NewStandardCfgManager(devicesSpecJson, DefaultAcceleratorLabels())
+	labelStr := manager.prepareRke2ConfigNodeLabels(nil)
 	expected := `kubelet-arg+: "node-labels=cohdi.io/nvidia-h100-size-min=2,cohdi.io/nvidia-h100-size-max=3"`
 	assert.Equal(t, expected, labelStr)
 }
@@ -136,11 +106,11 @@ func TestPrepareRke2ConfigScript(t *testing.T) {
 				`kubelet-arg+: "provider-id=fsas-cdi://"`)},
 	}
 
-	manager := NewStandardCfgManager("[]")
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
 
 	for _, tc := range testCases {
 		t.Run(tc.machineUUID, func(t *testing.T) {
-			observed := manager.PrepareRke2ConfigScript(configName, tc.machineUUID)
+			observed := manager.PrepareRke2ConfigScript(configName, tc.machineUUID, nil, nil)
 			assert.Equal(t, tc.expected, observed)
 		})
 	}
@@ -160,21 +130,96 @@ func TestPrepareRke2ConfigScript_WithGPUResources(t *testing.T) {
 			"max_resource_count": 2
 		}
 	]`
-	manager := NewStandardCfgManager(devicesSpecJson)
+	manager := NewStandardCfgManager(devicesSpecJson, DefaultAcceleratorLabels())
 	configName := "100-gpu-labels"
-	script := manager.PrepareRke2ConfigScript(configName, "my-machine-uuid")
+	script := manager.PrepareRke2ConfigScript(configName, "my-machine-uuid", nil, nil)
 	expected := fmt.Sprintf(rke2ConfigScriptContent, configName,
 		`kubelet-arg+: "provider-id=fsas-cdi://my-machine-uuid"
 kubelet-arg+: "node-labels=cohdi.io/nvidia-a100-40g-size-min=1,cohdi.io/nvidia-a100-40g-size-max=2"`)
 	assert.Equal(t, expected, script)
 }
+
+func TestPrepareRke2ConfigScript_WithNodeLabelsAndTaints(t *testing.T) {
+	devicesSpecJson := `[
+		{
+			"res_type": "gpu",
+			"res_num": 1,
+			"res_spec": {
+				"condition": [
+					{"column": "model", "operator": "eq", "value": "h100"}
+				]
+			},
+			"min_resource_count": 1,
+			"max_resource_count": 2
+		}
+	]`
+	manager := NewStandardCfgManager(devicesSpecJson, DefaultAcceleratorLabels())
+	configName := "100-fsas-providerid"
+	nodeLabels := []string{"tier=gpu", "cohdi.io/nvidia-h100-size-max=8", "node.kubernetes.io/pool="}
+	nodeTaints := []string{"nvidia.com/gpu=present:NoSchedule", "dedicated:PreferNoSchedule"}
+
+	script := manager.PrepareRke2ConfigScript(configName, "my-machine-uuid", nodeLabels, nodeTaints)
+
+	expected := fmt.Sprintf(rke2ConfigScriptContent, configName,
+		`kubelet-arg+: "provider-id=fsas-cdi://my-machine-uuid"
+kubelet-arg+: "node-labels=cohdi.io/nvidia-h100-size-min=1,cohdi.io/nvidia-h100-size-max=2,tier=gpu,node.kubernetes.io/pool="
+node-taint+:
+  - "nvidia.com/gpu=present:NoSchedule"
+  - "dedicated:PreferNoSchedule"`)
+	assert.Equal(t, expected, script)
+}
+
+func TestPrepareRke2ConfigScript_WithAcceleratorModels(t *testing.T) {
+	devicesSpecJson := `[
+		{
+			"res_type": "gpu",
+			"res_spec": {"condition": [{"column": "model", "operator": "eq", "value": "mi300x"}]},
+			"min_resource_count": 1,
+			"max_resource_count": 4
+		},
+		{
+			"res_type": "gpu",
+			"res_spec": {"condition": [{"column": "model", "operator": "eq", "value": "h100"}]},
+			"min_resource_count": 1,
+			"max_resource_count": 2
+		},
+		{
+			"res_type": "fpga",
+			"res_spec": {"condition": [{"column": "model", "operator": "eq", "value": "agilex7"}]},
+			"min_resource_count": 1,
+			"max_resource_count": 1
+		}
+	]`
+	acceleratorLabels := AcceleratorLabels{
+		Prefix: "accelerators.example.com",
+		Models: AcceleratorModels{"gpu": {"mi300x": "amd-mi300x"}, "fpga": {"agilex7": "intel-agilex7"}},
+	}
+	manager := NewStandardCfgManager(devicesSpecJson, acceleratorLabels)
+	configName := "100-fsas-providerid"
+
+	script := manager.PrepareRke2ConfigScript(configName, "my-machine-uuid", nil, nil)
+
+	// H100 is not in the table, so it is not labelled
+	expected := fmt.Sprintf(rke2ConfigScriptContent, configName,
+		`kubelet-arg+: "provider-id=fsas-cdi://my-machine-uuid"
+kubelet-arg+: "node-labels=accelerators.example.com/amd-mi300x-size-min=1,accelerators.example.com/amd-mi300x-size-max=4,`+
+			`accelerators.example.com/intel-agilex7-size-min=1,accelerators.example.com/intel-agilex7-size-max=1"`)
+	assert.Equal(t, expected, script)
+}
+
+func TestNewStandardCfgManager_DefaultAcceleratorLabels(t *testing.T) {
+	manager := NewStandardCfgManager("[]", AcceleratorLabels{})
+
+	assert.Equal(t, DefaultAcceleratorLabels(), manager.accelerator)
+}
+
 func Test_prepareRke2ConfigNodeLabels_FromExactJSON(t *testing.T) {
 	devicesSpecJson := `testJson`
 	var resources []models.Resource
 	if err := json.Unmarshal([]byte(devicesSpecJson), &resources); err != nil {
 		t.Logf("Failed to unmarshal JSON: %v", err)
 	}
-	manager := NewStandardCfgManager(devicesSpecJson)
-	labels := manager.prepareRke2ConfigNodeLabelsForGpu()
+	manager := NewStandardCfgManager(devicesSpecJson, DefaultAcceleratorLabels())
+	labels := manager.prepareRke2ConfigNodeLabels(nil)
 	t.Logf("Generated GPU label: %s", labels)
 }
@@ -0,0 +1,98 @@
+package cfgutils
+
+import (
+	"bytes"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Metadata holds content of NoCloud meta-data of the machine
+type Metadata struct {
+	InstanceId string
+	Hostname   string
+	PublicKeys []string // Authorized keys of the default user
+	Cdi        *CdiMetadata
+}
+
+// CdiMetadata holds facts Fabric Manager reports about the composed machine
+type CdiMetadata struct {
+	TenantUUID  string
+	FabricUUID  string
+	MachineUUID string
+	BootSSD     string
+	Resources   []models.Resource
+}
+
+type metadataYaml struct {
+	DsMode        string           `yaml:"dsmode"`
+	InstanceId    string           `yaml:"instance-id"`
+	Hostname      string           `yaml:"hostname"`
+	LocalHostname string           `yaml:"local-hostname"`
+	PublicKeys    []string         `yaml:"public-keys,omitempty"`
+	Cdi           *cdiMetadataYaml `yaml:"cdi,omitempty"`
+}
+
+type cdiMetadataYaml struct {
+	TenantUUID  string                `yaml:"tenant_uuid"`
+	FabricUUID  string                `yaml:"fabric_uuid,omitempty"`
+	MachineUUID string                `yaml:"machine_uuid"`
+	BootSSD     string                `yaml:"boot_ssd,omitempty"`
+	Resources   []cdiResourceMetadata `yaml:"resources,omitempty"`
+}
+
+type cdiResourceMetadata struct {
+	Type      string             `yaml:"type"`
+	UUID      string             `yaml:"uuid,omitempty"`
+	Name      string             `yaml:"name,omitempty"`
+	Condition []models.Condition `yaml:"condition,omitempty"`
+}
+
+/*
+PrepareMetadata Returns NoCloud meta-data of the machine as YAML document.
+Besides instance-id and host names it carries public keys of the default user and, when given,
+the cdi section describing tenant, fabric, machine, boot SSD and composed resources,
+so cloud-init modules and scripts on the node can read the hardware the machine was composed with.
+*/
+func (sc *StandardCfgManager) PrepareMetadata(metadata Metadata) (string, error) {
+	slog.Debug("Prepare metadata: ", "instance_id", metadata.InstanceId, "hostname", metadata.Hostname)
+
+	content := metadataYaml{
+		DsMode:        "local",
+		InstanceId:    metadata.InstanceId,
+		Hostname:      metadata.Hostname,
+		LocalHostname: metadata.Hostname,
+		PublicKeys:    metadata.PublicKeys,
+	}
+	if metadata.Cdi != nil {
+		content.Cdi = &cdiMetadataYaml{
+			TenantUUID:  metadata.Cdi.TenantUUID,
+			FabricUUID:  metadata.Cdi.FabricUUID,
+			MachineUUID: metadata.Cdi.MachineUUID,
+			BootSSD:     metadata.Cdi.BootSSD,
+		}
+		for _, resource := range metadata.Cdi.Resources {
+			resourceMetadata := cdiResourceMetadata{
+				Type: resource.ResourceType,
+				UUID: resource.ResourceUUID,
+				Name: resource.ResourceName,
+			}
+			if resource.ResourceSpec != nil {
+				resourceMetadata.Condition = resource.ResourceSpec.Condition
+			}
+			content.Cdi.Resources = append(content.Cdi.Resources, resourceMetadata)
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(content); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,100 @@
+package cfgutils
+
+import (
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPrepareMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata Metadata
+		expected string
+	}{
+		{name: "host1",
+			metadata: Metadata{InstanceId: "12345678-1234-1234-1234-123456789012", Hostname: "host1"},
+			expected: `dsmode: local
+instance-id: 12345678-1234-1234-1234-123456789012
+hostname: host1
+local-hostname: host1
+`,
+		},
+		{name: "empty hostname",
+			metadata: Metadata{InstanceId: "12345678-1234-1234-1234-123456789012"},
+			expected: `dsmode: local
+instance-id: 12345678-1234-1234-1234-123456789012
+hostname: ""
+local-hostname: ""
+`,
+		},
+		{name: "hostname needing quotes",
+			metadata: Metadata{InstanceId: "id", Hostname: "host: #1"},
+			expected: `dsmode: local
+instance-id: id
+hostname: 'host: #1'
+local-hostname: 'host: #1'
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+			observed, err := manager.PrepareMetadata(tc.metadata)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, observed)
+		})
+	}
+}
+
+func TestPrepareMetadata_WithCdiFacts(t *testing.T) {
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+
+	observed, err := manager.PrepareMetadata(Metadata{
+		InstanceId: "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+		Hostname:   "node-1",
+		PublicKeys: []string{"ssh-rsa AAAAB3NzaC1yc2E docker-machine"},
+		Cdi: &CdiMetadata{
+			TenantUUID:  "tenant-uuid",
+			FabricUUID:  "fabric-uuid",
+			MachineUUID: "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+			BootSSD:     "ssd-uuid",
+			Resources: []models.Resource{
+				{ResourceType: "gpu", ResourceUUID: "gpu-uuid", ResourceName: "gpu-0",
+					ResourceSpec: &models.ResSpec{Condition: []models.Condition{{Column: "model", Operator: "eq", Value: "A100 40G"}}}},
+				{ResourceType: "storage", ResourceUUID: "ssd-uuid"},
+			},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `dsmode: local
+instance-id: cdd792f2-5591-4c18-a8bd-1c39e55dedfa
+hostname: node-1
+local-hostname: node-1
+public-keys:
+  - ssh-rsa AAAAB3NzaC1yc2E docker-machine
+cdi:
+  tenant_uuid: tenant-uuid
+  fabric_uuid: fabric-uuid
+  machine_uuid: cdd792f2-5591-4c18-a8bd-1c39e55dedfa
+  boot_ssd: ssd-uuid
+  resources:
+    - type: gpu
+      uuid: gpu-uuid
+      name: gpu-0
+      condition:
+        - column: model
+          operator: eq
+          value: A100 40G
+    - type: storage
+      uuid: ssd-uuid
+`, observed)
+
+	var parsed map[string]any
+	assert.NoError(t, yaml.Unmarshal([]byte(observed), &parsed))
+}
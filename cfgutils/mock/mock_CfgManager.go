@@ -2,7 +2,12 @@
 
 package cfgutils
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	cfgutils "github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/fujitsu/docker-machine-driver-fsas/models"
+)
 
 // MockCfgManager is an autogenerated mock type for the CfgManager type
 type MockCfgManager struct {
@@ -62,22 +67,32 @@ func (_c *MockCfgManager_IsInit_Call) RunAndReturn(run func() bool) *MockCfgMana
 	return _c
 }
 
-// PrepareMetadata provides a mock function with given fields: instanceId, hostname
-func (_m *MockCfgManager) PrepareMetadata(instanceId string, hostname string) string {
-	ret := _m.Called(instanceId, hostname)
+// PrepareMetadata provides a mock function with given fields: metadata
+func (_m *MockCfgManager) PrepareMetadata(metadata cfgutils.Metadata) (string, error) {
+	ret := _m.Called(metadata)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PrepareMetadata")
 	}
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(instanceId, hostname)
+	var r1 error
+	if rf, ok := ret.Get(0).(func(cfgutils.Metadata) (string, error)); ok {
+		return rf(metadata)
+	}
+	if rf, ok := ret.Get(0).(func(cfgutils.Metadata) string); ok {
+		r0 = rf(metadata)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(cfgutils.Metadata) error); ok {
+		r1 = rf(metadata)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MockCfgManager_PrepareMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PrepareMetadata'
@@ -86,40 +101,96 @@ type MockCfgManager_PrepareMetadata_Call struct {
 }
 
 // PrepareMetadata is a helper method to define mock.On call
-//   - instanceId string
-//   - hostname string
-func (_e *MockCfgManager_Expecter) PrepareMetadata(instanceId interface{}, hostname interface{}) *MockCfgManager_PrepareMetadata_Call {
-	return &MockCfgManager_PrepareMetadata_Call{Call: _e.mock.On("PrepareMetadata", instanceId, hostname)}
+//   - metadata cfgutils.Metadata
+func (_e *MockCfgManager_Expecter) PrepareMetadata(metadata interface{}) *MockCfgManager_PrepareMetadata_Call {
+	return &MockCfgManager_PrepareMetadata_Call{Call: _e.mock.On("PrepareMetadata", metadata)}
 }
 
-func (_c *MockCfgManager_PrepareMetadata_Call) Run(run func(instanceId string, hostname string)) *MockCfgManager_PrepareMetadata_Call {
+func (_c *MockCfgManager_PrepareMetadata_Call) Run(run func(metadata cfgutils.Metadata)) *MockCfgManager_PrepareMetadata_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(cfgutils.Metadata))
 	})
 	return _c
 }
 
-func (_c *MockCfgManager_PrepareMetadata_Call) Return(_a0 string) *MockCfgManager_PrepareMetadata_Call {
-	_c.Call.Return(_a0)
+func (_c *MockCfgManager_PrepareMetadata_Call) Return(_a0 string, _a1 error) *MockCfgManager_PrepareMetadata_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCfgManager_PrepareMetadata_Call) RunAndReturn(run func(cfgutils.Metadata) (string, error)) *MockCfgManager_PrepareMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PrepareNetworkConfig provides a mock function with given fields: lanports, roles
+func (_m *MockCfgManager) PrepareNetworkConfig(lanports []models.Lanport, roles []cfgutils.NetworkRole) (string, error) {
+	ret := _m.Called(lanports, roles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PrepareNetworkConfig")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]models.Lanport, []cfgutils.NetworkRole) (string, error)); ok {
+		return rf(lanports, roles)
+	}
+	if rf, ok := ret.Get(0).(func([]models.Lanport, []cfgutils.NetworkRole) string); ok {
+		r0 = rf(lanports, roles)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func([]models.Lanport, []cfgutils.NetworkRole) error); ok {
+		r1 = rf(lanports, roles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCfgManager_PrepareNetworkConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PrepareNetworkConfig'
+type MockCfgManager_PrepareNetworkConfig_Call struct {
+	*mock.Call
+}
+
+// PrepareNetworkConfig is a helper method to define mock.On call
+//   - lanports []models.Lanport
+//   - roles []cfgutils.NetworkRole
+func (_e *MockCfgManager_Expecter) PrepareNetworkConfig(lanports interface{}, roles interface{}) *MockCfgManager_PrepareNetworkConfig_Call {
+	return &MockCfgManager_PrepareNetworkConfig_Call{Call: _e.mock.On("PrepareNetworkConfig", lanports, roles)}
+}
+
+func (_c *MockCfgManager_PrepareNetworkConfig_Call) Run(run func(lanports []models.Lanport, roles []cfgutils.NetworkRole)) *MockCfgManager_PrepareNetworkConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]models.Lanport), args[1].([]cfgutils.NetworkRole))
+	})
+	return _c
+}
+
+func (_c *MockCfgManager_PrepareNetworkConfig_Call) Return(_a0 string, _a1 error) *MockCfgManager_PrepareNetworkConfig_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockCfgManager_PrepareMetadata_Call) RunAndReturn(run func(string, string) string) *MockCfgManager_PrepareMetadata_Call {
+func (_c *MockCfgManager_PrepareNetworkConfig_Call) RunAndReturn(run func([]models.Lanport, []cfgutils.NetworkRole) (string, error)) *MockCfgManager_PrepareNetworkConfig_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PrepareRke2ConfigScript provides a mock function with given fields: configName, machineUUID
-func (_m *MockCfgManager) PrepareRke2ConfigScript(configName string, machineUUID string) string {
-	ret := _m.Called(configName, machineUUID)
+// PrepareRke2ConfigScript provides a mock function with given fields: configName, machineUUID, nodeLabels, nodeTaints
+func (_m *MockCfgManager) PrepareRke2ConfigScript(configName string, machineUUID string, nodeLabels []string, nodeTaints []string) string {
+	ret := _m.Called(configName, machineUUID, nodeLabels, nodeTaints)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PrepareRke2ConfigScript")
 	}
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(configName, machineUUID)
+	if rf, ok := ret.Get(0).(func(string, string, []string, []string) string); ok {
+		r0 = rf(configName, machineUUID, nodeLabels, nodeTaints)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
@@ -135,13 +206,15 @@ type MockCfgManager_PrepareRke2ConfigScript_Call struct {
 // PrepareRke2ConfigScript is a helper method to define mock.On call
 //   - configName string
 //   - machineUUID string
-func (_e *MockCfgManager_Expecter) PrepareRke2ConfigScript(configName interface{}, machineUUID interface{}) *MockCfgManager_PrepareRke2ConfigScript_Call {
-	return &MockCfgManager_PrepareRke2ConfigScript_Call{Call: _e.mock.On("PrepareRke2ConfigScript", configName, machineUUID)}
+//   - nodeLabels []string
+//   - nodeTaints []string
+func (_e *MockCfgManager_Expecter) PrepareRke2ConfigScript(configName interface{}, machineUUID interface{}, nodeLabels interface{}, nodeTaints interface{}) *MockCfgManager_PrepareRke2ConfigScript_Call {
+	return &MockCfgManager_PrepareRke2ConfigScript_Call{Call: _e.mock.On("PrepareRke2ConfigScript", configName, machineUUID, nodeLabels, nodeTaints)}
 }
 
-func (_c *MockCfgManager_PrepareRke2ConfigScript_Call) Run(run func(configName string, machineUUID string)) *MockCfgManager_PrepareRke2ConfigScript_Call {
+func (_c *MockCfgManager_PrepareRke2ConfigScript_Call) Run(run func(configName string, machineUUID string, nodeLabels []string, nodeTaints []string)) *MockCfgManager_PrepareRke2ConfigScript_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(string), args[1].(string), args[2].([]string), args[3].([]string))
 	})
 	return _c
 }
@@ -151,7 +224,7 @@ func (_c *MockCfgManager_PrepareRke2ConfigScript_Call) Return(_a0 string) *MockC
 	return _c
 }
 
-func (_c *MockCfgManager_PrepareRke2ConfigScript_Call) RunAndReturn(run func(string, string) string) *MockCfgManager_PrepareRke2ConfigScript_Call {
+func (_c *MockCfgManager_PrepareRke2ConfigScript_Call) RunAndReturn(run func(string, string, []string, []string) string) *MockCfgManager_PrepareRke2ConfigScript_Call {
 	_c.Call.Return(run)
 	return _c
 }
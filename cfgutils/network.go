@@ -0,0 +1,148 @@
+package cfgutils
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"gopkg.in/yaml.v3"
+)
+
+// NetworkRole describes how the interface connected to one subnet of the machine is configured
+type NetworkRole struct {
+	Name         string // Role of the subnet, e.g. "provision"; the interface is named <Name>0
+	SubnetUUID   string
+	Dhcp         bool
+	PrefixLength int // Used for static address only
+	Gateway      string
+	RouteMetric  int
+	Nameservers  []string
+}
+
+// macAddress is always quoted, cloud-init's YAML 1.1 parser would read some MAC addresses as numbers
+type macAddress string
+
+func (m macAddress) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: string(m)}, nil
+}
+
+type networkConfigV2 struct {
+	Network networkV2 `yaml:"network"`
+}
+
+type networkV2 struct {
+	Version   int                   `yaml:"version"`
+	Ethernets map[string]ethernetV2 `yaml:"ethernets"`
+}
+
+type ethernetV2 struct {
+	Match         ethernetMatchV2  `yaml:"match"`
+	SetName       string           `yaml:"set-name"`
+	Dhcp4         bool             `yaml:"dhcp4"`
+	Dhcp4Override *dhcpOverridesV2 `yaml:"dhcp4-overrides,omitempty"`
+	Addresses     []string         `yaml:"addresses,omitempty"`
+	Routes        []routeV2        `yaml:"routes,omitempty"`
+	Nameservers   *nameserversV2   `yaml:"nameservers,omitempty"`
+}
+
+type ethernetMatchV2 struct {
+	MacAddress macAddress `yaml:"macaddress"`
+}
+
+type dhcpOverridesV2 struct {
+	RouteMetric int `yaml:"route-metric"`
+}
+
+type routeV2 struct {
+	To     string `yaml:"to"`
+	Via    string `yaml:"via"`
+	Metric int    `yaml:"metric,omitempty"`
+}
+
+type nameserversV2 struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+/*
+PrepareNetworkConfig Returns cloud-init network-config (version 2) for lanports of the machine.
+Every role gets the lanport connected to its subnet: the interface is matched by MAC address and renamed
+after the role, then configured by DHCP or with the static address Fabric Manager assigned to the lanport.
+The gateway of the role becomes a default route with the role's metric, so the role of the lowest metric
+is preferred. Lanports of subnets without a role are left to the image defaults.
+Returns empty content when every role uses plain DHCP, so the image defaults are kept.
+*/
+func (sc *StandardCfgManager) PrepareNetworkConfig(lanports []models.Lanport, roles []NetworkRole) (string, error) {
+	slog.Debug("Prepare network config: ", "lanports", len(lanports), "roles", len(roles))
+
+	config := networkConfigV2{Network: networkV2{Version: 2, Ethernets: map[string]ethernetV2{}}}
+	configured := false
+	for _, role := range roles {
+		lanport, found := findLanport(lanports, role.SubnetUUID)
+		if !found {
+			return "", fmt.Errorf("no lanport of the machine is connected to %s subnet %s", role.Name, role.SubnetUUID)
+		}
+		if _, err := net.ParseMAC(lanport.MacAddress); err != nil {
+			return "", fmt.Errorf("invalid MAC address '%s' of lanport in %s subnet: %w", lanport.MacAddress, role.Name, err)
+		}
+
+		name := role.Name + "0"
+		ethernet := ethernetV2{
+			Match:   ethernetMatchV2{MacAddress: macAddress(lanport.MacAddress)},
+			SetName: name,
+			Dhcp4:   role.Dhcp,
+		}
+
+		if role.Dhcp {
+			if role.RouteMetric > 0 {
+				ethernet.Dhcp4Override = &dhcpOverridesV2{RouteMetric: role.RouteMetric}
+			}
+		} else {
+			if net.ParseIP(lanport.IPAddress) == nil {
+				return "", fmt.Errorf("lanport in %s subnet has no valid IP address for static configuration: '%s'", role.Name, lanport.IPAddress)
+			}
+			if role.PrefixLength <= 0 || role.PrefixLength > 32 {
+				return "", fmt.Errorf("prefix length of %s subnet must be between 1 and 32; got %d", role.Name, role.PrefixLength)
+			}
+			ethernet.Addresses = []string{fmt.Sprintf("%s/%d", lanport.IPAddress, role.PrefixLength)}
+			configured = true
+		}
+
+		if role.Gateway != "" {
+			ethernet.Routes = []routeV2{{To: "default", Via: role.Gateway, Metric: role.RouteMetric}}
+			configured = true
+		}
+		if len(role.Nameservers) > 0 {
+			ethernet.Nameservers = &nameserversV2{Addresses: role.Nameservers}
+			configured = true
+		}
+		config.Network.Ethernets[name] = ethernet
+	}
+
+	if !configured {
+		slog.Debug("No subnet role needs more than DHCP, keeping network config of the image")
+		return "", nil
+	}
+
+	var content bytes.Buffer
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return content.String(), nil
+}
+
+// findLanport Returns lanport connected to the subnet
+func findLanport(lanports []models.Lanport, subnetUUID string) (models.Lanport, bool) {
+	for _, lanport := range lanports {
+		if lanport.SubnetUUID == subnetUUID {
+			return lanport, true
+		}
+	}
+	return models.Lanport{}, false
+}
@@ -0,0 +1,131 @@
+package cfgutils
+
+import (
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var networkTestLanports = []models.Lanport{
+	{SubnetUUID: "provision-subnet", MacAddress: "00:11:22:33:44:55", LanportIdx: 1, IPAddress: "192.168.2.100"},
+	{SubnetUUID: "baremetal-subnet", MacAddress: "00:11:22:33:44:66", LanportIdx: 2, IPAddress: "10.0.0.100"},
+	{SubnetUUID: "other-subnet", MacAddress: "00:11:22:33:44:77", LanportIdx: 3, IPAddress: "172.16.0.5"},
+}
+
+func TestPrepareNetworkConfig_StaticAndDhcp(t *testing.T) {
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+
+	networkConfig, err := manager.PrepareNetworkConfig(networkTestLanports, []NetworkRole{
+		{Name: "provision", SubnetUUID: "provision-subnet", PrefixLength: 24, Gateway: "192.168.2.1", RouteMetric: 100, Nameservers: []string{"192.168.2.53"}},
+		{Name: "baremetal", SubnetUUID: "baremetal-subnet", Dhcp: true, RouteMetric: 200},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `network:
+  version: 2
+  ethernets:
+    baremetal0:
+      match:
+        macaddress: "00:11:22:33:44:66"
+      set-name: baremetal0
+      dhcp4: true
+      dhcp4-overrides:
+        route-metric: 200
+    provision0:
+      match:
+        macaddress: "00:11:22:33:44:55"
+      set-name: provision0
+      dhcp4: false
+      addresses:
+        - 192.168.2.100/24
+      routes:
+        - to: default
+          via: 192.168.2.1
+          metric: 100
+      nameservers:
+        addresses:
+          - 192.168.2.53
+`, networkConfig)
+}
+
+func TestPrepareNetworkConfig_DhcpWithGateway(t *testing.T) {
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+
+	networkConfig, err := manager.PrepareNetworkConfig(networkTestLanports, []NetworkRole{
+		{Name: "provision", SubnetUUID: "provision-subnet", Dhcp: true, Gateway: "192.168.2.1", RouteMetric: 100},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `network:
+  version: 2
+  ethernets:
+    provision0:
+      match:
+        macaddress: "00:11:22:33:44:55"
+      set-name: provision0
+      dhcp4: true
+      dhcp4-overrides:
+        route-metric: 100
+      routes:
+        - to: default
+          via: 192.168.2.1
+          metric: 100
+`, networkConfig)
+}
+
+func TestPrepareNetworkConfig_DhcpOnly(t *testing.T) {
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+
+	networkConfig, err := manager.PrepareNetworkConfig(networkTestLanports, []NetworkRole{
+		{Name: "provision", SubnetUUID: "provision-subnet", Dhcp: true, RouteMetric: 100},
+		{Name: "baremetal", SubnetUUID: "baremetal-subnet", Dhcp: true, RouteMetric: 200},
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, networkConfig)
+}
+
+func TestPrepareNetworkConfig_Errors(t *testing.T) {
+	manager := NewStandardCfgManager("[]", DefaultAcceleratorLabels())
+
+	tests := []struct {
+		name     string
+		lanports []models.Lanport
+		role     NetworkRole
+		wantErr  string
+	}{
+		{
+			name:     "missing lanport",
+			lanports: networkTestLanports,
+			role:     NetworkRole{Name: "baremetal", SubnetUUID: "unknown-subnet", Dhcp: true},
+			wantErr:  "no lanport of the machine is connected to baremetal subnet unknown-subnet",
+		},
+		{
+			name:     "invalid MAC address",
+			lanports: []models.Lanport{{SubnetUUID: "provision-subnet", MacAddress: "", IPAddress: "192.168.2.100"}},
+			role:     NetworkRole{Name: "provision", SubnetUUID: "provision-subnet", Dhcp: true},
+			wantErr:  "invalid MAC address '' of lanport in provision subnet",
+		},
+		{
+			name:     "static without IP address",
+			lanports: []models.Lanport{{SubnetUUID: "provision-subnet", MacAddress: "00:11:22:33:44:55"}},
+			role:     NetworkRole{Name: "provision", SubnetUUID: "provision-subnet", PrefixLength: 24},
+			wantErr:  "lanport in provision subnet has no valid IP address for static configuration: ''",
+		},
+		{
+			name:     "static without prefix length",
+			lanports: networkTestLanports,
+			role:     NetworkRole{Name: "provision", SubnetUUID: "provision-subnet"},
+			wantErr:  "prefix length of provision subnet must be between 1 and 32; got 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.PrepareNetworkConfig(tt.lanports, []NetworkRole{tt.role})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
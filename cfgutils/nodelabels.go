@@ -0,0 +1,165 @@
+package cfgutils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Taint effects accepted by Kubernetes
+const (
+	TAINT_EFFECT_NO_SCHEDULE        = "NoSchedule"
+	TAINT_EFFECT_PREFER_NO_SCHEDULE = "PreferNoSchedule"
+	TAINT_EFFECT_NO_EXECUTE         = "NoExecute"
+)
+
+const (
+	labelNameMaxLength   = 63
+	labelPrefixMaxLength = 253
+)
+
+var (
+	labelNameRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	labelPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	taintEffects      = []string{TAINT_EFFECT_NO_SCHEDULE, TAINT_EFFECT_PREFER_NO_SCHEDULE, TAINT_EFFECT_NO_EXECUTE}
+)
+
+/*
+kubeletAllowedLabelPrefixes Prefixes in the kubernetes.io and k8s.io namespaces the kubelet may set on its own node,
+other labels in those namespaces are refused by the NodeRestriction admission plugin and the node fails to register
+*/
+var kubeletAllowedLabelPrefixes = []string{"kubelet.kubernetes.io", "node.kubernetes.io"}
+
+// kubeletAllowedLabels Well-known labels in the kubernetes.io and k8s.io namespaces the kubelet may set on its own node
+var kubeletAllowedLabels = []string{
+	"kubernetes.io/hostname",
+	"kubernetes.io/arch",
+	"kubernetes.io/os",
+	"beta.kubernetes.io/arch",
+	"beta.kubernetes.io/os",
+	"beta.kubernetes.io/instance-type",
+	"failure-domain.beta.kubernetes.io/region",
+	"failure-domain.beta.kubernetes.io/zone",
+	"topology.kubernetes.io/region",
+	"topology.kubernetes.io/zone",
+}
+
+// validateLabelKey Verify key against Kubernetes syntax; optional DNS subdomain prefix and a name separated by '/'
+func validateLabelKey(key string) error {
+	name := key
+	if prefix, rest, found := strings.Cut(key, "/"); found {
+		if len(prefix) > labelPrefixMaxLength || !labelPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("prefix of key '%s' must be a lowercase DNS subdomain of at most %d characters", key, labelPrefixMaxLength)
+		}
+		name = rest
+	}
+	if len(name) > labelNameMaxLength || !labelNameRegexp.MatchString(name) {
+		return fmt.Errorf("name of key '%s' must be at most %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", key, labelNameMaxLength)
+	}
+	return nil
+}
+
+// validateLabelValue Verify value against Kubernetes syntax; empty or a name of at most 63 characters
+func validateLabelValue(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > labelNameMaxLength || !labelNameRegexp.MatchString(value) {
+		return fmt.Errorf("value '%s' of key '%s' must be at most %d alphanumeric characters, '-', '_' or '.', starting and ending with an alphanumeric character", value, key, labelNameMaxLength)
+	}
+	return nil
+}
+
+// validateKubeletLabelKey Verify that the kubelet is allowed to set label with the key on its own node
+func validateKubeletLabelKey(key string) error {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return nil
+	}
+	restricted := false
+	for _, namespace := range []string{"kubernetes.io", "k8s.io"} {
+		if prefix == namespace || strings.HasSuffix(prefix, "."+namespace) {
+			restricted = true
+		}
+	}
+	if !restricted {
+		return nil
+	}
+	for _, allowed := range kubeletAllowedLabelPrefixes {
+		if prefix == allowed || strings.HasSuffix(prefix, "."+allowed) {
+			return nil
+		}
+	}
+	for _, allowed := range kubeletAllowedLabels {
+		if key == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("label '%s' is in a namespace reserved for Kubernetes and cannot be set by the node itself", key)
+}
+
+// ParseNodeLabels Returns validated labels from comma separated 'key=value' pairs
+func ParseNodeLabels(spec string) ([]string, error) {
+	var labels []string
+	keys := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("label '%s' must be in form 'key=value'", entry)
+		}
+		if err := validateLabelKey(key); err != nil {
+			return nil, err
+		}
+		if err := validateLabelValue(key, value); err != nil {
+			return nil, err
+		}
+		if err := validateKubeletLabelKey(key); err != nil {
+			return nil, err
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("label '%s' is given more than once", key)
+		}
+		keys[key] = true
+		labels = append(labels, entry)
+	}
+	return labels, nil
+}
+
+// ParseNodeTaints Returns validated taints from comma separated 'key[=value]:effect' entries
+func ParseNodeTaints(spec string) ([]string, error) {
+	var taints []string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyValue, effect, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("taint '%s' must be in form 'key[=value]:effect'", entry)
+		}
+		key, value, _ := strings.Cut(keyValue, "=")
+		if err := validateLabelKey(key); err != nil {
+			return nil, err
+		}
+		if err := validateLabelValue(key, value); err != nil {
+			return nil, err
+		}
+		valid := false
+		for _, taintEffect := range taintEffects {
+			if effect == taintEffect {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("effect '%s' of taint '%s' must be one of: %s", effect, entry, strings.Join(taintEffects, ", "))
+		}
+		taints = append(taints, entry)
+	}
+	return taints, nil
+}
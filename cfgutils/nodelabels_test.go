@@ -0,0 +1,86 @@
+package cfgutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNodeLabels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		expected []string
+	}{
+		{name: "empty", spec: "", expected: nil},
+		{name: "simple", spec: "tier=gpu", expected: []string{"tier=gpu"}},
+		{name: "prefixed and empty value", spec: " example.com/team=ml , node.kubernetes.io/pool= ",
+			expected: []string{"example.com/team=ml", "node.kubernetes.io/pool="}},
+		{name: "well-known label", spec: "topology.kubernetes.io/zone=rack-1", expected: []string{"topology.kubernetes.io/zone=rack-1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, err := ParseNodeLabels(tc.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, labels)
+		})
+	}
+}
+
+func TestParseNodeLabels_Fail(t *testing.T) {
+	testCases := []struct {
+		name  string
+		spec  string
+		error string
+	}{
+		{name: "missing value", spec: "tier", error: "must be in form 'key=value'"},
+		{name: "invalid name", spec: "-tier=gpu", error: "name of key '-tier'"},
+		{name: "name too long", spec: strings.Repeat("a", 64) + "=gpu", error: "name of key"},
+		{name: "uppercase prefix", spec: "Example.com/team=ml", error: "prefix of key 'Example.com/team'"},
+		{name: "invalid value", spec: "tier=gpu!", error: "value 'gpu!' of key 'tier'"},
+		{name: "duplicate", spec: "tier=gpu,tier=cpu", error: "label 'tier' is given more than once"},
+		{name: "node role", spec: "node-role.kubernetes.io/worker=true", error: "reserved for Kubernetes"},
+		{name: "k8s.io namespace", spec: "k8s.io/team=ml", error: "reserved for Kubernetes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseNodeLabels(tc.spec)
+			assert.ErrorContains(t, err, tc.error)
+		})
+	}
+}
+
+func TestParseNodeTaints(t *testing.T) {
+	taints, err := ParseNodeTaints("nvidia.com/gpu=present:NoSchedule, dedicated:PreferNoSchedule,maintenance=:NoExecute")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nvidia.com/gpu=present:NoSchedule", "dedicated:PreferNoSchedule", "maintenance=:NoExecute"}, taints)
+
+	taints, err = ParseNodeTaints("")
+	require.NoError(t, err)
+	assert.Nil(t, taints)
+}
+
+func TestParseNodeTaints_Fail(t *testing.T) {
+	testCases := []struct {
+		name  string
+		spec  string
+		error string
+	}{
+		{name: "missing effect", spec: "dedicated=gpu", error: "must be in form 'key[=value]:effect'"},
+		{name: "unknown effect", spec: "dedicated=gpu:NoStart", error: "effect 'NoStart' of taint 'dedicated=gpu:NoStart' must be one of"},
+		{name: "invalid key", spec: "dedicated!:NoSchedule", error: "name of key 'dedicated!'"},
+		{name: "invalid value", spec: "dedicated=a b:NoSchedule", error: "value 'a b' of key 'dedicated'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseNodeTaints(tc.spec)
+			assert.ErrorContains(t, err, tc.error)
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package cfgutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Content types of user-data parts understood by cloud-init, keyed by the first line marker of the part
+var userDataPartTypes = []struct {
+	marker      string
+	contentType string
+}{
+	{marker: "#cloud-config", contentType: "text/cloud-config"},
+	{marker: "#cloud-boothook", contentType: "text/cloud-boothook"},
+	{marker: "#!", contentType: "text/x-shellscript"},
+}
+
+// userDataBoundary is fixed so the same parts always render to the same document
+const userDataBoundary = "fsas-userdata-boundary"
+
+const redacted = "[REDACTED]"
+
+// UserDataPart is a user-data document taking part in the user-data uploaded to the machine
+type UserDataPart struct {
+	Source  string // Origin of the part used in errors, e.g. "rancher"
+	Content string
+}
+
+// userDataContentType Returns content type of the part or error when cloud-init would not understand it
+func userDataContentType(part UserDataPart) (string, error) {
+	content := strings.TrimSpace(part.Content)
+	for _, partType := range userDataPartTypes {
+		if strings.HasPrefix(content, partType.marker) {
+			return partType.contentType, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported %s user-data: must start with '#cloud-config', '#cloud-boothook' or '#!'", part.Source)
+}
+
+// parseCloudConfig Returns top level mapping of the cloud-config document
+func parseCloudConfig(source, content string) (map[string]any, error) {
+	config := map[string]any{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, fmt.Errorf("invalid %s cloud-config: %w", source, err)
+	}
+	return config, nil
+}
+
+// mergeCloudConfig Merge src into dst: lists are appended, mappings merged recursively, other values of src win
+func mergeCloudConfig(dst, src map[string]any) {
+	for key, srcValue := range src {
+		switch srcTyped := srcValue.(type) {
+		case []any:
+			if dstList, ok := dst[key].([]any); ok {
+				dst[key] = append(dstList, srcTyped...)
+				continue
+			}
+		case map[string]any:
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				mergeCloudConfig(dstMap, srcTyped)
+				continue
+			}
+		}
+		dst[key] = srcValue
+	}
+}
+
+// renderCloudConfig Returns cloud-config document of the mapping
+func renderCloudConfig(config map[string]any) (string, error) {
+	var content bytes.Buffer
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return "#cloud-config\n" + content.String(), nil
+}
+
+/*
+MergeUserData Returns user-data merging given parts, ordered from the lowest precedence to the highest.
+Cloud-config parts are merged into one cloud-config: lists such as runcmd or write_files are concatenated
+in the order of parts, mappings are merged and any other value is taken from the part of higher precedence.
+When all parts are cloud-config the merged cloud-config is returned, otherwise a multipart MIME document
+with the merged cloud-config followed by the remaining parts in their order. Empty parts are skipped.
+*/
+func MergeUserData(parts ...UserDataPart) (string, error) {
+	merged := map[string]any{}
+	hasCloudConfig := false
+	var otherParts []UserDataPart
+	var otherTypes []string
+
+	for _, part := range parts {
+		if strings.TrimSpace(part.Content) == "" {
+			continue
+		}
+		contentType, err := userDataContentType(part)
+		if err != nil {
+			return "", err
+		}
+		if contentType != "text/cloud-config" {
+			otherParts = append(otherParts, part)
+			otherTypes = append(otherTypes, contentType)
+			continue
+		}
+
+		config, err := parseCloudConfig(part.Source, part.Content)
+		if err != nil {
+			return "", err
+		}
+		mergeCloudConfig(merged, config)
+		hasCloudConfig = true
+	}
+
+	var cloudConfig string
+	if hasCloudConfig {
+		var err error
+		if cloudConfig, err = renderCloudConfig(merged); err != nil {
+			return "", err
+		}
+	}
+	if len(otherParts) == 0 {
+		return cloudConfig, nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(userDataBoundary); err != nil {
+		return "", err
+	}
+	if hasCloudConfig {
+		if err := writeUserDataPart(writer, "text/cloud-config", "cloud-config.txt", cloudConfig); err != nil {
+			return "", err
+		}
+	}
+	for idx, part := range otherParts {
+		fileName := fmt.Sprintf("%s-%d.txt", part.Source, idx)
+		if err := writeUserDataPart(writer, otherTypes[idx], fileName, part.Content); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	header := fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\nMIME-Version: 1.0\n\n", userDataBoundary)
+	return header + body.String(), nil
+}
+
+// writeUserDataPart Add part of given content type to the multipart user-data
+func writeUserDataPart(writer *multipart.Writer, contentType, fileName, content string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+`; charset="utf-8"`)
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	partWriter, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(partWriter, content)
+	return err
+}
+
+// userDataDocument is a single document of user-data, either the whole user-data or one part of multipart user-data
+type userDataDocument struct {
+	contentType string
+	content     string
+}
+
+// splitUserData Returns documents of the user-data; multipart user-data is split into its parts
+func splitUserData(userData string) ([]userDataDocument, error) {
+	if !strings.HasPrefix(strings.TrimSpace(userData), "Content-Type:") {
+		contentType, err := userDataContentType(UserDataPart{Source: "merged", Content: userData})
+		if err != nil {
+			return nil, err
+		}
+		return []userDataDocument{{contentType: contentType, content: userData}}, nil
+	}
+
+	headerEnd := strings.Index(userData, "\n\n")
+	if headerEnd < 0 {
+		return nil, errors.New("invalid multipart user-data: missing end of header")
+	}
+	headerLine, _, _ := strings.Cut(strings.TrimSpace(userData), "\n")
+	mediaType, params, err := mime.ParseMediaType(strings.TrimPrefix(headerLine, "Content-Type:"))
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+		return nil, fmt.Errorf("invalid multipart user-data header '%s'", headerLine)
+	}
+
+	var documents []userDataDocument
+	reader := multipart.NewReader(strings.NewReader(userData[headerEnd+2:]), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart user-data: %w", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart user-data: %w", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		documents = append(documents, userDataDocument{contentType: contentType, content: string(content)})
+	}
+	if len(documents) == 0 {
+		return nil, errors.New("invalid multipart user-data: no parts")
+	}
+	return documents, nil
+}
+
+// ValidateUserData Verify that cloud-init can process the user-data: every part has a known type
+// and every cloud-config is a valid YAML mapping
+func ValidateUserData(userData string) error {
+	documents, err := splitUserData(userData)
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		switch document.contentType {
+		case "text/cloud-config":
+			if _, err := parseCloudConfig("merged", document.content); err != nil {
+				return err
+			}
+		case "text/cloud-boothook", "text/x-shellscript":
+		default:
+			return fmt.Errorf("unsupported user-data part of type '%s'", document.contentType)
+		}
+	}
+	return nil
+}
+
+// Keys of cloud-config whose values are replaced when user-data is logged
+var secretCloudConfigKey = regexp.MustCompile(`(?i)(passw|secret|token|private|regcode|registration_code|content)`)
+
+// Secrets passed on command lines of scripts, e.g. "--token abc" or "CATTLE_TOKEN=abc"
+var secretScriptArgument = regexp.MustCompile(`(?i)((?:passw\w*|secret\w*|token\w*)(?:=|:\s*|\s+))("[^"]*"|'[^']*'|\S+)`)
+
+// RedactUserData Returns rendering of the user-data for logs with secrets removed: values of cloud-config keys
+// which may carry secrets, including content of write_files, and secret arguments of commands and scripts
+func RedactUserData(userData string) string {
+	documents, err := splitUserData(userData)
+	if err != nil {
+		return redacted
+	}
+
+	var rendered []string
+	for _, document := range documents {
+		switch document.contentType {
+		case "text/cloud-config":
+			config, err := parseCloudConfig("merged", document.content)
+			if err != nil {
+				rendered = append(rendered, redacted)
+				continue
+			}
+			redactCloudConfig(config)
+			content, err := renderCloudConfig(config)
+			if err != nil {
+				rendered = append(rendered, redacted)
+				continue
+			}
+			rendered = append(rendered, content)
+		default:
+			rendered = append(rendered, secretScriptArgument.ReplaceAllString(document.content, "${1}"+redacted))
+		}
+	}
+	return strings.Join(rendered, "\n--- next part ---\n")
+}
+
+// redactCloudConfig Replace secret values of the mapping in place
+func redactCloudConfig(config map[string]any) {
+	for key, value := range config {
+		if secretCloudConfigKey.MatchString(key) {
+			config[key] = redacted
+			continue
+		}
+		config[key] = redactCloudConfigValue(value)
+	}
+}
+
+func redactCloudConfigValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		redactCloudConfig(typed)
+		return typed
+	case []any:
+		for idx := range typed {
+			typed[idx] = redactCloudConfigValue(typed[idx])
+		}
+		return typed
+	case string:
+		return secretScriptArgument.ReplaceAllString(typed, "${1}"+redacted)
+	default:
+		return value
+	}
+}
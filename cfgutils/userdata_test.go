@@ -0,0 +1,94 @@
+package cfgutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeUserData_CloudConfigOnly(t *testing.T) {
+	userData, err := MergeUserData(
+		UserDataPart{Source: "driver", Content: "#cloud-config\nssh_pwauth: false\nchpasswd:\n  expire: false\n"},
+		UserDataPart{Source: "custom", Content: ""},
+		UserDataPart{Source: "rancher", Content: "#cloud-config\nchpasswd:\n  list: |\n    rancher:secret\nruncmd:\n- sh install.sh\n"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "#cloud-config\nchpasswd:\n  expire: false\n  list: |\n    rancher:secret\nruncmd:\n  - sh install.sh\nssh_pwauth: false\n", userData)
+	assert.NoError(t, ValidateUserData(userData))
+}
+
+func TestMergeUserData_ListsAreConcatenatedInOrder(t *testing.T) {
+	userData, err := MergeUserData(
+		UserDataPart{Source: "custom", Content: "#cloud-config\nwrite_files:\n- path: /etc/a\nruncmd:\n- first\n"},
+		UserDataPart{Source: "rancher", Content: "#cloud-config\nwrite_files:\n- path: /etc/b\nruncmd:\n- second\n"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "#cloud-config\nruncmd:\n  - first\n  - second\nwrite_files:\n  - path: /etc/a\n  - path: /etc/b\n", userData)
+}
+
+func TestMergeUserData_Multipart(t *testing.T) {
+	userData, err := MergeUserData(
+		UserDataPart{Source: "driver", Content: "#cloud-config\nssh_pwauth: false\n"},
+		UserDataPart{Source: "custom", Content: "#!/bin/sh\necho custom\n"},
+		UserDataPart{Source: "rancher", Content: "#cloud-config\nruncmd:\n- sh install.sh\n"},
+	)
+
+	require.NoError(t, err)
+	assert.NoError(t, ValidateUserData(userData))
+
+	documents, err := splitUserData(userData)
+	require.NoError(t, err)
+	assert.Equal(t, []userDataDocument{
+		{contentType: "text/cloud-config", content: "#cloud-config\nruncmd:\n  - sh install.sh\nssh_pwauth: false\n"},
+		{contentType: "text/x-shellscript", content: "#!/bin/sh\necho custom\n"},
+	}, documents)
+}
+
+func TestMergeUserData_Errors(t *testing.T) {
+	_, err := MergeUserData(UserDataPart{Source: "custom", Content: "packages: [jq]"})
+	assert.EqualError(t, err, "unsupported custom user-data: must start with '#cloud-config', '#cloud-boothook' or '#!'")
+
+	_, err = MergeUserData(UserDataPart{Source: "rancher", Content: "#cloud-config\n- not a mapping\n"})
+	assert.ErrorContains(t, err, "invalid rancher cloud-config")
+}
+
+func TestValidateUserData(t *testing.T) {
+	assert.NoError(t, ValidateUserData("#cloud-boothook\necho early\n"))
+	assert.ErrorContains(t, ValidateUserData("#cloud-config\nruncmd: [\n"), "invalid merged cloud-config")
+	assert.ErrorContains(t, ValidateUserData("Content-Type: text/plain\n\nhello"), "invalid multipart user-data header")
+	assert.ErrorContains(t, ValidateUserData("Content-Type: multipart/mixed; boundary=\"b\"\nMIME-Version: 1.0\n\n--b\nContent-Type: text/x-include-url\n\nhttp://example.com\n--b--\n"),
+		"unsupported user-data part of type 'text/x-include-url'")
+}
+
+func TestRedactUserData(t *testing.T) {
+	userData := "#cloud-config\n" +
+		"chpasswd:\n  list: |\n    rancher:secret\n" +
+		"runcmd:\n- curl -fsSL https://rancher.example.com/system-agent-install.sh | sh -s - --token abc123 --server https://rancher.example.com\n" +
+		"write_files:\n- path: /usr/local/custom_script/install.sh\n  content: CATTLE_TOKEN=abc123\n" +
+		"hostname: node-01\n"
+
+	redactedUserData := RedactUserData(userData)
+
+	assert.NotContains(t, redactedUserData, "abc123")
+	assert.NotContains(t, redactedUserData, "rancher:secret")
+	assert.Contains(t, redactedUserData, "--token [REDACTED] --server https://rancher.example.com")
+	assert.Contains(t, redactedUserData, "path: /usr/local/custom_script/install.sh")
+	assert.Contains(t, redactedUserData, "hostname: node-01")
+}
+
+func TestRedactUserData_Multipart(t *testing.T) {
+	userData, err := MergeUserData(
+		UserDataPart{Source: "custom", Content: "#!/bin/sh\nexport REGISTRY_PASSWORD=hunter2\n"},
+		UserDataPart{Source: "rancher", Content: "#cloud-config\nruncmd:\n- sh install.sh\n"},
+	)
+	require.NoError(t, err)
+
+	redactedUserData := RedactUserData(userData)
+
+	assert.NotContains(t, redactedUserData, "hunter2")
+	assert.Contains(t, redactedUserData, "REGISTRY_PASSWORD=[REDACTED]")
+	assert.Contains(t, redactedUserData, "- sh install.sh")
+}
@@ -0,0 +1,142 @@
+// fsas-operator runs maintenance operations on machines provisioned by the FSAS node driver
+// outside of Rancher, using the driver state saved in the machine's docker-machine config.json.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/pkg/drivers/fsas"
+)
+
+const usage = `Usage: fsas-operator <command> --config <path to machine config.json>
+
+Commands:
+  reimage  Reinstall the OS of the machine in place, keeping its Fabric Manager machine
+  state    Print the state of the machine together with its CDI state
+
+Driver options saved in the config, e.g. the credentials, can be overridden by their FSAS_* environment variables.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run Execute the command given by args and print its result to out
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("missing command\n" + usage)
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configPath := flags.String("config", "", "path to config.json of the machine in the docker-machine store")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w\n%s", err, usage)
+	}
+	if *configPath == "" {
+		return errors.New("--config is required\n" + usage)
+	}
+
+	switch command {
+	case "reimage":
+		return reimage(*configPath, out)
+	case "state":
+		return printState(*configPath, out)
+	default:
+		return fmt.Errorf("unknown command '%s'\n%s", command, usage)
+	}
+}
+
+// reimage Reimage the machine and save the updated driver state back to its config
+func reimage(configPath string, out io.Writer) error {
+	config, driver, err := loadMachineConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	reimageErr := driver.Reimage()
+
+	// Driver state changes even when reimage fails, e.g. the last completed phase
+	if err := saveMachineConfig(configPath, config, driver); err != nil {
+		return errors.Join(reimageErr, err)
+	}
+	if reimageErr != nil {
+		return reimageErr
+	}
+
+	fmt.Fprintf(out, "Machine %s reimaged\n", driver.MachineUUID)
+	return nil
+}
+
+// printState Print the extended state of the machine
+func printState(configPath string, out io.Writer) error {
+	_, driver, err := loadMachineConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	extendedState, err := driver.GetExtendedState()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, extendedState)
+	return nil
+}
+
+// loadMachineConfig Read machine config.json and the FSAS driver state stored in its "Driver" key
+func loadMachineConfig(configPath string) (map[string]json.RawMessage, *fsas.Driver, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read machine config: %w", err)
+	}
+
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("cannot parse machine config %s: %w", configPath, err)
+	}
+
+	var driverName string
+	if err := json.Unmarshal(config["DriverName"], &driverName); err != nil || driverName != "fsas" {
+		return nil, nil, fmt.Errorf("machine config %s does not belong to the fsas driver", configPath)
+	}
+
+	driver := fsas.NewDriver()
+	if err := json.Unmarshal(config["Driver"], driver); err != nil {
+		return nil, nil, err
+	}
+	return config, driver, nil
+}
+
+// saveMachineConfig Write driver state back to machine config.json keeping the other keys untouched
+func saveMachineConfig(configPath string, config map[string]json.RawMessage, driver *fsas.Driver) error {
+	driverData, err := json.Marshal(driver)
+	if err != nil {
+		return err
+	}
+	config["Driver"] = driverData
+
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted write never leaves a truncated config
+	tmpPath := configPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("cannot write machine config: %w", err)
+	}
+	if err := os.Rename(tmpPath, configPath); err != nil {
+		return fmt.Errorf("cannot write machine config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const machineConfig = `{
+    "ConfigVersion": 3,
+    "Driver": {
+        "MachineName": "node-01",
+        "StorePath": "/var/lib/rancher/machine",
+        "MachineUUID": "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+        "TenantUuid": "4a9587f0-e7da-4824-8127-d5ca5ddf8c34",
+        "OsImageName": "sles.img"
+    },
+    "DriverName": "fsas",
+    "Name": "node-01"
+}`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "no command", args: nil, wantErr: "missing command"},
+		{name: "no config", args: []string{"reimage"}, wantErr: "--config is required"},
+		{name: "unknown flag", args: []string{"reimage", "--force"}, wantErr: "flag provided but not defined: -force"},
+		{name: "unknown command", args: []string{"rebuild", "--config", "config.json"}, wantErr: "unknown command 'rebuild'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args, &bytes.Buffer{})
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorContains(t, err, "Usage: fsas-operator")
+		})
+	}
+}
+
+func TestLoadMachineConfig(t *testing.T) {
+	path := writeConfig(t, machineConfig)
+
+	_, driver, err := loadMachineConfig(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "node-01", driver.MachineName)
+	assert.Equal(t, "cdd792f2-5591-4c18-a8bd-1c39e55dedfa", driver.MachineUUID)
+	assert.Equal(t, "sles.img", driver.OsImageName)
+}
+
+func TestLoadMachineConfigOfOtherDriver(t *testing.T) {
+	path := writeConfig(t, `{"Driver": {"MachineName": "node-01"}, "DriverName": "amazonec2"}`)
+
+	_, _, err := loadMachineConfig(path)
+
+	assert.EqualError(t, err, "machine config "+path+" does not belong to the fsas driver")
+}
+
+func TestLoadMachineConfigMissingFile(t *testing.T) {
+	_, _, err := loadMachineConfig(filepath.Join(t.TempDir(), "config.json"))
+
+	assert.ErrorContains(t, err, "cannot read machine config")
+}
+
+func TestSaveMachineConfigKeepsOtherKeys(t *testing.T) {
+	path := writeConfig(t, machineConfig)
+	config, driver, err := loadMachineConfig(path)
+	require.NoError(t, err)
+
+	driver.IPAddress = "10.0.0.15"
+	require.NoError(t, saveMachineConfig(path, config, driver))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var saved map[string]any
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, "node-01", saved["Name"])
+	assert.Equal(t, float64(3), saved["ConfigVersion"])
+
+	_, reloaded, err := loadMachineConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.15", reloaded.IPAddress)
+	assert.Equal(t, driver.MachineUUID, reloaded.MachineUUID)
+}
@@ -15,18 +15,25 @@ import (
 
 const ErrMissingParams = "baseURI and port cannot be empty"
 
+// FabricConditionColumn is the compute condition column restricting fabric the machine is composed on
+const FabricConditionColumn = "fabric_uuid"
+
 var (
 	isInit                            = false
 	ErrBootStorageTags                = errors.New("mandatory field 'is_bootstorage' must be equal true in devices specification")
 	ErrBootStorageConditionNotFound   = errors.New("not found condition in device spec for bootable storage")
 	ErrSsdIdNotFound                  = errors.New("ssdId not found in resources")
 	ErrGetMachineUUIDFromPostResponse = errors.New("error while getting machine UUID from POST response")
+	ErrTenantNotFoundInResponse       = errors.New("tenant not found in GET /tenants response")
+	ErrMachineNotFoundInResponse      = errors.New("machine not found in GET /machines response")
+	ErrTenantQuotaExceeded            = errors.New("tenant quota exceeded")
 )
 
 // FabricManager interface defines the methods for interacting with the Fabric Manager.
 type FabricManager interface {
 	IsInit() bool
 	ValidateTenant(tenantId, bearerToken string) error
+	GetTenantQuota(tenantId, bearerToken string) ([]models.ResourceQuota, error)
 	PowerOn(machineUUID, tenantId, bearerToken string) error
 	PowerOff(machineUUID, tenantId, bearerToken string) error
 	GracefulShutdown(machineUUID, tenantId, bearerToken string) error
@@ -34,6 +41,8 @@ type FabricManager interface {
 	RemoveMachine(machineUUID, tenantId, bearerToken string) error
 	CreateMachine(machineName, tenantId string, machineSpecs models.MachineSpecsArgs, bearerToken string) (string, error)
 	GetMachineDetails(tenantId, machineUUID, bearerToken string) ([]models.Lanport, string, int, error)
+	GetMachine(tenantId, machineUUID, bearerToken string) (*models.MachineDetails, error)
+	ListMachines(tenantId, bearerToken string) ([]models.MachineDetails, error)
 }
 
 // FabricManagerClient struct holds configuration for Fabric Manager interaction.
@@ -84,6 +93,30 @@ func (fmc *FabricManagerClient) ValidateTenant(tenantId, bearerToken string) err
 	return nil
 }
 
+// GetTenantQuota Returns resource limits and current allocation of the tenant
+func (fmc *FabricManagerClient) GetTenantQuota(tenantId, bearerToken string) ([]models.ResourceQuota, error) {
+	endpoint := fmt.Sprintf("/tenants/%s", tenantId)
+
+	var responseData models.TenantsRequestResponse
+
+	queryParams := map[string]string{"tenant_uuid": tenantId}
+	headers := httputils.GetAuthorizationHeader(bearerToken)
+	if _, err := fmc.cdiClient.Get(endpoint, queryParams, &responseData, headers); err != nil {
+		slog.Error(fmt.Sprintf("Request GET %s failed: ", endpoint), "err", err)
+		return nil, err
+	}
+
+	for _, tenant := range responseData.Data.Tenants {
+		if tenant.TenantUUID == tenantId {
+			slog.Info("Successfully received tenant quota: ", "tenant_id", tenantId, "quotas", tenant.Quotas)
+			return tenant.Quotas, nil
+		}
+	}
+
+	slog.Error(ErrTenantNotFoundInResponse.Error()+";", "tenant_id", tenantId)
+	return nil, ErrTenantNotFoundInResponse
+}
+
 func (fmc *FabricManagerClient) PowerOn(machineUUID, tenantId, bearerToken string) error {
 
 	endpoint := fmt.Sprintf("/machines/%s/pon", machineUUID)
@@ -218,6 +251,7 @@ func (fmc *FabricManagerClient) populateCreateMachineRequest(machineName, tenant
 		slog.Error("Error unmarshalling compute conditions from JSON: ", "err", err)
 		return nil, err
 	}
+	computeConditions = append(computeConditions, machineSpecs.FabricConditions...)
 
 	subnets := []models.Subnet{
 		{
@@ -257,7 +291,9 @@ func (fmc *FabricManagerClient) populateCreateMachineRequest(machineName, tenant
 	machineName = strings.ReplaceAll(machineName, "-", "_")
 
 	machine := models.CreateMachineSpec{
-		Machine: machineName,
+		Machine:      machineName,
+		MachineOwner: machineSpecs.MachineOwner,
+		GroupUUID:    machineSpecs.GroupUUID,
 		Resources: []models.ResSpecs{
 			{
 				ResourceSpecifications: resourceSpecification,
@@ -343,6 +379,64 @@ func (fmc *FabricManagerClient) GetMachineDetails(tenantId, machineUUID, bearerT
 	return lanports, bootSsd, status, nil
 }
 
+// GetMachine Returns all details of the machine as reported by the Fabric Manager service
+func (fmc *FabricManagerClient) GetMachine(tenantId, machineUUID, bearerToken string) (*models.MachineDetails, error) {
+	endpoint := fmt.Sprintf("/machines/%s", machineUUID)
+
+	var responseData models.MachinesRequestResponse
+
+	queryParams := map[string]string{"tenant_uuid": tenantId}
+	headers := httputils.GetAuthorizationHeader(bearerToken)
+
+	if _, err := fmc.cdiClient.Get(endpoint, queryParams, &responseData, headers); err != nil {
+		slog.Error(fmt.Sprintf("Request GET %s failed: ", endpoint), "err", err)
+		return nil, err
+	}
+
+	if len(responseData.Data.Machines) == 0 {
+		slog.Error(ErrMachineNotFoundInResponse.Error()+";", "mach_uuid", machineUUID)
+		return nil, ErrMachineNotFoundInResponse
+	}
+	machine := responseData.Data.Machines[0]
+
+	slog.Info("Successfully received details of Machine: ",
+		"mach_uuid", machineUUID,
+		"mach_status", machine.MachineStatus,
+		"mach_owner", machine.MachineOwner,
+		"grp_uuid", machine.GroupUUID)
+
+	return &machine, nil
+}
+
+// ListMachines Returns all machines of the tenant
+func (fmc *FabricManagerClient) ListMachines(tenantId, bearerToken string) ([]models.MachineDetails, error) {
+	var responseData models.MachinesRequestResponse
+
+	queryParams := map[string]string{"tenant_uuid": tenantId}
+	headers := httputils.GetAuthorizationHeader(bearerToken)
+
+	if _, err := fmc.cdiClient.Get("/machines", queryParams, &responseData, headers); err != nil {
+		slog.Error("Request GET /machines failed: ", "err", err)
+		return nil, err
+	}
+
+	slog.Info("Successfully listed machines of tenant: ", "tenant_id", tenantId, "count", len(responseData.Data.Machines))
+	return responseData.Data.Machines, nil
+}
+
+// MatchFabricConditions Returns true when fabric satisfies all fabric conditions ('eq' and 'ne' operators)
+func MatchFabricConditions(fabricUUID string, conditions []models.Condition) bool {
+	for _, c := range conditions {
+		if c.Column != FabricConditionColumn {
+			continue
+		}
+		if (c.Operator == "eq" && c.Value != fabricUUID) || (c.Operator == "ne" && c.Value == fabricUUID) {
+			return false
+		}
+	}
+	return true
+}
+
 // getSsdId Returns ssd id as UUID string and error
 func (fmc *FabricManagerClient) getSsdId(resource []models.Resource) (string, error) {
 	// Do not return error in case resource slice is empty (response after machine is deleted)
@@ -389,3 +483,45 @@ func CheckDeviceSpecJson(ds string) error {
 
 	return nil
 }
+
+// RequestedResources Returns number of resources per resource type requested for a single machine:
+// one compute resource and the resources listed in devices specification
+func RequestedResources(devicesSpecJson string) (map[string]int, error) {
+	var devicesSpec []models.Resource
+	if err := json.Unmarshal([]byte(devicesSpecJson), &devicesSpec); err != nil {
+		slog.Error("Error unmarshalling devices specification from JSON: ", "err", err, "deviceSpecJson", devicesSpecJson)
+		return nil, err
+	}
+
+	requested := map[string]int{"compute": 1}
+	for _, ds := range devicesSpec {
+		// FM allocates a single resource when res_num is omitted
+		requested[ds.ResourceType] += max(ds.ResourceNum, 1)
+	}
+	return requested, nil
+}
+
+// CheckTenantQuota Returns ErrTenantQuotaExceeded when resources requested in devices specification
+// do not fit into the tenant quota; resource types without quota are not limited
+func CheckTenantQuota(quotas []models.ResourceQuota, devicesSpecJson string) error {
+	requested, err := RequestedResources(devicesSpecJson)
+	if err != nil {
+		return err
+	}
+
+	var exceeded []string
+	for _, quota := range quotas {
+		num, ok := requested[quota.ResourceType]
+		if !ok || quota.Used+num <= quota.Limit {
+			continue
+		}
+		exceeded = append(exceeded, fmt.Sprintf("'%s' requested %d, in use %d of %d (available %d)",
+			quota.ResourceType, num, quota.Used, quota.Limit, max(quota.Limit-quota.Used, 0)))
+	}
+
+	if len(exceeded) > 0 {
+		slog.Error(ErrTenantQuotaExceeded.Error()+";", "exceeded", exceeded)
+		return fmt.Errorf("%w: %s", ErrTenantQuotaExceeded, strings.Join(exceeded, "; "))
+	}
+	return nil
+}
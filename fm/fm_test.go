@@ -16,6 +16,7 @@ import (
 	httputils "github.com/fujitsu/docker-machine-driver-fsas/httputils/mock"
 	"github.com/fujitsu/docker-machine-driver-fsas/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/stretchr/testify/require"
 )
@@ -826,3 +827,280 @@ func Test_getBootStorageCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTenantQuotaSuccess(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+
+	tenantId := "12345678-1234-1234-1234-123456789012"
+	expectedQuery := map[string]string{"tenant_uuid": tenantId}
+	expectedHeaders := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", models.AccessTokenExample)}
+	endpoint := fmt.Sprintf("/tenants/%s", tenantId)
+	expectedQuotas := []models.ResourceQuota{
+		{ResourceType: "compute", Limit: 4, Used: 3},
+		{ResourceType: "gpu", Limit: 8, Used: 0},
+	}
+
+	helperSetTenantDetails := func(endpoint string, queryParams map[string]string, responseAddress interface{}, headers map[string]string) {
+		resp := responseAddress.(*models.TenantsRequestResponse)
+		resp.Data.Tenants = []models.TenantDetails{{TenantUUID: tenantId, Quotas: expectedQuotas}}
+	}
+
+	mockClient.
+		EXPECT().
+		Get(endpoint, expectedQuery, &models.TenantsRequestResponse{}, expectedHeaders).
+		Run(helperSetTenantDetails).
+		Return(http.StatusOK, nil)
+
+	quotas, err := fmc.GetTenantQuota(tenantId, models.AccessTokenExample)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedQuotas, quotas)
+}
+
+func TestGetTenantQuotaTenantNotInResponse(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+
+	tenantId := "12345678-1234-1234-1234-123456789012"
+	endpoint := fmt.Sprintf("/tenants/%s", tenantId)
+	mockClient.EXPECT().Get(endpoint, mock.Anything, mock.Anything, mock.Anything).Return(http.StatusOK, nil)
+
+	quotas, err := fmc.GetTenantQuota(tenantId, models.AccessTokenExample)
+
+	assert.ErrorIs(t, err, ErrTenantNotFoundInResponse)
+	assert.Nil(t, quotas)
+}
+
+func TestGetTenantQuotaRequestFailed(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+
+	tenantId := "12345678-1234-1234-1234-123456789012"
+	endpoint := fmt.Sprintf("/tenants/%s", tenantId)
+	mockError := errors.New("Request GET /tenants/cdi-test failed")
+	mockClient.EXPECT().Get(endpoint, mock.Anything, mock.Anything, mock.Anything).Return(http.StatusNotFound, mockError)
+
+	quotas, err := fmc.GetTenantQuota(tenantId, models.AccessTokenExample)
+
+	assert.EqualError(t, err, "Request GET /tenants/cdi-test failed")
+	assert.Nil(t, quotas)
+}
+
+func TestRequestedResources(t *testing.T) {
+	requested, err := RequestedResources(models.DeviceSpecsValid)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"compute": 1, "storage": 2}, requested)
+
+	requested, err = RequestedResources(`[{"res_type":"gpu","res_num":4},{"res_type":"gpu"}]`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"compute": 1, "gpu": 5}, requested)
+
+	_, err = RequestedResources("not a json")
+	assert.Error(t, err)
+}
+
+func TestCheckTenantQuota(t *testing.T) {
+	testCases := []struct {
+		name      string
+		quotas    []models.ResourceQuota
+		errSubstr string
+	}{
+		{name: "no quotas",
+			quotas: nil},
+
+		{name: "fits exactly",
+			quotas: []models.ResourceQuota{{ResourceType: "compute", Limit: 4, Used: 3}, {ResourceType: "storage", Limit: 2, Used: 0}}},
+
+		{name: "quota of not requested resource type is ignored",
+			quotas: []models.ResourceQuota{{ResourceType: "gpu", Limit: 0, Used: 0}}},
+
+		{name: "compute exceeded",
+			quotas:    []models.ResourceQuota{{ResourceType: "compute", Limit: 4, Used: 4}},
+			errSubstr: "'compute' requested 1, in use 4 of 4 (available 0)"},
+
+		{name: "storage exceeded",
+			quotas:    []models.ResourceQuota{{ResourceType: "compute", Limit: 4, Used: 0}, {ResourceType: "storage", Limit: 10, Used: 9}},
+			errSubstr: "'storage' requested 2, in use 9 of 10 (available 1)"},
+
+		{name: "quota already overused",
+			quotas:    []models.ResourceQuota{{ResourceType: "storage", Limit: 1, Used: 3}},
+			errSubstr: "'storage' requested 2, in use 3 of 1 (available 0)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckTenantQuota(tc.quotas, models.DeviceSpecsValid)
+			if tc.errSubstr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrTenantQuotaExceeded)
+			assert.ErrorContains(t, err, tc.errSubstr)
+		})
+	}
+}
+
+func TestPopulateCreateMachineRequestWithOwnerAndGroup(t *testing.T) {
+	fmc := &FabricManagerClient{}
+
+	machineSpecsArgs := models.MachineSpecsArgs{
+		ComputeConditionsJson: `[{"column": "model","operator": "eq","value": "PRIMERGY-RX2540M6"}]`,
+		DevicesSpecJson:       models.DeviceSpecsValid,
+		NetworkProvisionUUID:  "5dc4769c-eef2-407f-b729-fec926ec9eda",
+		NetworkProvisionPort:  1,
+		MachineOwner:          "rancher:cluster-a-pool1",
+		GroupUUID:             "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e",
+	}
+
+	createMachineRequest, err := fmc.populateCreateMachineRequest("test-machine-001", "cdi-test", machineSpecsArgs)
+	require.NoError(t, err)
+
+	rawJSON, err := json.Marshal(createMachineRequest)
+	require.NoError(t, err)
+	assert.Contains(t, string(rawJSON), `"mach_name":"test_machine_001","mach_owner":"rancher:cluster-a-pool1","grp_uuid":"0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e"`)
+}
+
+func TestGetMachineSuccess(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+
+	tenantId := "cdi-test"
+	machineUUID := "a1b2c3d4-e5f6-7890-1234-567890abcdef"
+	expectedQuery := map[string]string{"tenant_uuid": tenantId}
+	expectedHeaders := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", models.AccessTokenExample)}
+	expectedEndpoint := fmt.Sprintf("/machines/%s", machineUUID)
+	expectedMachine := models.MachineDetails{
+		MachineUUID:   machineUUID,
+		MachineStatus: 13,
+		MachineOwner:  "rancher:cluster-a-pool1",
+		GroupUUID:     "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e",
+	}
+
+	helperSetMachine := func(endpoint string, queryParams map[string]string, responseAddress interface{}, headers map[string]string) {
+		resp := responseAddress.(*models.MachinesRequestResponse)
+		resp.Data.Machines = []models.MachineDetails{expectedMachine}
+	}
+
+	mockClient.
+		EXPECT().
+		Get(expectedEndpoint, expectedQuery, &models.MachinesRequestResponse{}, expectedHeaders).
+		Run(helperSetMachine).
+		Return(http.StatusOK, nil)
+
+	machine, err := fmc.GetMachine(tenantId, machineUUID, models.AccessTokenExample)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &expectedMachine, machine)
+}
+
+func TestGetMachineEmptyResponse(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+	mockClient.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(http.StatusOK, nil)
+
+	machine, err := fmc.GetMachine("cdi-test", "a1b2c3d4-e5f6-7890-1234-567890abcdef", models.AccessTokenExample)
+
+	assert.ErrorIs(t, err, ErrMachineNotFoundInResponse)
+	assert.Nil(t, machine)
+}
+
+func TestGetMachineFailed(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+	mockError := errors.New("Request GET /machines failed")
+	mockClient.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(http.StatusNotFound, mockError)
+
+	machine, err := fmc.GetMachine("cdi-test", "a1b2c3d4-e5f6-7890-1234-567890abcdef", models.AccessTokenExample)
+
+	assert.EqualError(t, err, "Request GET /machines failed")
+	assert.Nil(t, machine)
+}
+
+func TestPopulateCreateMachineRequestWithFabricConditions(t *testing.T) {
+	fmc := &FabricManagerClient{}
+	fabricCondition := models.Condition{Column: FabricConditionColumn, Operator: "ne", Value: "58f4c0f8-6c74-4e86-a560-95ed13daaa46"}
+
+	machineSpecsArgs := models.MachineSpecsArgs{
+		ComputeConditionsJson: `[{"column": "model","operator": "eq","value": "PRIMERGY-RX2540M6"}]`,
+		DevicesSpecJson:       models.DeviceSpecsValid,
+		NetworkProvisionUUID:  "5dc4769c-eef2-407f-b729-fec926ec9eda",
+		NetworkProvisionPort:  1,
+		FabricConditions:      []models.Condition{fabricCondition},
+	}
+
+	createMachineRequest, err := fmc.populateCreateMachineRequest("test-machine-001", "cdi-test", machineSpecsArgs)
+	require.NoError(t, err)
+
+	compute := createMachineRequest.Tenants.Machines[0].Resources[0].ResourceSpecifications[0]
+	assert.Equal(t, "compute", compute.ResourceType)
+	assert.Equal(t, []models.Condition{{Column: "model", Operator: "eq", Value: "PRIMERGY-RX2540M6"}, fabricCondition}, compute.ResourceSpec.Condition)
+}
+
+func TestListMachinesSuccess(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+
+	tenantId := "cdi-test"
+	expectedQuery := map[string]string{"tenant_uuid": tenantId}
+	expectedHeaders := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", models.AccessTokenExample)}
+	expectedMachines := []models.MachineDetails{
+		{MachineUUID: "a1b2c3d4-e5f6-7890-1234-567890abcdef", FabricUUID: "58f4c0f8-6c74-4e86-a560-95ed13daaa46"},
+		{MachineUUID: "b1b2c3d4-e5f6-7890-1234-567890abcdef", FabricUUID: "68f4c0f8-6c74-4e86-a560-95ed13daaa46"},
+	}
+
+	helperSetMachines := func(endpoint string, queryParams map[string]string, responseAddress interface{}, headers map[string]string) {
+		resp := responseAddress.(*models.MachinesRequestResponse)
+		resp.Data.Machines = expectedMachines
+	}
+
+	mockClient.
+		EXPECT().
+		Get("/machines", expectedQuery, &models.MachinesRequestResponse{}, expectedHeaders).
+		Run(helperSetMachines).
+		Return(http.StatusOK, nil)
+
+	machines, err := fmc.ListMachines(tenantId, models.AccessTokenExample)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMachines, machines)
+}
+
+func TestListMachinesFailed(t *testing.T) {
+	mockClient := httputils.NewMockCdiHTTPClient(t)
+	fmc := &FabricManagerClient{cdiClient: mockClient}
+	mockError := errors.New("Request GET /machines failed")
+	mockClient.EXPECT().Get("/machines", mock.Anything, mock.Anything, mock.Anything).Return(http.StatusInternalServerError, mockError)
+
+	machines, err := fmc.ListMachines("cdi-test", models.AccessTokenExample)
+
+	assert.EqualError(t, err, "Request GET /machines failed")
+	assert.Nil(t, machines)
+}
+
+func TestMatchFabricConditions(t *testing.T) {
+	fabricA := "58f4c0f8-6c74-4e86-a560-95ed13daaa46"
+	fabricB := "68f4c0f8-6c74-4e86-a560-95ed13daaa46"
+
+	testCases := []struct {
+		name       string
+		conditions []models.Condition
+		expected   bool
+	}{
+		{name: "no conditions", conditions: nil, expected: true},
+		{name: "other columns are ignored", conditions: []models.Condition{{Column: "model", Operator: "eq", Value: "x"}}, expected: true},
+		{name: "pinned to fabric", conditions: []models.Condition{{Column: FabricConditionColumn, Operator: "eq", Value: fabricA}}, expected: true},
+		{name: "pinned to other fabric", conditions: []models.Condition{{Column: FabricConditionColumn, Operator: "eq", Value: fabricB}}, expected: false},
+		{name: "other fabric excluded", conditions: []models.Condition{{Column: FabricConditionColumn, Operator: "ne", Value: fabricB}}, expected: true},
+		{name: "fabric excluded", conditions: []models.Condition{
+			{Column: FabricConditionColumn, Operator: "ne", Value: fabricB},
+			{Column: FabricConditionColumn, Operator: "ne", Value: fabricA},
+		}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MatchFabricConditions(fabricA, tc.conditions))
+		})
+	}
+}
@@ -0,0 +1,704 @@
+// Package fmfake provides an in-process Fabric Manager server for tests.
+//
+// The server speaks the same HTTP API as the production Fabric Manager (tenants, machines,
+// power endpoints and image installation), steps composed machines through the machine status
+// codes on a controllable clock and allows to inject faults, so the real fm.FabricManagerClient
+// and the whole driver lifecycle can be exercised without CDI hardware.
+package fmfake
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/fm"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+)
+
+// Machine status codes reported by Fabric Manager in field 'mach_status'.
+// They mirror the values of fsas.CdiMachineState.
+const (
+	StatusBuildingBeforeQueue = 10
+	StatusBuilding            = 11
+	StatusBooting             = 12
+	StatusActivePon           = 13
+	StatusPoweringOff         = 14
+	StatusActivePoff          = 15
+	StatusUnbuilding          = 16
+	StatusUnbuilded           = 17
+	StatusOsInstalling        = 18
+	StatusError               = 90
+)
+
+const (
+	// DefaultBasePath is the path under which the Fabric Manager API is served
+	DefaultBasePath = "/fabric_manager/api/v1"
+	// DefaultTransitionDelay is the time each intermediate machine status lasts
+	DefaultTransitionDelay = 5 * time.Second
+	// DefaultInstallDuration is the time a machine stays in OS installation
+	DefaultInstallDuration = 30 * time.Second
+	// DefaultFabricUUID is the fabric every machine is placed on unless configured otherwise
+	DefaultFabricUUID = "58f4c0f8-6c74-4e86-a560-95ed13daaa46"
+)
+
+// Options holds configuration of the fake server. Zero values are replaced with defaults.
+type Options struct {
+	BasePath        string
+	Clock           timeutils.Clock
+	TransitionDelay time.Duration
+	InstallDuration time.Duration
+	Fabrics         []string // UUIDs of fabrics machines can be composed on; defaults to DefaultFabricUUID
+}
+
+// Fault describes an injected failure of requests matching a method and path pattern.
+// StatusCode equal 0 means that only the delay is applied and the request is served normally.
+// Times equal 0 means that the fault applies to every matching request.
+type Fault struct {
+	StatusCode int
+	Message    string
+	Delay      time.Duration
+	Times      int
+}
+
+// Request is a record of a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+type faultRule struct {
+	method  string
+	pattern string
+	fault   Fault
+	hits    int
+}
+
+type transition struct {
+	status int
+	at     time.Time
+}
+
+type machine struct {
+	tenant   string
+	details  models.MachineDetails
+	pending  []transition
+	usage    map[string]int
+	stuck    bool
+	released bool
+}
+
+// Server is an in-process Fabric Manager
+type Server struct {
+	mu              sync.Mutex
+	httpServer      *httptest.Server
+	basePath        string
+	clock           timeutils.Clock
+	transitionDelay time.Duration
+	installDuration time.Duration
+	fabrics         []string
+	ignorePlacement bool
+	ignoreGraceful  bool
+	tenants         map[string]bool
+	quotas          map[string]map[string]int
+	machines        map[string]*machine
+	subnets         map[string]int
+	faults          []*faultRule
+	requests        []Request
+	sequence        int
+}
+
+// NewServer starts and returns a new fake Fabric Manager server. Call Close when done.
+func NewServer(opts Options) *Server {
+	s := &Server{
+		basePath:        opts.BasePath,
+		clock:           opts.Clock,
+		transitionDelay: opts.TransitionDelay,
+		installDuration: opts.InstallDuration,
+		fabrics:         opts.Fabrics,
+		tenants:         map[string]bool{},
+		quotas:          map[string]map[string]int{},
+		machines:        map[string]*machine{},
+		subnets:         map[string]int{},
+	}
+	if len(s.fabrics) == 0 {
+		s.fabrics = []string{DefaultFabricUUID}
+	}
+	if s.basePath == "" {
+		s.basePath = DefaultBasePath
+	}
+	if s.clock == nil {
+		s.clock = timeutils.NewRealClock()
+	}
+	if s.transitionDelay == 0 {
+		s.transitionDelay = DefaultTransitionDelay
+	}
+	if s.installDuration == 0 {
+		s.installDuration = DefaultInstallDuration
+	}
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL Returns the root URL of the server; use it as driver API URL
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// AddTenant registers tenants known to the server
+func (s *Server) AddTenant(tenantIds ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range tenantIds {
+		s.tenants[id] = true
+	}
+}
+
+// SetTenantQuota limits number of resources per resource type the tenant may allocate.
+// Resources held by machines which are not yet unbuilt count as used; nil limits remove the quota.
+func (s *Server) SetTenantQuota(tenantId string, limits map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotas[tenantId] = limits
+}
+
+// IgnorePlacement makes the server compose machines on the first fabric regardless of fabric
+// conditions in the compute specification, as a Fabric Manager not honouring placement does
+func (s *Server) IgnorePlacement(ignore bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ignorePlacement = ignore
+}
+
+// IgnoreGracefulShutdown makes the server accept graceful shutdown requests while the machine keeps running,
+// as an operating system not reacting to the shutdown signal does
+func (s *Server) IgnoreGracefulShutdown(ignore bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ignoreGraceful = ignore
+}
+
+// InjectFault makes requests with given method and path matching pattern fail or slow down.
+// The pattern is relative to the base path and uses path.Match syntax, e.g. "/machines/*/pon".
+func (s *Server) InjectFault(method, pattern string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &faultRule{method: method, pattern: pattern, fault: fault})
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// StickMachineState forces the machine into given status and keeps it there until UnstickMachine is called
+func (s *Server) StickMachineState(machineUUID string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.machines[machineUUID]; ok {
+		m.details.MachineStatus = status
+		m.pending = nil
+		m.stuck = true
+	}
+}
+
+// UnstickMachine releases the machine frozen by StickMachineState
+func (s *Server) UnstickMachine(machineUUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.machines[machineUUID]; ok {
+		m.stuck = false
+	}
+}
+
+// SetMachineState forces the machine into given status and drops its pending transitions
+func (s *Server) SetMachineState(machineUUID string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.machines[machineUUID]; ok {
+		m.details.MachineStatus = status
+		m.pending = nil
+	}
+}
+
+// Machine Returns a snapshot of the machine details as currently served
+func (s *Server) Machine(machineUUID string) (models.MachineDetails, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.machines[machineUUID]
+	if !ok {
+		return models.MachineDetails{}, false
+	}
+	m.advance(s.clock.Now())
+	return m.details, true
+}
+
+// MachineUUIDs Returns UUIDs of all machines ever created, including removed ones
+func (s *Server) MachineUUIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.machineUUIDs()
+}
+
+// machineUUIDs Returns UUIDs of all machines in order of creation
+func (s *Server) machineUUIDs() []string {
+	uuids := make([]string, 0, len(s.machines))
+	for uuid := range s.machines {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool {
+		return s.machines[uuids[i]].details.MachineID < s.machines[uuids[j]].details.MachineID
+	})
+	return uuids
+}
+
+// Requests Returns all requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// advance applies all transitions that are due at given time
+func (m *machine) advance(now time.Time) {
+	if m.stuck {
+		return
+	}
+	for len(m.pending) > 0 && !now.Before(m.pending[0].at) {
+		m.details.MachineStatus = m.pending[0].status
+		m.pending = m.pending[1:]
+	}
+	if m.details.MachineStatus == StatusUnbuilded && !m.released {
+		m.details.Lanports = nil
+		m.details.Resources = nil
+		m.details.BootSSD = ""
+		m.released = true
+	}
+}
+
+// schedule sets the machine status immediately and queues next ones, each lasting given time
+func (m *machine) schedule(now time.Time, status int, next []int, delay time.Duration) {
+	if m.stuck {
+		return
+	}
+	m.details.MachineStatus = status
+	m.pending = nil
+	at := now
+	for _, n := range next {
+		at = at.Add(delay)
+		m.pending = append(m.pending, transition{status: n, at: at})
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("reading request body: %s", err))
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, s.basePath) {
+		writeError(w, http.StatusNotFound, "unknown API path")
+		return
+	}
+	endpoint := strings.TrimPrefix(r.URL.Path, s.basePath)
+
+	fault := s.matchFault(r.Method, endpoint, Request{Method: r.Method, Path: endpoint, Query: r.URL.RawQuery, Body: string(body)})
+	if fault != nil {
+		if fault.Delay > 0 {
+			time.Sleep(fault.Delay)
+		}
+		if fault.StatusCode != 0 {
+			writeError(w, fault.StatusCode, fault.Message)
+			return
+		}
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") || r.Header.Get("Authorization") == "Bearer " {
+		writeError(w, http.StatusUnauthorized, "missing bearer token")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantId := r.URL.Query().Get("tenant_uuid")
+	if !s.tenants[tenantId] {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("tenant '%s' not found", tenantId))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "tenants" && r.Method == http.MethodGet:
+		s.handleGetTenant(w, parts[1], tenantId)
+	case len(parts) == 1 && parts[0] == "machines" && r.Method == http.MethodPost:
+		s.handleCreateMachine(w, tenantId, string(body))
+	case len(parts) == 1 && parts[0] == "machines" && r.Method == http.MethodGet:
+		s.handleListMachines(w, tenantId)
+	case len(parts) == 2 && parts[0] == "machines" && r.Method == http.MethodGet:
+		s.handleGetMachine(w, tenantId, parts[1])
+	case len(parts) == 2 && parts[0] == "machines" && r.Method == http.MethodDelete:
+		s.handleDeleteMachine(w, tenantId, parts[1])
+	case len(parts) == 3 && parts[0] == "machines" && r.Method == http.MethodPut:
+		s.handlePower(w, tenantId, parts[1], parts[2])
+	case len(parts) == 3 && parts[0] == "resources" && parts[2] == "imginstall" && r.Method == http.MethodPut:
+		s.handleImageInstall(w, tenantId, parts[1], string(body))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, endpoint))
+	}
+}
+
+// matchFault records the request and returns the first fault matching it, if any
+func (s *Server) matchFault(method, endpoint string, request Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request)
+
+	for _, rule := range s.faults {
+		if rule.method != method {
+			continue
+		}
+		if ok, _ := path.Match(rule.pattern, endpoint); !ok {
+			continue
+		}
+		if rule.fault.Times > 0 && rule.hits >= rule.fault.Times {
+			continue
+		}
+		rule.hits++
+		fault := rule.fault
+		return &fault
+	}
+	return nil
+}
+
+func (s *Server) handleGetTenant(w http.ResponseWriter, tenantId, queryTenantId string) {
+	if tenantId != queryTenantId {
+		writeError(w, http.StatusForbidden, "tenant in path and query differ")
+		return
+	}
+	tenant := models.TenantDetails{TenantUUID: tenantId}
+	usage := s.tenantUsage(tenantId)
+	for _, resType := range sortedKeys(s.quotas[tenantId]) {
+		tenant.Quotas = append(tenant.Quotas, models.ResourceQuota{
+			ResourceType: resType,
+			Limit:        s.quotas[tenantId][resType],
+			Used:         usage[resType],
+		})
+	}
+	writeJSON(w, http.StatusOK, models.TenantsRequestResponse{Data: models.TenantsResponseData{Tenants: []models.TenantDetails{tenant}}})
+}
+
+// tenantUsage Returns number of resources per resource type held by machines of the tenant
+func (s *Server) tenantUsage(tenantId string) map[string]int {
+	now := s.clock.Now()
+	usage := map[string]int{}
+	for _, m := range s.machines {
+		if m.tenant != tenantId {
+			continue
+		}
+		m.advance(now)
+		if m.released {
+			continue
+		}
+		for resType, num := range m.usage {
+			usage[resType] += num
+		}
+	}
+	return usage
+}
+
+// quotaExceeded Returns resource type whose quota would be exceeded by requested machines, or empty string
+func (s *Server) quotaExceeded(tenantId string, machines []models.CreateMachineSpec) string {
+	limits := s.quotas[tenantId]
+	if len(limits) == 0 {
+		return ""
+	}
+	usage := s.tenantUsage(tenantId)
+	for _, spec := range machines {
+		for resType, num := range requestedUsage(spec) {
+			usage[resType] += num
+		}
+	}
+	for _, resType := range sortedKeys(limits) {
+		if usage[resType] > limits[resType] {
+			return resType
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// requestedUsage Returns number of resources per resource type requested for the machine
+func requestedUsage(spec models.CreateMachineSpec) map[string]int {
+	usage := map[string]int{}
+	for _, specs := range spec.Resources {
+		for _, res := range specs.ResourceSpecifications {
+			usage[res.ResourceType] += max(res.ResourceNum, 1)
+		}
+	}
+	return usage
+}
+
+func (s *Server) handleCreateMachine(w http.ResponseWriter, tenantId, body string) {
+	var request models.CreateMachineRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+	if request.Tenants.TenantUUID != tenantId || len(request.Tenants.Machines) == 0 {
+		writeError(w, http.StatusBadRequest, "tenant or machines missing in request body")
+		return
+	}
+
+	if resType := s.quotaExceeded(tenantId, request.Tenants.Machines); resType != "" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("quota exceeded for resource type '%s'", resType))
+		return
+	}
+
+	now := s.clock.Now()
+	created := []models.MachineDetails{}
+	for _, spec := range request.Tenants.Machines {
+		fabricIdx := s.placeMachine(spec)
+		if fabricIdx < 0 {
+			writeError(w, http.StatusConflict, "no fabric satisfies placement conditions")
+			return
+		}
+		s.sequence++
+		m := &machine{
+			tenant: tenantId,
+			details: models.MachineDetails{
+				FabricUUID:   s.fabrics[fabricIdx],
+				FabricID:     fabricIdx + 1,
+				MachineUUID:  newUUID(),
+				MachineID:    s.sequence,
+				MachineName:  spec.Machine,
+				MachineOwner: spec.MachineOwner,
+				GroupUUID:    spec.GroupUUID,
+			},
+			usage: requestedUsage(spec),
+		}
+		for _, specs := range spec.Resources {
+			for idx, res := range specs.ResourceSpecifications {
+				res.ResourceUUID = newUUID()
+				res.ResourceName = fmt.Sprintf("%s-%02d", res.ResourceType, idx+1)
+				res.ResourceNum = 0
+				res.ResourceStatus = 1
+				if res.Network != nil {
+					m.details.Lanports = append(m.details.Lanports, s.allocateLanports(res.Network.Subnets)...)
+				}
+				m.details.Resources = append(m.details.Resources, res)
+			}
+		}
+		m.schedule(now, StatusBuildingBeforeQueue, []int{StatusBuilding, StatusActivePoff}, s.transitionDelay)
+		s.machines[m.details.MachineUUID] = m
+		created = append(created, models.MachineDetails{
+			MachineUUID:   m.details.MachineUUID,
+			MachineName:   m.details.MachineName,
+			MachineStatus: m.details.MachineStatus,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, models.MachinesRequestResponse{Data: models.MachinesResponseData{Machines: created}})
+}
+
+// placeMachine Returns index of the first fabric satisfying fabric conditions of compute resources, or -1
+func (s *Server) placeMachine(spec models.CreateMachineSpec) int {
+	if s.ignorePlacement {
+		return 0
+	}
+	var conditions []models.Condition
+	for _, specs := range spec.Resources {
+		for _, res := range specs.ResourceSpecifications {
+			if res.ResourceType == "compute" && res.ResourceSpec != nil {
+				conditions = append(conditions, res.ResourceSpec.Condition...)
+			}
+		}
+	}
+	for idx, fabricUUID := range s.fabrics {
+		if fm.MatchFabricConditions(fabricUUID, conditions) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// allocateLanports Returns one lanport with a unique MAC and IP address for each subnet
+func (s *Server) allocateLanports(subnets []models.Subnet) []models.Lanport {
+	lanports := []models.Lanport{}
+	for _, subnet := range subnets {
+		idx, ok := s.subnets[subnet.SubnetUUID]
+		if !ok {
+			idx = len(s.subnets) + 1
+			s.subnets[subnet.SubnetUUID] = idx
+		}
+		lanports = append(lanports, models.Lanport{
+			LanportUUID: newUUID(),
+			SubnetUUID:  subnet.SubnetUUID,
+			MacAddress:  fmt.Sprintf("02:fa:%02x:%02x:%02x:%02x", idx, subnet.LanportIdx, s.sequence/256, s.sequence%256),
+			LanportIdx:  subnet.LanportIdx,
+			IPAddress:   fmt.Sprintf("10.%d.%d.%d", idx, s.sequence/250, s.sequence%250+1),
+		})
+	}
+	return lanports
+}
+
+// lookupMachine Returns the machine of given tenant with pending transitions applied
+func (s *Server) lookupMachine(w http.ResponseWriter, tenantId, machineUUID string) *machine {
+	m, ok := s.machines[machineUUID]
+	if !ok || m.tenant != tenantId {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("machine '%s' not found", machineUUID))
+		return nil
+	}
+	m.advance(s.clock.Now())
+	return m
+}
+
+func (s *Server) handleGetMachine(w http.ResponseWriter, tenantId, machineUUID string) {
+	m := s.lookupMachine(w, tenantId, machineUUID)
+	if m == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, models.MachinesRequestResponse{Data: models.MachinesResponseData{Machines: []models.MachineDetails{m.details}}})
+}
+
+func (s *Server) handleListMachines(w http.ResponseWriter, tenantId string) {
+	now := s.clock.Now()
+	machines := []models.MachineDetails{}
+	for _, uuid := range s.machineUUIDs() {
+		m := s.machines[uuid]
+		if m.tenant != tenantId {
+			continue
+		}
+		m.advance(now)
+		machines = append(machines, m.details)
+	}
+	writeJSON(w, http.StatusOK, models.MachinesRequestResponse{Data: models.MachinesResponseData{Machines: machines}})
+}
+
+func (s *Server) handleDeleteMachine(w http.ResponseWriter, tenantId, machineUUID string) {
+	m := s.lookupMachine(w, tenantId, machineUUID)
+	if m == nil {
+		return
+	}
+	if m.details.MachineStatus == StatusUnbuilded || m.details.MachineStatus == StatusUnbuilding {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("machine '%s' already removed", machineUUID))
+		return
+	}
+	m.schedule(s.clock.Now(), StatusUnbuilding, []int{StatusUnbuilded}, s.transitionDelay)
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) handlePower(w http.ResponseWriter, tenantId, machineUUID, action string) {
+	m := s.lookupMachine(w, tenantId, machineUUID)
+	if m == nil {
+		return
+	}
+	now := s.clock.Now()
+	switch action {
+	case "pon":
+		if m.details.MachineStatus != StatusActivePoff {
+			writeConflict(w, action, m.details.MachineStatus)
+			return
+		}
+		m.schedule(now, StatusBooting, []int{StatusActivePon}, s.transitionDelay)
+	case "poff", "graceful":
+		if m.details.MachineStatus != StatusActivePon && m.details.MachineStatus != StatusBooting {
+			writeConflict(w, action, m.details.MachineStatus)
+			return
+		}
+		if action == "graceful" && s.ignoreGraceful {
+			break
+		}
+		m.schedule(now, StatusPoweringOff, []int{StatusActivePoff}, s.transitionDelay)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown machine action '%s'", action))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) handleImageInstall(w http.ResponseWriter, tenantId, ssdId, body string) {
+	var installation models.ImageInstallation
+	if err := json.Unmarshal([]byte(body), &installation); err != nil || installation.Resources.BootImageName == "" {
+		writeError(w, http.StatusBadRequest, "invalid image installation request")
+		return
+	}
+
+	for _, m := range s.machines {
+		if m.tenant != tenantId {
+			continue
+		}
+		m.advance(s.clock.Now())
+		for _, res := range m.details.Resources {
+			if res.ResourceUUID != ssdId || res.ResourceType != "storage" {
+				continue
+			}
+			if m.details.MachineStatus != StatusActivePoff {
+				writeConflict(w, "imginstall", m.details.MachineStatus)
+				return
+			}
+			m.details.BootSSD = ssdId
+			m.schedule(s.clock.Now(), StatusOsInstalling, []int{StatusActivePoff}, s.installDuration)
+			writeJSON(w, http.StatusOK, map[string]any{})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("storage resource '%s' not found", ssdId))
+}
+
+func writeConflict(w http.ResponseWriter, action string, status int) {
+	writeError(w, http.StatusConflict, fmt.Sprintf("action '%s' not allowed in machine status %d", action, status))
+}
+
+// writeError writes an error body shaped like the one returned by Fabric Manager
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]any{
+		"status": statusCode,
+		"detail": map[string]any{
+			"code":    fmt.Sprintf("E%06d", statusCode),
+			"message": message,
+			"data":    map[string]any{},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newUUID Returns random UUID version 4 string
+func newUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,254 @@
+package fmfake
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/fm"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTenant      = "4a9587f0-e7da-4824-8127-d5ca5ddf8c34"
+	testToken       = "token"
+	testDevicesSpec = `[{"res_type":"storage","res_num":1,"tags":{"is_bootstorage":true},"res_spec":{"condition":[{"column":"vendor","operator":"eq","value":"samsung"}]}}]`
+)
+
+func TestMain(m *testing.M) {
+	originalLogger := slog.Default()
+	// Suppress slog output in test
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	exitCode := m.Run()
+
+	slog.SetDefault(originalLogger)
+	os.Exit(exitCode)
+}
+
+func newTestServer(t *testing.T) (*Server, *timeutils.ManualClock, *fm.FabricManagerClient) {
+	clock := timeutils.NewManualClock(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	server := NewServer(Options{Clock: clock})
+	t.Cleanup(server.Close)
+	server.AddTenant(testTenant)
+
+	fmc, err := fm.NewFabricManagerClient(server.URL(), DefaultBasePath, testDevicesSpec)
+	require.NoError(t, err)
+	return server, clock, fmc
+}
+
+func testMachineSpecs() models.MachineSpecsArgs {
+	return models.MachineSpecsArgs{
+		ComputeConditionsJson:     `[{"column":"model","operator":"eq","value":"PRIMERGY-RX2540M6"}]`,
+		DevicesSpecJson:           testDevicesSpec,
+		NetworkProvisionPort:      1,
+		NetworkProvisionUUID:      "5dc4769c-eef2-407f-b729-fec926ec9eda",
+		NetworkProvisionDefaultGW: "192.168.0.1",
+		NetworkBaremetalPort:      2,
+		NetworkBaremetalUUID:      "75e6b24f-c1cc-4009-a871-b5828a468f4f",
+		NtpServer:                 "192.168.0.1",
+		DnsServer:                 "8.8.8.8",
+	}
+}
+
+func TestValidateTenant(t *testing.T) {
+	_, _, fmc := newTestServer(t)
+
+	assert.NoError(t, fmc.ValidateTenant(testTenant, testToken))
+	assert.Error(t, fmc.ValidateTenant("unknown-tenant", testToken))
+	assert.Error(t, fmc.ValidateTenant(testTenant, ""))
+}
+
+func TestMachineLifecycle(t *testing.T) {
+	_, clock, fmc := newTestServer(t)
+
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	require.NoError(t, err)
+
+	lanports, bootSsd, status, err := fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	require.NoError(t, err)
+	assert.Equal(t, StatusBuildingBeforeQueue, status)
+	assert.NotEmpty(t, bootSsd)
+	require.Len(t, lanports, 2)
+	assert.Equal(t, "5dc4769c-eef2-407f-b729-fec926ec9eda", lanports[0].SubnetUUID)
+	assert.NotEmpty(t, lanports[0].IPAddress)
+	assert.NotEqual(t, lanports[0].MacAddress, lanports[1].MacAddress)
+
+	clock.Advance(DefaultTransitionDelay)
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusBuilding, status)
+
+	clock.Advance(DefaultTransitionDelay)
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusActivePoff, status)
+
+	require.NoError(t, fmc.ImageInstall(testTenant, bootSsd, "sles.img", testToken))
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusOsInstalling, status)
+
+	clock.Advance(DefaultInstallDuration)
+	require.NoError(t, fmc.PowerOn(machineUUID, testTenant, testToken))
+	clock.Advance(DefaultTransitionDelay)
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusActivePon, status)
+
+	require.NoError(t, fmc.GracefulShutdown(machineUUID, testTenant, testToken))
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusPoweringOff, status)
+	clock.Advance(DefaultTransitionDelay)
+
+	require.NoError(t, fmc.RemoveMachine(machineUUID, testTenant, testToken))
+	clock.Advance(DefaultTransitionDelay)
+	lanports, bootSsd, status, err = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnbuilded, status)
+	assert.Empty(t, lanports)
+	assert.Empty(t, bootSsd)
+}
+
+func TestPowerOnRejectedInWrongState(t *testing.T) {
+	_, _, fmc := newTestServer(t)
+
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	require.NoError(t, err)
+
+	// The machine is still being built
+	assert.ErrorContains(t, fmc.PowerOn(machineUUID, testTenant, testToken), "not allowed in machine status 10")
+}
+
+func TestInjectFault(t *testing.T) {
+	server, _, fmc := newTestServer(t)
+	server.InjectFault(http.MethodPost, "/machines", Fault{StatusCode: http.StatusInternalServerError, Message: "Exception occured", Times: 1})
+
+	_, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	assert.ErrorContains(t, err, "Exception occured")
+
+	// The fault was limited to a single request
+	_, err = fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	assert.NoError(t, err)
+
+	server.InjectFault(http.MethodPut, "/machines/*/pon", Fault{StatusCode: http.StatusServiceUnavailable})
+	assert.Error(t, fmc.PowerOn("any", testTenant, testToken))
+
+	server.ClearFaults()
+	assert.ErrorContains(t, fmc.PowerOn("any", testTenant, testToken), "not found")
+	assert.Len(t, server.Requests(), 4)
+}
+
+func TestInjectFaultDelay(t *testing.T) {
+	server, _, fmc := newTestServer(t)
+	delay := 50 * time.Millisecond
+	server.InjectFault(http.MethodGet, "/tenants/*", Fault{Delay: delay})
+
+	start := time.Now()
+	assert.NoError(t, fmc.ValidateTenant(testTenant, testToken))
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+}
+
+func TestStickMachineState(t *testing.T) {
+	server, clock, fmc := newTestServer(t)
+
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	require.NoError(t, err)
+	server.StickMachineState(machineUUID, StatusBuilding)
+
+	clock.Advance(time.Hour)
+	_, _, status, _ := fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusBuilding, status)
+
+	server.UnstickMachine(machineUUID)
+	server.SetMachineState(machineUUID, StatusError)
+	details, ok := server.Machine(machineUUID)
+	assert.True(t, ok)
+	assert.Equal(t, StatusError, details.MachineStatus)
+	assert.Equal(t, []string{machineUUID}, server.MachineUUIDs())
+}
+
+func TestIgnoreGracefulShutdown(t *testing.T) {
+	server, clock, fmc := newTestServer(t)
+	server.IgnoreGracefulShutdown(true)
+
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	require.NoError(t, err)
+	server.SetMachineState(machineUUID, StatusActivePon)
+
+	require.NoError(t, fmc.GracefulShutdown(machineUUID, testTenant, testToken))
+	clock.Advance(time.Hour)
+	_, _, status, _ := fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusActivePon, status)
+
+	require.NoError(t, fmc.PowerOff(machineUUID, testTenant, testToken))
+	clock.Advance(2 * DefaultTransitionDelay)
+	_, _, status, _ = fmc.GetMachineDetails(testTenant, machineUUID, testToken)
+	assert.Equal(t, StatusActivePoff, status)
+}
+
+func TestTenantQuota(t *testing.T) {
+	server, clock, fmc := newTestServer(t)
+
+	quotas, err := fmc.GetTenantQuota(testTenant, testToken)
+	require.NoError(t, err)
+	assert.Empty(t, quotas)
+
+	server.SetTenantQuota(testTenant, map[string]int{"compute": 1, "storage": 4})
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, testMachineSpecs(), testToken)
+	require.NoError(t, err)
+
+	quotas, err = fmc.GetTenantQuota(testTenant, testToken)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ResourceQuota{
+		{ResourceType: "compute", Limit: 1, Used: 1},
+		{ResourceType: "storage", Limit: 4, Used: 1},
+	}, quotas)
+	assert.ErrorIs(t, fm.CheckTenantQuota(quotas, testDevicesSpec), fm.ErrTenantQuotaExceeded)
+
+	_, err = fmc.CreateMachine("test-machine-002", testTenant, testMachineSpecs(), testToken)
+	assert.ErrorContains(t, err, "quota exceeded for resource type 'compute'")
+
+	// Resources are given back once the machine is unbuilt
+	clock.Advance(2 * DefaultTransitionDelay)
+	require.NoError(t, fmc.RemoveMachine(machineUUID, testTenant, testToken))
+	clock.Advance(DefaultTransitionDelay)
+	quotas, err = fmc.GetTenantQuota(testTenant, testToken)
+	require.NoError(t, err)
+	assert.NoError(t, fm.CheckTenantQuota(quotas, testDevicesSpec))
+}
+
+func TestPlacementAcrossFabrics(t *testing.T) {
+	fabricA := "58f4c0f8-6c74-4e86-a560-95ed13daaa46"
+	fabricB := "68f4c0f8-6c74-4e86-a560-95ed13daaa46"
+	server := NewServer(Options{Fabrics: []string{fabricA, fabricB}})
+	t.Cleanup(server.Close)
+	server.AddTenant(testTenant)
+	fmc, err := fm.NewFabricManagerClient(server.URL(), DefaultBasePath, testDevicesSpec)
+	require.NoError(t, err)
+
+	specs := testMachineSpecs()
+	specs.FabricConditions = []models.Condition{{Column: fm.FabricConditionColumn, Operator: "ne", Value: fabricA}}
+	machineUUID, err := fmc.CreateMachine("test-machine-001", testTenant, specs, testToken)
+	require.NoError(t, err)
+
+	machines, err := fmc.ListMachines(testTenant, testToken)
+	require.NoError(t, err)
+	require.Len(t, machines, 1)
+	assert.Equal(t, machineUUID, machines[0].MachineUUID)
+	assert.Equal(t, fabricB, machines[0].FabricUUID)
+	assert.Equal(t, 2, machines[0].FabricID)
+
+	specs.FabricConditions = append(specs.FabricConditions, models.Condition{Column: fm.FabricConditionColumn, Operator: "ne", Value: fabricB})
+	_, err = fmc.CreateMachine("test-machine-002", testTenant, specs, testToken)
+	assert.ErrorContains(t, err, "no fabric satisfies placement conditions")
+
+	server.IgnorePlacement(true)
+	machineUUID, err = fmc.CreateMachine("test-machine-002", testTenant, specs, testToken)
+	require.NoError(t, err)
+	machine, err := fmc.GetMachine(testTenant, machineUUID, testToken)
+	require.NoError(t, err)
+	assert.Equal(t, fabricA, machine.FabricUUID)
+}
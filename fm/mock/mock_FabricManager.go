@@ -79,6 +79,66 @@ func (_c *MockFabricManager_CreateMachine_Call) RunAndReturn(run func(string, st
 	return _c
 }
 
+// GetMachine provides a mock function with given fields: tenantId, machineUUID, bearerToken
+func (_m *MockFabricManager) GetMachine(tenantId string, machineUUID string, bearerToken string) (*models.MachineDetails, error) {
+	ret := _m.Called(tenantId, machineUUID, bearerToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMachine")
+	}
+
+	var r0 *models.MachineDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string) (*models.MachineDetails, error)); ok {
+		return rf(tenantId, machineUUID, bearerToken)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string) *models.MachineDetails); ok {
+		r0 = rf(tenantId, machineUUID, bearerToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MachineDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(tenantId, machineUUID, bearerToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFabricManager_GetMachine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMachine'
+type MockFabricManager_GetMachine_Call struct {
+	*mock.Call
+}
+
+// GetMachine is a helper method to define mock.On call
+//   - tenantId string
+//   - machineUUID string
+//   - bearerToken string
+func (_e *MockFabricManager_Expecter) GetMachine(tenantId interface{}, machineUUID interface{}, bearerToken interface{}) *MockFabricManager_GetMachine_Call {
+	return &MockFabricManager_GetMachine_Call{Call: _e.mock.On("GetMachine", tenantId, machineUUID, bearerToken)}
+}
+
+func (_c *MockFabricManager_GetMachine_Call) Run(run func(tenantId string, machineUUID string, bearerToken string)) *MockFabricManager_GetMachine_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockFabricManager_GetMachine_Call) Return(_a0 *models.MachineDetails, _a1 error) *MockFabricManager_GetMachine_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFabricManager_GetMachine_Call) RunAndReturn(run func(string, string, string) (*models.MachineDetails, error)) *MockFabricManager_GetMachine_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetMachineDetails provides a mock function with given fields: tenantId, machineUUID, bearerToken
 func (_m *MockFabricManager) GetMachineDetails(tenantId string, machineUUID string, bearerToken string) ([]models.Lanport, string, int, error) {
 	ret := _m.Called(tenantId, machineUUID, bearerToken)
@@ -153,6 +213,65 @@ func (_c *MockFabricManager_GetMachineDetails_Call) RunAndReturn(run func(string
 	return _c
 }
 
+// GetTenantQuota provides a mock function with given fields: tenantId, bearerToken
+func (_m *MockFabricManager) GetTenantQuota(tenantId string, bearerToken string) ([]models.ResourceQuota, error) {
+	ret := _m.Called(tenantId, bearerToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTenantQuota")
+	}
+
+	var r0 []models.ResourceQuota
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.ResourceQuota, error)); ok {
+		return rf(tenantId, bearerToken)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.ResourceQuota); ok {
+		r0 = rf(tenantId, bearerToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ResourceQuota)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(tenantId, bearerToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFabricManager_GetTenantQuota_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTenantQuota'
+type MockFabricManager_GetTenantQuota_Call struct {
+	*mock.Call
+}
+
+// GetTenantQuota is a helper method to define mock.On call
+//   - tenantId string
+//   - bearerToken string
+func (_e *MockFabricManager_Expecter) GetTenantQuota(tenantId interface{}, bearerToken interface{}) *MockFabricManager_GetTenantQuota_Call {
+	return &MockFabricManager_GetTenantQuota_Call{Call: _e.mock.On("GetTenantQuota", tenantId, bearerToken)}
+}
+
+func (_c *MockFabricManager_GetTenantQuota_Call) Run(run func(tenantId string, bearerToken string)) *MockFabricManager_GetTenantQuota_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockFabricManager_GetTenantQuota_Call) Return(_a0 []models.ResourceQuota, _a1 error) *MockFabricManager_GetTenantQuota_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFabricManager_GetTenantQuota_Call) RunAndReturn(run func(string, string) ([]models.ResourceQuota, error)) *MockFabricManager_GetTenantQuota_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GracefulShutdown provides a mock function with given fields: machineUUID, tenantId, bearerToken
 func (_m *MockFabricManager) GracefulShutdown(machineUUID string, tenantId string, bearerToken string) error {
 	ret := _m.Called(machineUUID, tenantId, bearerToken)
@@ -295,6 +414,65 @@ func (_c *MockFabricManager_IsInit_Call) RunAndReturn(run func() bool) *MockFabr
 	return _c
 }
 
+// ListMachines provides a mock function with given fields: tenantId, bearerToken
+func (_m *MockFabricManager) ListMachines(tenantId string, bearerToken string) ([]models.MachineDetails, error) {
+	ret := _m.Called(tenantId, bearerToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMachines")
+	}
+
+	var r0 []models.MachineDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) ([]models.MachineDetails, error)); ok {
+		return rf(tenantId, bearerToken)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) []models.MachineDetails); ok {
+		r0 = rf(tenantId, bearerToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.MachineDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(tenantId, bearerToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFabricManager_ListMachines_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMachines'
+type MockFabricManager_ListMachines_Call struct {
+	*mock.Call
+}
+
+// ListMachines is a helper method to define mock.On call
+//   - tenantId string
+//   - bearerToken string
+func (_e *MockFabricManager_Expecter) ListMachines(tenantId interface{}, bearerToken interface{}) *MockFabricManager_ListMachines_Call {
+	return &MockFabricManager_ListMachines_Call{Call: _e.mock.On("ListMachines", tenantId, bearerToken)}
+}
+
+func (_c *MockFabricManager_ListMachines_Call) Run(run func(tenantId string, bearerToken string)) *MockFabricManager_ListMachines_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockFabricManager_ListMachines_Call) Return(_a0 []models.MachineDetails, _a1 error) *MockFabricManager_ListMachines_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFabricManager_ListMachines_Call) RunAndReturn(run func(string, string) ([]models.MachineDetails, error)) *MockFabricManager_ListMachines_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PowerOff provides a mock function with given fields: machineUUID, tenantId, bearerToken
 func (_m *MockFabricManager) PowerOff(machineUUID string, tenantId string, bearerToken string) error {
 	ret := _m.Called(machineUUID, tenantId, bearerToken)
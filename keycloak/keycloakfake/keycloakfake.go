@@ -0,0 +1,375 @@
+// Package keycloakfake provides an in-process Keycloak (OIDC) server for tests.
+//
+// The server implements the token endpoint with the password and refresh_token grants and the
+// token introspection endpoint used by keycloak.KeycloakClient. Tokens are RS256-signed JWTs
+// with configurable lifetimes measured on a controllable clock, privileges returned in
+// 'pgcdi_privileges' are configurable per user and refresh tokens can be revoked or expired
+// to exercise the client's fallback paths against realistic server behaviour.
+package keycloakfake
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultBasePath is the path under which the Keycloak API is served
+	DefaultBasePath = "/id_manager"
+	// DefaultAccessTokenLifetime is the validity of issued access tokens
+	DefaultAccessTokenLifetime = 5 * time.Minute
+	// DefaultRefreshTokenLifetime is the validity of issued refresh tokens
+	DefaultRefreshTokenLifetime = 30 * time.Minute
+
+	tokenTypeBearer  = "Bearer"
+	tokenTypeRefresh = "Refresh"
+	signingKeyId     = "keycloakfake"
+)
+
+// Options holds configuration of the fake server. Zero values are replaced with defaults.
+type Options struct {
+	BasePath             string
+	Clock                timeutils.Clock
+	ClientId             string
+	ClientSecret         string
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+}
+
+// Privileges is the content of claim 'pgcdi_privileges' returned by introspection
+type Privileges struct {
+	Roles    []string `json:"roles"`
+	Clusters []string `json:"clusters"`
+	Tenant   string   `json:"tenant"`
+}
+
+// User is an account known to the fake server
+type User struct {
+	Realm      string
+	Username   string
+	Password   string
+	Privileges *Privileges
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Type              string `json:"typ"`
+	AuthorizedParty   string `json:"azp"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// Server is an in-process Keycloak
+type Server struct {
+	mu                   sync.Mutex
+	httpServer           *httptest.Server
+	basePath             string
+	clock                timeutils.Clock
+	clientId             string
+	clientSecret         string
+	accessTokenLifetime  time.Duration
+	refreshTokenLifetime time.Duration
+	signingKey           *rsa.PrivateKey
+	users                map[string]*User
+	issued               map[string]bool
+	revoked              map[string]bool
+	grants               map[string]int
+}
+
+// NewServer starts and returns a new fake Keycloak server. Call Close when done.
+func NewServer(opts Options) (*Server, error) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	s := &Server{
+		basePath:             opts.BasePath,
+		clock:                opts.Clock,
+		clientId:             opts.ClientId,
+		clientSecret:         opts.ClientSecret,
+		accessTokenLifetime:  opts.AccessTokenLifetime,
+		refreshTokenLifetime: opts.RefreshTokenLifetime,
+		signingKey:           signingKey,
+		users:                map[string]*User{},
+		issued:               map[string]bool{},
+		revoked:              map[string]bool{},
+		grants:               map[string]int{},
+	}
+	if s.basePath == "" {
+		s.basePath = DefaultBasePath
+	}
+	if s.clock == nil {
+		s.clock = timeutils.NewRealClock()
+	}
+	if s.accessTokenLifetime == 0 {
+		s.accessTokenLifetime = DefaultAccessTokenLifetime
+	}
+	if s.refreshTokenLifetime == 0 {
+		s.refreshTokenLifetime = DefaultRefreshTokenLifetime
+	}
+	s.httpServer = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s, nil
+}
+
+// URL Returns the root URL of the server; use it as driver API URL
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// AddUser registers an account. A nil Privileges makes introspection omit 'pgcdi_privileges'.
+func (s *Server) AddUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := user
+	s.users[userKey(user.Realm, user.Username)] = &u
+}
+
+// SetPrivileges replaces privileges of an existing account
+func (s *Server) SetPrivileges(realm, username string, privileges *Privileges) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[userKey(realm, username)]; ok {
+		u.Privileges = privileges
+	}
+}
+
+// SetTokenLifetimes changes lifetimes of tokens issued from now on
+func (s *Server) SetTokenLifetimes(accessToken, refreshToken time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokenLifetime = accessToken
+	s.refreshTokenLifetime = refreshToken
+}
+
+// RevokeToken makes the given access or refresh token inactive
+func (s *Server) RevokeToken(token string) error {
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[claims.ID] = true
+	return nil
+}
+
+// RevokeAllTokens makes every token issued so far inactive, like a logout of all sessions
+func (s *Server) RevokeAllTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti := range s.issued {
+		s.revoked[jti] = true
+	}
+}
+
+// GrantCount Returns how many tokens were issued with given grant type, e.g. "password" or "refresh_token"
+func (s *Server) GrantCount(grantType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.grants[grantType]
+}
+
+// IssueToken Returns an access token signed by the server for given user; useful to seed clients
+func (s *Server) IssueToken(realm, username string, lifetime time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signToken(realm, username, tokenTypeBearer, lifetime)
+}
+
+func userKey(realm, username string) string {
+	return realm + "/" + username
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, s.basePath)
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	// Expected: realms/<realm>/protocol/openid-connect/token[/introspect]
+	if len(parts) < 5 || parts[0] != "realms" || parts[2] != "protocol" || parts[3] != "openid-connect" || parts[4] != "token" || r.Method != http.MethodPost {
+		writeOAuthError(w, http.StatusNotFound, "not_found", "unknown endpoint")
+		return
+	}
+	realm := parts[1]
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") != s.clientId || r.PostForm.Get("client_secret") != s.clientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "unauthorized_client", "Invalid client or Invalid client credentials")
+		return
+	}
+
+	switch {
+	case len(parts) == 5:
+		s.handleToken(w, realm, r)
+	case len(parts) == 6 && parts[5] == "introspect":
+		s.handleIntrospect(w, realm, r.PostForm.Get("token"))
+	default:
+		writeOAuthError(w, http.StatusNotFound, "not_found", "unknown endpoint")
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, realm string, r *http.Request) {
+	grantType := r.PostForm.Get("grant_type")
+	var username string
+
+	switch grantType {
+	case "password":
+		s.mu.Lock()
+		u, ok := s.users[userKey(realm, r.PostForm.Get("username"))]
+		s.mu.Unlock()
+		if !ok || u.Password != r.PostForm.Get("password") {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "Invalid user credentials")
+			return
+		}
+		username = u.Username
+	case "refresh_token":
+		claims, err := s.activeClaims(realm, r.PostForm.Get("refresh_token"))
+		if err != nil || claims.Type != tokenTypeRefresh {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Token is not active")
+			return
+		}
+		username = claims.PreferredUsername
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("Unsupported grant_type '%s'", grantType))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accessToken, err := s.signToken(realm, username, tokenTypeBearer, s.accessTokenLifetime)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	refreshToken, err := s.signToken(realm, username, tokenTypeRefresh, s.refreshTokenLifetime)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	s.grants[grantType]++
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":       accessToken,
+		"expires_in":         int(s.accessTokenLifetime.Seconds()),
+		"refresh_token":      refreshToken,
+		"refresh_expires_in": int(s.refreshTokenLifetime.Seconds()),
+		"token_type":         tokenTypeBearer,
+		"scope":              "openid profile email",
+	})
+}
+
+func (s *Server) handleIntrospect(w http.ResponseWriter, realm, token string) {
+	claims, err := s.activeClaims(realm, token)
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"active": false})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	response := map[string]any{
+		"active":             true,
+		"exp":                claims.ExpiresAt.Unix(),
+		"iat":                claims.IssuedAt.Unix(),
+		"jti":                claims.ID,
+		"iss":                claims.Issuer,
+		"sub":                claims.Subject,
+		"typ":                claims.Type,
+		"azp":                claims.AuthorizedParty,
+		"client_id":          claims.AuthorizedParty,
+		"preferred_username": claims.PreferredUsername,
+		"username":           claims.PreferredUsername,
+		"token_type":         tokenTypeBearer,
+	}
+	if u, ok := s.users[userKey(realm, claims.PreferredUsername)]; ok && u.Privileges != nil {
+		response["pgcdi_privileges"] = u.Privileges
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// signToken Returns a signed token; must be called with the lock held
+func (s *Server) signToken(realm, username, tokenType string, lifetime time.Duration) (string, error) {
+	now := s.clock.Now()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
+			Issuer:    fmt.Sprintf("%s%s/realms/%s", s.httpServer.URL, s.basePath, realm),
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+		},
+		Type:              tokenType,
+		AuthorizedParty:   s.clientId,
+		PreferredUsername: username,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = signingKeyId
+	s.issued[claims.ID] = true
+	return token.SignedString(s.signingKey)
+}
+
+// parseToken verifies the signature of the token and returns its claims without checking expiry
+func (s *Server) parseToken(token string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return &s.signingKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// activeClaims Returns claims of a token that is signed by the server, belongs to realm,
+// has not expired according to the server clock and has not been revoked
+func (s *Server) activeClaims(realm, token string) (*tokenClaims, error) {
+	claims, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.HasSuffix(claims.Issuer, "/realms/"+realm) {
+		return nil, errors.New("token issued for another realm")
+	}
+	if !s.clock.Now().Before(claims.ExpiresAt.Time) {
+		return nil, errors.New("token expired")
+	}
+	if s.revoked[claims.ID] {
+		return nil, errors.New("token revoked")
+	}
+	return claims, nil
+}
+
+// writeOAuthError writes an error body shaped like the one returned by Keycloak
+func writeOAuthError(w http.ResponseWriter, statusCode int, code, description string) {
+	writeJSON(w, statusCode, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,171 @@
+package keycloakfake
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/httputils"
+	"github.com/fujitsu/docker-machine-driver-fsas/keycloak"
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRealm        = "cdi-test"
+	testUser         = "alice"
+	testPassword     = "alice-password"
+	testClientId     = "cdi"
+	testClientSecret = "secret"
+)
+
+func TestMain(m *testing.M) {
+	originalLogger := slog.Default()
+	// Suppress slog output in test
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	exitCode := m.Run()
+
+	slog.SetDefault(originalLogger)
+	os.Exit(exitCode)
+}
+
+// newTestServer Returns fake server whose clock is one hour behind real time, so every
+// access token it issues is already expired for KeycloakClient that checks expiry on real time,
+// while refresh tokens are still valid on the server clock.
+func newTestServer(t *testing.T) (*Server, *timeutils.ManualClock, *keycloak.KeycloakClient) {
+	clock := timeutils.NewManualClock(time.Now().Add(-time.Hour))
+	server, err := NewServer(Options{Clock: clock, ClientId: testClientId, ClientSecret: testClientSecret})
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	server.AddUser(User{
+		Realm:    testRealm,
+		Username: testUser,
+		Password: testPassword,
+		Privileges: &Privileges{
+			Roles:    []string{"tenant_manager"},
+			Clusters: []string{"cluster_a"},
+			Tenant:   testRealm,
+		},
+	})
+
+	t.Setenv("CLIENT_ID", testClientId)
+	t.Setenv("CLIENT_SECRET", testClientSecret)
+	client, err := keycloak.NewKeycloak(testRealm, testUser, testPassword, server.URL(), DefaultBasePath)
+	require.NoError(t, err)
+	return server, clock, client
+}
+
+func TestInitConnectionAndAuthorization(t *testing.T) {
+	server, _, client := newTestServer(t)
+
+	require.NoError(t, client.InitConnection())
+	assert.NotEmpty(t, client.AccessToken)
+	assert.NotEmpty(t, client.RefreshToken)
+	assert.NoError(t, client.UserIsAllowedToCreateCluster())
+	assert.Equal(t, 1, server.GrantCount("password"))
+}
+
+func TestInitConnectionInvalidCredentials(t *testing.T) {
+	_, _, client := newTestServer(t)
+	client.UserPassword = "wrong"
+
+	err := client.InitConnection()
+
+	var httpErr *keycloak.KeycloakHttpError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.Code)
+	assert.Contains(t, httpErr.Message, "Invalid user credentials")
+}
+
+func TestInitConnectionMissingPrivileges(t *testing.T) {
+	server, _, client := newTestServer(t)
+	server.SetPrivileges(testRealm, testUser, nil)
+
+	assert.ErrorIs(t, client.InitConnection(), keycloak.ErrResponseBodyMapNotContainKeyPgcdiPrivileges)
+}
+
+func TestUserWithoutCreatorRoleIsRejected(t *testing.T) {
+	server, _, client := newTestServer(t)
+	server.SetPrivileges(testRealm, testUser, &Privileges{Roles: []string{"cluster_user"}, Tenant: testRealm})
+
+	require.NoError(t, client.InitConnection())
+	assert.ErrorContains(t, client.UserIsAllowedToCreateCluster(), "is not allowed to create cluster")
+}
+
+func TestGetTokenRefreshesExpiredAccessToken(t *testing.T) {
+	server, _, client := newTestServer(t)
+	require.NoError(t, client.InitConnection())
+	firstToken := client.AccessToken
+
+	token := client.GetToken()
+
+	assert.NotEqual(t, firstToken, token)
+	assert.Equal(t, 1, server.GrantCount("refresh_token"))
+	assert.Equal(t, 1, server.GrantCount("password"))
+}
+
+func TestGetTokenFallsBackToPasswordWhenRefreshTokenRevoked(t *testing.T) {
+	server, _, client := newTestServer(t)
+	require.NoError(t, client.InitConnection())
+	server.RevokeAllTokens()
+
+	token := client.GetToken()
+
+	assert.NotEmpty(t, token)
+	assert.Equal(t, 0, server.GrantCount("refresh_token"))
+	assert.Equal(t, 2, server.GrantCount("password"))
+}
+
+func TestGetTokenFallsBackToPasswordWhenRefreshTokenExpired(t *testing.T) {
+	server, clock, client := newTestServer(t)
+	require.NoError(t, client.InitConnection())
+	clock.Advance(DefaultRefreshTokenLifetime)
+
+	client.GetToken()
+
+	assert.Equal(t, 0, server.GrantCount("refresh_token"))
+	assert.Equal(t, 2, server.GrantCount("password"))
+}
+
+func TestGetTokenKeepsValidAccessToken(t *testing.T) {
+	server, clock, client := newTestServer(t)
+	clock.Advance(time.Hour)
+	require.NoError(t, client.InitConnection())
+
+	assert.Equal(t, client.AccessToken, client.GetToken())
+	assert.Equal(t, 0, server.GrantCount("refresh_token"))
+}
+
+func TestIntrospectRevokedToken(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	cdiClient := httputils.NewStandardCdiHTTPClient(httputils.UrlBuilder(server.URL(), DefaultBasePath))
+	endpoint := fmt.Sprintf("/realms/%s/protocol/openid-connect/token/introspect", testRealm)
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+	introspect := func(token string) map[string]any {
+		payload := url.Values{"client_id": {testClientId}, "client_secret": {testClientSecret}, "token": {token}}
+		var data map[string]any
+		_, err := cdiClient.Post([]byte(payload.Encode()), endpoint, map[string]string{}, &data, headers)
+		require.NoError(t, err)
+		return data
+	}
+
+	token, err := server.IssueToken(testRealm, testUser, time.Hour)
+	require.NoError(t, err)
+
+	data := introspect(token)
+	assert.Equal(t, true, data["active"])
+	assert.Contains(t, data, "pgcdi_privileges")
+
+	require.NoError(t, server.RevokeToken(token))
+	data = introspect(token)
+	assert.Equal(t, map[string]any{"active": false}, data)
+}
@@ -124,8 +124,10 @@ type ResSpecs struct {
 }
 
 type CreateMachineSpec struct {
-	Machine   string     `json:"mach_name"`
-	Resources []ResSpecs `json:"resources"` // List containing only 1 element by design of fabric manager
+	Machine      string     `json:"mach_name"`
+	MachineOwner string     `json:"mach_owner,omitempty"` // Owner or description shown in CDI console
+	GroupUUID    string     `json:"grp_uuid,omitempty"`   // FM machine group the machine is assigned to
+	Resources    []ResSpecs `json:"resources"`            // List containing only 1 element by design of fabric manager
 }
 
 type CreateMachineRequest struct {
@@ -171,6 +173,27 @@ type MachinesRequestResponse struct {
 	Data MachinesResponseData `json:"data"`
 }
 
+// Structures necessary to deserialize response from GET /tenants/<uuid> request
+type ResourceQuota struct {
+	ResourceType string `json:"res_type"`
+	Limit        int    `json:"limit"` // Maximum number of resources of given type the tenant may allocate
+	Used         int    `json:"used"`  // Number of resources of given type currently allocated by the tenant
+}
+
+type TenantDetails struct {
+	TenantUUID string          `json:"tenant_uuid"`
+	TenantName string          `json:"tenant_name,omitempty"`
+	Quotas     []ResourceQuota `json:"quotas,omitempty"` // Resource types not listed here are not limited
+}
+
+type TenantsResponseData struct {
+	Tenants []TenantDetails `json:"tenants"`
+}
+
+type TenantsRequestResponse struct {
+	Data TenantsResponseData `json:"data"`
+}
+
 // Structures necesary to handle OS image installation
 type BootResource struct {
 	SSDResourceUUID string `json:"res_uuid_ssd"`
@@ -193,6 +216,28 @@ type MachineSpecsArgs struct {
 	NetworkProvisionDefaultGW string
 	NtpServer                 string
 	DnsServer                 string
+	MachineOwner              string
+	GroupUUID                 string
+	FabricConditions          []Condition // Appended to compute conditions to control placement of the machine
+}
+
+// OsRegistration holds parameters of the OS registration with its vendor
+type OsRegistration struct {
+	Registrar string // Name of the registrar; 'auto' or empty detects it from /etc/os-release
+	Code      string // SUSE registration code, Red Hat activation key or Ubuntu Pro token
+	Email     string // SUSE registration email
+	Org       string // Red Hat organization ID the activation key belongs to
+	// RMT or SMT server SUSE products are registered with instead of SUSE Customer Center
+	ServerUrl           string
+	ServerCaCert        string // PEM CA certificate of the registration server installed on the machine
+	ServerCaFingerprint string // SHA-256 or SHA-1 fingerprint the CA certificate must match
+	Modules             string // SUSE modules and profiles to register, all modules when empty
+}
+
+// SuseModule represents a SUSE module or extension selected for registration
+type SuseModule struct {
+	Identifier string
+	Regcode    string // Registration code of a paid extension
 }
 
 // SuseProduct represents a single product or module reported by SUSEConnect
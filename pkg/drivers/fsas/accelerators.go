@@ -0,0 +1,56 @@
+package fsas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+)
+
+// readAcceleratorModels Returns accelerator model table given inline in JSON or as a path to a file
+func (d *Driver) readAcceleratorModels() (string, error) {
+	if d.AcceleratorModels == "" || strings.HasPrefix(d.AcceleratorModels, "{") {
+		return d.AcceleratorModels, nil
+	}
+
+	content, err := osReadFile(d.AcceleratorModels)
+	if err != nil {
+		return "", fmt.Errorf("cannot read accelerator models: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+/*
+checkAcceleratorLabels Verify accelerator model table and node label prefix. A table given as a path is replaced
+with its content, so the file does not have to be present when RKE2 is configured
+*/
+func (d *Driver) checkAcceleratorLabels() error {
+	if d.NodeLabelPrefix != "" {
+		if err := cfgutils.ValidateNodeLabelPrefix(d.NodeLabelPrefix); err != nil {
+			return fmt.Errorf("invalid %s: %w", "--fsas-node-label-prefix", err)
+		}
+	}
+
+	content, err := d.readAcceleratorModels()
+	if err != nil {
+		return err
+	}
+	if _, err := cfgutils.ParseAcceleratorModels(content); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-accelerator-models", err)
+	}
+	d.AcceleratorModels = content
+	return nil
+}
+
+// acceleratorLabels Returns configuration of node labels generated for accelerators of the machine
+func (d *Driver) acceleratorLabels() (cfgutils.AcceleratorLabels, error) {
+	content, err := d.readAcceleratorModels()
+	if err != nil {
+		return cfgutils.AcceleratorLabels{}, err
+	}
+	models, err := cfgutils.ParseAcceleratorModels(content)
+	if err != nil {
+		return cfgutils.AcceleratorLabels{}, err
+	}
+	return cfgutils.AcceleratorLabels{Prefix: d.NodeLabelPrefix, Models: models}, nil
+}
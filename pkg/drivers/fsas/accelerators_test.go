@@ -0,0 +1,62 @@
+package fsas
+
+import (
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkAcceleratorLabels_default(t *testing.T) {
+	driver := &Driver{NodeLabelPrefix: cfgutils.DEFAULT_NODE_LABEL_PREFIX}
+
+	require.NoError(t, driver.checkAcceleratorLabels())
+	acceleratorLabels, err := driver.acceleratorLabels()
+	require.NoError(t, err)
+	assert.Equal(t, cfgutils.DefaultAcceleratorLabels(), acceleratorLabels)
+}
+
+func Test_checkAcceleratorLabels_modelsFromPath(t *testing.T) {
+	models := `{"gpu": {"mi300x": "amd-mi300x"}, "fpga": {"agilex7": "intel-agilex7"}}`
+	mockOsReadFile(t, map[string]string{"/etc/rancher/accelerators.json": models + "\n"})
+	driver := &Driver{NodeLabelPrefix: "accelerators.example.com", AcceleratorModels: "/etc/rancher/accelerators.json"}
+
+	require.NoError(t, driver.checkAcceleratorLabels())
+	assert.Equal(t, models, driver.AcceleratorModels)
+	acceleratorLabels, err := driver.acceleratorLabels()
+	require.NoError(t, err)
+	assert.Equal(t, cfgutils.AcceleratorLabels{
+		Prefix: "accelerators.example.com",
+		Models: cfgutils.AcceleratorModels{"gpu": {"mi300x": "amd-mi300x"}, "fpga": {"agilex7": "intel-agilex7"}},
+	}, acceleratorLabels)
+}
+
+func Test_checkAcceleratorLabels_fail(t *testing.T) {
+	mockOsReadFile(t, map[string]string{})
+
+	testCases := []struct {
+		name     string
+		driver   *Driver
+		expected string
+	}{
+		{name: "invalid prefix",
+			driver:   &Driver{NodeLabelPrefix: "cohdi.io/gpu"},
+			expected: "invalid --fsas-node-label-prefix: prefix 'cohdi.io/gpu' must not contain '/'",
+		},
+		{name: "missing file",
+			driver:   &Driver{AcceleratorModels: "/missing.json"},
+			expected: "cannot read accelerator models",
+		},
+		{name: "invalid table",
+			driver:   &Driver{AcceleratorModels: `{"gpu": {"h100": "NVIDIA H100"}}`},
+			expected: "invalid --fsas-accelerator-models: label name 'NVIDIA H100' of gpu model 'h100'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorContains(t, tc.driver.checkAcceleratorLabels(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package fsas
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Paths the RKE2 installer looks for air-gap artifacts at; the artifact directory is passed in INSTALL_RKE2_ARTIFACT_PATH,
+// the images directory is read by RKE2 itself
+const (
+	rke2AirgapArtifactDir = "/root/rke2-artifacts"
+	rke2AirgapImagesDir   = "/var/lib/rancher/rke2/agent/images"
+	airgapStagingDir      = "/var/tmp/fsas-rke2-airgap" // Survives reboots, so an interrupted upload can be resumed
+	airgapImagesPrefix    = "rke2-images"
+	airgapChecksumsPrefix = "sha256sum"
+)
+
+/*
+airgapInstallEnvScript Sets INSTALL_RKE2_ARTIFACT_PATH in environment files of rancher-system-agent, whose RKE2
+installer inherits the environment of the agent; the files are read by its systemd unit and kept on agent upgrades
+*/
+var airgapInstallEnvScript = fmt.Sprintf(`for f in /etc/default/rancher-system-agent /etc/sysconfig/rancher-system-agent; do
+mkdir -p "$(dirname "$f")"
+touch "$f"
+sed -i '/^INSTALL_RKE2_ARTIFACT_PATH=/d' "$f"
+echo 'INSTALL_RKE2_ARTIFACT_PATH=%s' >> "$f"
+done
+`, rke2AirgapArtifactDir)
+
+// airgapArtifact is a file uploaded to the machine for the air-gapped RKE2 installation
+type airgapArtifact struct {
+	localPath  string
+	remotePath string
+	checksum   string // SHA-256 of the local file in hex
+}
+
+// airgapArtifactFiles Returns files given in --fsas-rke2-airgap-artifacts, directories are expanded to regular files they contain
+func (d *Driver) airgapArtifactFiles() ([]string, error) {
+	files := []string{}
+	for _, artifactPath := range strings.Split(d.Rke2AirgapArtifacts, ",") {
+		artifactPath = strings.TrimSpace(artifactPath)
+		if artifactPath == "" {
+			continue
+		}
+
+		info, err := os.Stat(artifactPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read RKE2 air-gap artifact: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, artifactPath)
+			continue
+		}
+
+		entries, err := os.ReadDir(artifactPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read RKE2 air-gap artifacts directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				files = append(files, filepath.Join(artifactPath, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// checkAirgapArtifacts Verify that air-gap artifacts can be read
+func (d *Driver) checkAirgapArtifacts() error {
+	if d.Rke2AirgapArtifacts == "" {
+		return nil
+	}
+
+	files, err := d.airgapArtifactFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no RKE2 air-gap artifacts found in %s", d.Rke2AirgapArtifacts)
+	}
+	return nil
+}
+
+// airgapRemotePath Returns path on the machine the RKE2 installer looks for the artifact at
+func airgapRemotePath(name string) string {
+	if strings.HasPrefix(name, airgapImagesPrefix) {
+		return path.Join(rke2AirgapImagesDir, name)
+	}
+	return path.Join(rke2AirgapArtifactDir, name)
+}
+
+// fileSha256 Returns SHA-256 checksum of the local file in hex
+func fileSha256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readChecksums Returns checksums by file name from a file in 'sha256sum' format
+func readChecksums(filePath string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return checksums, scanner.Err()
+}
+
+// prepareAirgapArtifacts Returns artifacts to upload with checksums verified against checksum files given among them
+func (d *Driver) prepareAirgapArtifacts() ([]airgapArtifact, error) {
+	files, err := d.airgapArtifactFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string]string{}
+	for _, file := range files {
+		if strings.HasPrefix(filepath.Base(file), airgapChecksumsPrefix) {
+			checksums, err := readChecksums(file)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read RKE2 air-gap checksums: %w", err)
+			}
+			for name, checksum := range checksums {
+				expected[name] = checksum
+			}
+		}
+	}
+
+	artifacts := []airgapArtifact{}
+	for _, file := range files {
+		checksum, err := fileSha256(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compute checksum of RKE2 air-gap artifact: %w", err)
+		}
+
+		name := filepath.Base(file)
+		if want, ok := expected[name]; ok && want != checksum {
+			return nil, fmt.Errorf("checksum of RKE2 air-gap artifact %s does not match checksums file: %s != %s", file, checksum, want)
+		}
+		artifacts = append(artifacts, airgapArtifact{localPath: file, remotePath: airgapRemotePath(name), checksum: checksum})
+	}
+	return artifacts, nil
+}
+
+// uploadAirgapArtifact Upload artifact to stagingPath resuming a previous upload; a resumed upload with wrong checksum is uploaded again from the start
+func (d *Driver) uploadAirgapArtifact(artifact airgapArtifact, stagingPath string) error {
+	for _, resume := range []bool{true, false} {
+		if err := d.SshManager.StreamFileToRemoteMachine(artifact.localPath, stagingPath, 0644, resume); err != nil {
+			return err
+		}
+
+		checksum, err := d.SshManager.RemoteFileChecksum(stagingPath)
+		if err != nil {
+			return err
+		}
+		if checksum == artifact.checksum {
+			return nil
+		}
+		slog.Warn("Checksum of uploaded RKE2 air-gap artifact does not match: ", "path", stagingPath, "checksum", checksum, "expected", artifact.checksum, "resumed", resume)
+	}
+	return fmt.Errorf("checksum of uploaded RKE2 air-gap artifact %s does not match", stagingPath)
+}
+
+/*
+uploadAirgapArtifacts Upload RKE2 air-gap artifacts to the paths the RKE2 installer looks for them at.
+Artifacts already in place are skipped, the others are staged, verified and then moved in place at once.
+When the install tarball or checksums are uploaded, the installer is pointed at them
+*/
+func (d *Driver) uploadAirgapArtifacts() error {
+	if d.Rke2AirgapArtifacts == "" {
+		return nil
+	}
+
+	artifacts, err := d.prepareAirgapArtifacts()
+	if err != nil {
+		return err
+	}
+
+	var script strings.Builder
+	installerArtifacts := false
+	for _, artifact := range artifacts {
+		if path.Dir(artifact.remotePath) == rke2AirgapArtifactDir {
+			installerArtifacts = true
+		}
+		if checksum, err := d.SshManager.RemoteFileChecksum(artifact.remotePath); err == nil && checksum == artifact.checksum {
+			slog.Info("RKE2 air-gap artifact already in place: ", "path", artifact.remotePath)
+			continue
+		}
+
+		stagingPath := path.Join(airgapStagingDir, path.Base(artifact.remotePath))
+		slog.Info("Uploading RKE2 air-gap artifact: ", "file", artifact.localPath, "path", artifact.remotePath)
+		if err := d.uploadAirgapArtifact(artifact, stagingPath); err != nil {
+			return err
+		}
+		script.WriteString(fmt.Sprintf("install -D -m 0644 %s %s\nrm -f %s\n", stagingPath, artifact.remotePath, stagingPath))
+	}
+
+	if installerArtifacts {
+		script.WriteString(airgapInstallEnvScript)
+	}
+	if script.Len() == 0 {
+		return nil
+	}
+	return d.SshManager.ExecuteScript("", "#!/bin/sh\nset -e\n"+script.String(), true, true)
+}
@@ -0,0 +1,167 @@
+package fsas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAirgapArtifacts Writes files with given content into a new directory and returns it with checksums of the files
+func writeAirgapArtifacts(t *testing.T, files map[string]string) (string, map[string]string) {
+	dir := t.TempDir()
+	checksums := map[string]string{}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		sum := sha256.Sum256([]byte(content))
+		checksums[name] = hex.EncodeToString(sum[:])
+	}
+	return dir, checksums
+}
+
+func Test_uploadAirgapArtifacts_none(t *testing.T) {
+	driver := &Driver{SshManager: sshMock.NewMockSshManager(t)}
+
+	assert.NoError(t, driver.uploadAirgapArtifacts())
+}
+
+func Test_checkAirgapArtifacts(t *testing.T) {
+	assert.NoError(t, (&Driver{}).checkAirgapArtifacts())
+
+	err := (&Driver{Rke2AirgapArtifacts: "/missing/rke2.linux-amd64.tar.gz"}).checkAirgapArtifacts()
+	assert.ErrorContains(t, err, "cannot read RKE2 air-gap artifact")
+
+	emptyDir := t.TempDir()
+	err = (&Driver{Rke2AirgapArtifacts: emptyDir}).checkAirgapArtifacts()
+	assert.ErrorContains(t, err, "no RKE2 air-gap artifacts found")
+}
+
+func Test_prepareAirgapArtifacts(t *testing.T) {
+	dir, checksums := writeAirgapArtifacts(t, map[string]string{
+		"rke2.linux-amd64.tar.gz":         "tarball",
+		"rke2-images.linux-amd64.tar.zst": "images",
+	})
+	checksumsFile := fmt.Sprintf("%s  rke2.linux-amd64.tar.gz\n%s *rke2-images.linux-amd64.tar.zst\n",
+		checksums["rke2.linux-amd64.tar.gz"], checksums["rke2-images.linux-amd64.tar.zst"])
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sha256sum-amd64.txt"), []byte(checksumsFile), 0600))
+	driver := &Driver{Rke2AirgapArtifacts: dir}
+
+	artifacts, err := driver.prepareAirgapArtifacts()
+
+	require.NoError(t, err)
+	remotePaths := map[string]string{}
+	for _, artifact := range artifacts {
+		remotePaths[filepath.Base(artifact.localPath)] = artifact.remotePath
+	}
+	assert.Equal(t, map[string]string{
+		"rke2.linux-amd64.tar.gz":         "/root/rke2-artifacts/rke2.linux-amd64.tar.gz",
+		"rke2-images.linux-amd64.tar.zst": "/var/lib/rancher/rke2/agent/images/rke2-images.linux-amd64.tar.zst",
+		"sha256sum-amd64.txt":             "/root/rke2-artifacts/sha256sum-amd64.txt",
+	}, remotePaths)
+}
+
+func Test_prepareAirgapArtifacts_checksumMismatch(t *testing.T) {
+	dir, _ := writeAirgapArtifacts(t, map[string]string{
+		"rke2.linux-amd64.tar.gz": "truncated",
+		"sha256sum-amd64.txt":     "0000  rke2.linux-amd64.tar.gz\n",
+	})
+	driver := &Driver{Rke2AirgapArtifacts: dir}
+
+	_, err := driver.prepareAirgapArtifacts()
+
+	assert.ErrorContains(t, err, "does not match checksums file")
+}
+
+func Test_uploadAirgapArtifacts(t *testing.T) {
+	dir, checksums := writeAirgapArtifacts(t, map[string]string{
+		"rke2.linux-amd64.tar.gz":         "tarball",
+		"rke2-images.linux-amd64.tar.zst": "images",
+	})
+	tarball := filepath.Join(dir, "rke2.linux-amd64.tar.gz")
+	images := filepath.Join(dir, "rke2-images.linux-amd64.tar.zst")
+	imagesStaging := "/var/tmp/fsas-rke2-airgap/rke2-images.linux-amd64.tar.zst"
+	imagesPath := "/var/lib/rancher/rke2/agent/images/rke2-images.linux-amd64.tar.zst"
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	// Tarball is in place already
+	mockSSH.On("RemoteFileChecksum", "/root/rke2-artifacts/rke2.linux-amd64.tar.gz").Return(checksums["rke2.linux-amd64.tar.gz"], nil)
+	// Resumed upload of images is corrupted, so it is uploaded again from the start
+	mockSSH.On("RemoteFileChecksum", imagesPath).Return("", errors.New("No such file or directory"))
+	mockSSH.On("StreamFileToRemoteMachine", images, imagesStaging, os.FileMode(0644), true).Return(nil).Once()
+	mockSSH.On("RemoteFileChecksum", imagesStaging).Return("0000", nil).Once()
+	mockSSH.On("StreamFileToRemoteMachine", images, imagesStaging, os.FileMode(0644), false).Return(nil).Once()
+	mockSSH.On("RemoteFileChecksum", imagesStaging).Return(checksums["rke2-images.linux-amd64.tar.zst"], nil).Once()
+	mockSSH.On("ExecuteScript", "", "#!/bin/sh\nset -e\n"+
+		"install -D -m 0644 "+imagesStaging+" "+imagesPath+"\nrm -f "+imagesStaging+"\n"+airgapInstallEnvScript, true, true).Return(nil)
+	driver := &Driver{Rke2AirgapArtifacts: tarball + ", " + images, SshManager: mockSSH}
+
+	assert.NoError(t, driver.uploadAirgapArtifacts())
+}
+
+func Test_uploadAirgapArtifacts_imagesOnly(t *testing.T) {
+	dir, checksums := writeAirgapArtifacts(t, map[string]string{"rke2-images.linux-amd64.tar.zst": "images"})
+	images := filepath.Join(dir, "rke2-images.linux-amd64.tar.zst")
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	// Images alone do not need the installer pointed at the artifact directory
+	mockSSH.On("RemoteFileChecksum", "/var/lib/rancher/rke2/agent/images/rke2-images.linux-amd64.tar.zst").Return(checksums["rke2-images.linux-amd64.tar.zst"], nil)
+	driver := &Driver{Rke2AirgapArtifacts: images, SshManager: mockSSH}
+
+	assert.NoError(t, driver.uploadAirgapArtifacts())
+	mockSSH.AssertNotCalled(t, "ExecuteScript")
+}
+
+func Test_uploadAirgapArtifacts_installerEnvWhenInPlace(t *testing.T) {
+	dir, checksums := writeAirgapArtifacts(t, map[string]string{"rke2.linux-amd64.tar.gz": "tarball"})
+	tarball := filepath.Join(dir, "rke2.linux-amd64.tar.gz")
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("RemoteFileChecksum", "/root/rke2-artifacts/rke2.linux-amd64.tar.gz").Return(checksums["rke2.linux-amd64.tar.gz"], nil)
+	mockSSH.On("ExecuteScript", "", "#!/bin/sh\nset -e\n"+airgapInstallEnvScript, true, true).Return(nil)
+	driver := &Driver{Rke2AirgapArtifacts: tarball, SshManager: mockSSH}
+
+	assert.NoError(t, driver.uploadAirgapArtifacts())
+}
+
+func Test_airgapInstallEnvScript(t *testing.T) {
+	assert.Contains(t, airgapInstallEnvScript, "/etc/default/rancher-system-agent")
+	assert.Contains(t, airgapInstallEnvScript, "/etc/sysconfig/rancher-system-agent")
+	assert.Contains(t, airgapInstallEnvScript, "echo 'INSTALL_RKE2_ARTIFACT_PATH=/root/rke2-artifacts' >> \"$f\"")
+}
+
+func Test_uploadAirgapArtifacts_checksumMismatch(t *testing.T) {
+	dir, _ := writeAirgapArtifacts(t, map[string]string{"rke2.linux-amd64.tar.gz": "tarball"})
+	tarball := filepath.Join(dir, "rke2.linux-amd64.tar.gz")
+	staging := "/var/tmp/fsas-rke2-airgap/rke2.linux-amd64.tar.gz"
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("RemoteFileChecksum", "/root/rke2-artifacts/rke2.linux-amd64.tar.gz").Return("", errors.New("No such file or directory"))
+	mockSSH.On("StreamFileToRemoteMachine", tarball, staging, os.FileMode(0644), true).Return(nil)
+	mockSSH.On("StreamFileToRemoteMachine", tarball, staging, os.FileMode(0644), false).Return(nil)
+	mockSSH.On("RemoteFileChecksum", staging).Return("0000", nil)
+	driver := &Driver{Rke2AirgapArtifacts: tarball, SshManager: mockSSH}
+
+	err := driver.uploadAirgapArtifacts()
+
+	assert.EqualError(t, err, "checksum of uploaded RKE2 air-gap artifact "+staging+" does not match")
+}
+
+func Test_uploadAirgapArtifacts_uploadFails(t *testing.T) {
+	dir, _ := writeAirgapArtifacts(t, map[string]string{"rke2.linux-amd64.tar.gz": "tarball"})
+	tarball := filepath.Join(dir, "rke2.linux-amd64.tar.gz")
+	mockError := errors.New("connection reset")
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("RemoteFileChecksum", "/root/rke2-artifacts/rke2.linux-amd64.tar.gz").Return("", errors.New("No such file or directory"))
+	mockSSH.On("StreamFileToRemoteMachine", tarball, "/var/tmp/fsas-rke2-airgap/rke2.linux-amd64.tar.gz", os.FileMode(0644), true).Return(mockError)
+	driver := &Driver{Rke2AirgapArtifacts: tarball, SshManager: mockSSH}
+
+	assert.ErrorIs(t, driver.uploadAirgapArtifacts(), mockError)
+}
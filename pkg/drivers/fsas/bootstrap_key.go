@@ -0,0 +1,60 @@
+package fsas
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Bootstrap key given as PEM text is saved next to the driver SSH key, as the SSH clients read keys from files
+const bootstrapKeyFileName = "id_bootstrap"
+
+// isPemKey Returns true when the bootstrap key is given as PEM text rather than as a path
+func isPemKey(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN")
+}
+
+// checkBootstrapKey Verify that the bootstrap key, if any, is an unencrypted private key
+func checkBootstrapKey(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	keyBytes := []byte(value)
+	if !isPemKey(value) {
+		var err error
+		if keyBytes, err = os.ReadFile(value); err != nil {
+			return fmt.Errorf("cannot read SSH bootstrap key: %w", err)
+		}
+	}
+
+	if _, err := gossh.ParsePrivateKey(keyBytes); err != nil {
+		return fmt.Errorf("invalid SSH bootstrap key: %w", err)
+	}
+	return nil
+}
+
+// bootstrapKeyPath Returns path of the bootstrap key file or empty string when no bootstrap key is set.
+// Key given as PEM text is written to the machine's store path first.
+func (d *Driver) bootstrapKeyPath() (string, error) {
+	if d.SSHBootstrapKey == "" || !isPemKey(d.SSHBootstrapKey) {
+		return d.SSHBootstrapKey, nil
+	}
+
+	path := d.ResolveStorePath(bootstrapKeyFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("cannot create directory for SSH bootstrap key: %w", err)
+	}
+
+	key := strings.TrimSpace(d.SSHBootstrapKey) + "\n"
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		return "", fmt.Errorf("cannot write SSH bootstrap key: %w", err)
+	}
+
+	slog.Debug("SSH bootstrap key written: ", "path", path)
+	return path, nil
+}
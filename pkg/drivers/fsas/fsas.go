@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
@@ -28,46 +29,85 @@ import (
 var statusClock timeutils.Clock = timeutils.NewRealClock()
 
 const (
-	WAIT_FOR_STATUS_TIMEOUT               time.Duration = 30 * time.Minute
-	WAIT_FOR_STATUS_INSTALL_TIMEOUT       time.Duration = 30 * time.Minute
-	WAIT_FOR_STATUS_STEP                  time.Duration = 5 * time.Second
-	WAIT_FOR_STATUS_STEP_FOR_INSTALLATION time.Duration = 5 * time.Second
-	WAIT_FOR_STATUS_INSTALL_STEP          time.Duration = 5 * time.Second
-	WAIT_FOR_STATUS_STOPPED_TIMEOUT       time.Duration = 15 * time.Second
-	WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT     time.Duration = 15 * time.Second
-	WAIT_FOR_START_AFTER_REBOOT           time.Duration = 60 * time.Second
+	WAIT_FOR_STATUS_TIMEOUT           time.Duration = 30 * time.Minute
+	WAIT_FOR_STATUS_INSTALL_TIMEOUT   time.Duration = 30 * time.Minute
+	WAIT_FOR_STATUS_STEP              time.Duration = 5 * time.Second
+	WAIT_FOR_STATUS_MAX_STEP          time.Duration = 30 * time.Second
+	WAIT_FOR_STATUS_STOPPED_TIMEOUT   time.Duration = 15 * time.Second
+	WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT time.Duration = 15 * time.Second
+	WAIT_FOR_START_AFTER_REBOOT       time.Duration = 60 * time.Second
+	WAIT_FOR_SSH_TIMEOUT              time.Duration = 10 * time.Minute
+	WAIT_FOR_CLOUD_INIT_TIMEOUT       time.Duration = 30 * time.Minute
 )
 
 // Driver is the implementation of BaseDriver interface
 type Driver struct {
 	*drivers.BaseDriver
-	SSHPassword               string
-	TenantUuid                string
-	Username                  string
-	Password                  string
-	ApiUrl                    string
-	NtpUrl                    string
-	DnsIp                     string
-	ComputeConditionsJson     string
-	DevicesSpecJson           string
-	NetworkBaremetalPort      int
-	NetworkBaremetalUUID      string
-	NetworkBaremetalDefaultGW string
-	NetworkProvisionPort      int
-	NetworkProvisionUUID      string
-	NetworkProvisionDefaultGW string
-	PrivateIPAddress          string
-	OsImageName               string
-	OsImageSshHostPubKey      string
-	OsImageSshHostParsedKey   gossh.PublicKey `json:"-"`
-	MachineUUID               string
-	UserDataFile              string
-	SlesRegistrationCode      string
-	SlesRegistrationEmail     string
-	FabricManager             fm.FabricManager    `json:"-"`
-	Keycloak                  keycloak.Keycloak   `json:"-"`
-	SshManager                sshutils.SshManager `json:"-"`
-	CfgManager                cfgutils.CfgManager `json:"-"`
+	SSHPassword                 string
+	SSHBootstrapKey             string // PEM text or path of private key authorized on the OS image
+	TenantUuid                  string
+	Username                    string
+	Password                    string
+	ApiUrl                      string
+	NtpUrl                      string
+	DnsIp                       string
+	ComputeConditionsJson       string
+	DevicesSpecJson             string
+	NetworkBaremetalPort        int
+	NetworkBaremetalUUID        string
+	NetworkBaremetalDefaultGW   string
+	NetworkProvisionPort        int
+	NetworkProvisionUUID        string
+	NetworkProvisionDefaultGW   string
+	NetworkProvisionAddressMode string // 'dhcp' or 'static/<prefix length>'
+	NetworkBaremetalAddressMode string
+	PrivateIPAddress            string
+	OsImageName                 string
+	OsImageSshHostPubKey        string
+	OsImageSshHostParsedKey     gossh.PublicKey `json:"-"`
+	SshHostPubKey               string          // Host key installed by cloud-init, replaces the OS image host key once set
+	MachineUUID                 string
+	UserDataFile                string
+	CustomUserData              string // User's own user-data merged with the one provided by Rancher; inline or path
+	SlesRegistrationCode        string // Registration code of the OS; SUSE regcode, Red Hat activation key or Ubuntu Pro token
+	SlesRegistrationEmail       string
+	OsRegistrar                 string // 'auto' detects registrar from /etc/os-release
+	OsRegistrationOrg           string
+	SlesRmtUrl                  string // RMT or SMT server SLES is registered with instead of SUSE Customer Center
+	SlesRmtCaCert               string // PEM CA certificate of the RMT server; inline or path, holds the PEM once config is checked
+	SlesRmtCaFingerprint        string
+	Rke2AirgapArtifacts         string // Comma separated local files or directories uploaded for the air-gapped RKE2 installation
+	SlesModules                 string // Modules and profiles registered after SLES, e.g. 'containers,sle-ha=<regcode>'; all when empty
+	NodeLabels                  string // Comma separated 'key=value' labels of the Kubernetes node, merged with GPU labels
+	NodeTaints                  string // Comma separated 'key[=value]:effect' taints of the Kubernetes node
+	NodeLabelPrefix             string // Prefix of accelerator labels; cfgutils.DEFAULT_NODE_LABEL_PREFIX when empty
+	AcceleratorModels           string // JSON table of labelled accelerator models by resource type; inline or path, holds the JSON once config is checked
+	MachineGroupUUID            string
+	MachineOwner                string
+	Placement                   string
+	PlacementFabricUUID         string
+	PlacementEnforcement        string
+	FabricUUID                  string
+	LastCompletedPhase          string
+	CreateResumeAttempts        int
+	CreateResumeAttemptsUsed    int
+	WaitStatusTimeout           int // Seconds; durations not set fall back to the WAIT_FOR_* defaults
+	WaitInstallTimeout          int
+	WaitStoppedTimeout          int
+	WaitRemovedTimeout          int
+	WaitAfterReboot             int
+	WaitSshTimeout              int
+	WaitCloudInitTimeout        int
+	PollInterval                int
+	PollMaxInterval             int
+	KeepOnFailure               string
+	StopEscalation              string
+	KeptOnFailure               bool
+	KeptOnFailureUntil          time.Time
+	FabricManager               fm.FabricManager    `json:"-"`
+	Keycloak                    keycloak.Keycloak   `json:"-"`
+	SshManager                  sshutils.SshManager `json:"-"`
+	CfgManager                  cfgutils.CfgManager `json:"-"`
 }
 
 // NewDriver creates and returns a new instance of the FSAS CDI driver
@@ -95,6 +135,11 @@ func NewDriver() *Driver {
 		UserDataFile:              "",
 		SlesRegistrationCode:      "",
 		SlesRegistrationEmail:     "",
+		OsRegistrar:               sshutils.OS_REGISTRAR_SUSECONNECT,
+		NodeLabelPrefix:           cfgutils.DEFAULT_NODE_LABEL_PREFIX,
+		PlacementEnforcement:      placementEnforcementFail,
+		KeepOnFailure:             keepOnFailureOff,
+		StopEscalation:            defaultStopEscalation,
 		FabricManager:             &fm.FabricManagerClient{},
 		Keycloak:                  &keycloak.KeycloakClient{},
 		SshManager:                &sshutils.StandardSshManager{},
@@ -109,6 +154,11 @@ const (
 	defaultFabricManagerEndpoint = "/fabric_manager/api/v1"
 	defaultKeycloakEndpoint      = "/id_manager"
 	errorMandatoryOption         = "%s must be specified using the CLI option %s"
+	defaultMachineOwnerTemplate  = "rancher:{{.NodePool}}"
+	placementPin                 = "pin"
+	placementSpread              = "spread"
+	placementEnforcementFail     = "fail"
+	placementEnforcementWarn     = "warn"
 	cloudInitDirPath             = "/etc/cdi/cloud-init-discovery/"
 )
 
@@ -130,9 +180,42 @@ func (d *Driver) String() string {
 		fmt.Sprintf("PrivateIPAddress: %s, ", d.PrivateIPAddress) +
 		fmt.Sprintf("OsImageName: %s, ", d.OsImageName) +
 		fmt.Sprintf("OsImageSshHostPubKey: %s, ", d.OsImageSshHostPubKey) +
+		fmt.Sprintf("SshHostPubKey: %s, ", d.SshHostPubKey) +
 		fmt.Sprintf("MachineUUID: %s, ", d.MachineUUID) +
-		fmt.Sprintf("UserDataFile: %s", d.UserDataFile) +
-		fmt.Sprintf("SlesRegistrationEmail: %s", d.SlesRegistrationEmail) +
+		fmt.Sprintf("UserDataFile: %s, ", d.UserDataFile) +
+		fmt.Sprintf("SlesRegistrationEmail: %s, ", d.SlesRegistrationEmail) +
+		fmt.Sprintf("OsRegistrar: %s, ", d.OsRegistrar) +
+		fmt.Sprintf("OsRegistrationOrg: %s, ", d.OsRegistrationOrg) +
+		fmt.Sprintf("SlesRmtUrl: %s, ", d.SlesRmtUrl) +
+		fmt.Sprintf("SlesRmtCaFingerprint: %s, ", d.SlesRmtCaFingerprint) +
+		fmt.Sprintf("Rke2AirgapArtifacts: %s, ", d.Rke2AirgapArtifacts) +
+		fmt.Sprintf("SlesModules: %s, ", sshutils.RedactSuseModules(d.SlesModules)) +
+		fmt.Sprintf("NodeLabels: %s, ", d.NodeLabels) +
+		fmt.Sprintf("NodeTaints: %s, ", d.NodeTaints) +
+		fmt.Sprintf("NodeLabelPrefix: %s, ", d.NodeLabelPrefix) +
+		fmt.Sprintf("AcceleratorModels: %s, ", d.AcceleratorModels) +
+		fmt.Sprintf("MachineGroupUUID: %s, ", d.MachineGroupUUID) +
+		fmt.Sprintf("MachineOwner: %s, ", d.MachineOwner) +
+		fmt.Sprintf("Placement: %s, ", d.Placement) +
+		fmt.Sprintf("PlacementFabricUUID: %s, ", d.PlacementFabricUUID) +
+		fmt.Sprintf("PlacementEnforcement: %s, ", d.PlacementEnforcement) +
+		fmt.Sprintf("FabricUUID: %s, ", d.FabricUUID) +
+		fmt.Sprintf("LastCompletedPhase: %s, ", d.LastCompletedPhase) +
+		fmt.Sprintf("CreateResumeAttempts: %d, ", d.CreateResumeAttempts) +
+		fmt.Sprintf("CreateResumeAttemptsUsed: %d, ", d.CreateResumeAttemptsUsed) +
+		fmt.Sprintf("WaitStatusTimeout: %d, ", d.WaitStatusTimeout) +
+		fmt.Sprintf("WaitInstallTimeout: %d, ", d.WaitInstallTimeout) +
+		fmt.Sprintf("WaitStoppedTimeout: %d, ", d.WaitStoppedTimeout) +
+		fmt.Sprintf("WaitRemovedTimeout: %d, ", d.WaitRemovedTimeout) +
+		fmt.Sprintf("WaitAfterReboot: %d, ", d.WaitAfterReboot) +
+		fmt.Sprintf("WaitSshTimeout: %d, ", d.WaitSshTimeout) +
+		fmt.Sprintf("WaitCloudInitTimeout: %d, ", d.WaitCloudInitTimeout) +
+		fmt.Sprintf("PollInterval: %d, ", d.PollInterval) +
+		fmt.Sprintf("PollMaxInterval: %d, ", d.PollMaxInterval) +
+		fmt.Sprintf("KeepOnFailure: %s, ", d.KeepOnFailure) +
+		fmt.Sprintf("StopEscalation: %s, ", d.StopEscalation) +
+		fmt.Sprintf("KeptOnFailure: %t, ", d.KeptOnFailure) +
+		fmt.Sprintf("KeptOnFailureUntil: %s", d.KeptOnFailureUntil) +
 		"}"
 }
 
@@ -153,6 +236,11 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Value:  defaultSSHPassword,
 			EnvVar: "FSAS_SSH_PASSWORD",
 		},
+		mcnflag.StringFlag{
+			Name:   "fsas-ssh-bootstrap-key",
+			Usage:  "Private key authorized on the OS image (PEM text or path to a file); when set, password login is not used",
+			EnvVar: "FSAS_SSH_BOOTSTRAP_KEY",
+		},
 		mcnflag.StringFlag{
 			Name:   "fsas-tenant-uuid",
 			Usage:  "FSAS CDI Tenant UUID",
@@ -218,6 +306,18 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  `Node subnet default gateway for Rancher-baremetal communication`,
 			EnvVar: "FSAS_NETWORK_PROVISION_DEFAULT_GW",
 		},
+		mcnflag.StringFlag{
+			Name:   "fsas-network-provision-address-mode",
+			Usage:  "Addressing of the provisioning subnet interface in network-config: 'dhcp' or 'static/<prefix length>' using the IP address assigned by Fabric Manager",
+			Value:  addressModeDhcp,
+			EnvVar: "FSAS_NETWORK_PROVISION_ADDRESS_MODE",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-network-baremetal-address-mode",
+			Usage:  "Addressing of the baremetal subnet interface in network-config: 'dhcp' or 'static/<prefix length>' using the IP address assigned by Fabric Manager",
+			Value:  addressModeDhcp,
+			EnvVar: "FSAS_NETWORK_BAREMETAL_ADDRESS_MODE",
+		},
 		mcnflag.StringFlag{
 			Name:   "fsas-devices-spec-json",
 			Usage:  `FSAS CDI devices specifications JSON (string with devices spec, e.g. "[{"res_type":"storage","res_num":1,"res_spec":{"condition":[{"column":"vendor","operator":"eq","value":"samsung"}]},"tags":{"is_bootstorage":true}}]")`,
@@ -235,7 +335,7 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 		},
 		mcnflag.StringFlag{
 			Name:   "fsas-sles-registration-code",
-			Usage:  "SLES registration code",
+			Usage:  "OS registration code: SUSE registration code, Red Hat activation key or Ubuntu Pro token",
 			EnvVar: "FSAS_SLES_REGISTRATION_CODE",
 		},
 		mcnflag.StringFlag{
@@ -243,11 +343,173 @@ func (d *Driver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "SLES registration email",
 			EnvVar: "FSAS_SLES_REGISTRATION_EMAIL",
 		},
+		mcnflag.StringFlag{
+			Name:   "fsas-os-registrar",
+			Usage:  "Tool registering the OS: 'suseconnect', 'subscription-manager', 'pro', 'none' or 'auto' to detect it from /etc/os-release; with 'auto' a registration code needs SLES email or organization ID, Ubuntu Pro needs 'pro'",
+			EnvVar: "FSAS_OS_REGISTRAR",
+			Value:  sshutils.OS_REGISTRAR_SUSECONNECT,
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-os-registration-org",
+			Usage:  "Red Hat organization ID the activation key given in --fsas-sles-registration-code belongs to",
+			EnvVar: "FSAS_OS_REGISTRATION_ORG",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-sles-rmt-url",
+			Usage:  "URL of the RMT or SMT server SLES is registered with instead of SUSE Customer Center, e.g. https://rmt.example.com",
+			EnvVar: "FSAS_SLES_RMT_URL",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-sles-rmt-ca-cert",
+			Usage:  "CA certificate of the RMT server given inline in PEM or as a path; installed on the node before registration",
+			EnvVar: "FSAS_SLES_RMT_CA_CERT",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-sles-rmt-ca-fingerprint",
+			Usage:  "SHA-256 or SHA-1 fingerprint of the RMT server CA certificate; without --fsas-sles-rmt-ca-cert the certificate the server publishes over plain http is fetched and trusted only if it matches, so the fingerprint is mandatory for fetching it",
+			EnvVar: "FSAS_SLES_RMT_CA_FINGERPRINT",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-rke2-airgap-artifacts",
+			Usage:  "Comma separated files or directories with RKE2 air-gap artifacts uploaded to the node; image archives go to " + rke2AirgapImagesDir + ", install tarball and checksums to " + rke2AirgapArtifactDir,
+			EnvVar: "FSAS_RKE2_AIRGAP_ARTIFACTS",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-sles-modules",
+			Usage:  "Comma separated SUSE modules registered after SLES, given by identifier with optional '=<regcode>' for paid extensions, or profiles 'containers', 'hpc'; 'none' registers no modules, 'all' every available one",
+			EnvVar: "FSAS_SLES_MODULES",
+			Value:  sshutils.SUSE_MODULES_ALL,
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-node-labels",
+			Usage:  "Comma separated 'key=value' labels the Kubernetes node registers with, in addition to the generated GPU labels",
+			EnvVar: "FSAS_NODE_LABELS",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-node-taints",
+			Usage:  "Comma separated 'key[=value]:effect' taints the Kubernetes node registers with; effect is NoSchedule, PreferNoSchedule or NoExecute",
+			EnvVar: "FSAS_NODE_TAINTS",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-node-label-prefix",
+			Usage:  "Prefix of node labels with accelerator counts, e.g. '<prefix>/nvidia-h100-size-max'",
+			EnvVar: "FSAS_NODE_LABEL_PREFIX",
+			Value:  cfgutils.DEFAULT_NODE_LABEL_PREFIX,
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-accelerator-models",
+			Usage:  "JSON object or path to a JSON file mapping resource type and FM model to the name used in node labels, e.g. '{\"gpu\": {\"h100\": \"nvidia-h100\"}, \"fpga\": {\"agilex7\": \"intel-agilex7\"}}'; replaces the built-in NVIDIA A100 and H100 table",
+			EnvVar: "FSAS_ACCELERATOR_MODELS",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-machine-group-uuid",
+			Usage:  "FM machine group UUID the composed machine is assigned to",
+			EnvVar: "FSAS_MACHINE_GROUP_UUID",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-machine-owner",
+			Usage:  "Owner or description of the composed machine shown in CDI console; Go template with {{.MachineName}} and {{.NodePool}} (Rancher '<cluster>-<pool>')",
+			Value:  defaultMachineOwnerTemplate,
+			EnvVar: "FSAS_MACHINE_OWNER",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-placement",
+			Usage:  "Fabric placement of the composed machine: empty (any fabric), 'pin' (fabric given by --fsas-placement-fabric-uuid) or 'spread' (fabric not used by other machines of the node pool)",
+			EnvVar: "FSAS_PLACEMENT",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-placement-fabric-uuid",
+			Usage:  "Fabric UUID the composed machine is pinned to when placement is 'pin'",
+			EnvVar: "FSAS_PLACEMENT_FABRIC_UUID",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-placement-enforcement",
+			Usage:  "Action when Fabric Manager does not honour requested placement: 'fail' or 'warn'",
+			Value:  placementEnforcementFail,
+			EnvVar: "FSAS_PLACEMENT_ENFORCEMENT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-create-resume-attempts",
+			Usage:  "Number of retried Creates resuming from the failed phase on the already composed machine before it is removed",
+			Value:  0,
+			EnvVar: "FSAS_CREATE_RESUME_ATTEMPTS",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-status-timeout",
+			Usage:  "Seconds to wait for composition, power on and start of OS installation",
+			Value:  int(WAIT_FOR_STATUS_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_STATUS_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-install-timeout",
+			Usage:  "Seconds to wait for OS installation to complete",
+			Value:  int(WAIT_FOR_STATUS_INSTALL_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_INSTALL_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-stopped-timeout",
+			Usage:  "Seconds to wait for the machine to power off on Stop and Kill",
+			Value:  int(WAIT_FOR_STATUS_STOPPED_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_STOPPED_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-removed-timeout",
+			Usage:  "Seconds to wait for the machine to be decomposed on Remove",
+			Value:  int(WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_REMOVED_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-after-reboot",
+			Usage:  "Seconds to wait for the machine to reach ACTIVE_PON after the reboot applying cloud-init",
+			Value:  int(WAIT_FOR_START_AFTER_REBOOT / time.Second),
+			EnvVar: "FSAS_WAIT_AFTER_REBOOT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-ssh-timeout",
+			Usage:  "Seconds to wait for SSH to come back with the pinned host key after the reboot applying cloud-init",
+			Value:  int(WAIT_FOR_SSH_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_SSH_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-wait-cloud-init-timeout",
+			Usage:  "Seconds to wait for cloud-init to finish after the reboot applying cloud-init",
+			Value:  int(WAIT_FOR_CLOUD_INIT_TIMEOUT / time.Second),
+			EnvVar: "FSAS_WAIT_CLOUD_INIT_TIMEOUT",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-poll-interval",
+			Usage:  "Seconds between machine state queries; used after every state change and just before the expected transition",
+			Value:  int(WAIT_FOR_STATUS_STEP / time.Second),
+			EnvVar: "FSAS_POLL_INTERVAL",
+		},
+		mcnflag.IntFlag{
+			Name:   "fsas-poll-max-interval",
+			Usage:  "Maximum seconds between machine state queries; the interval doubles up to this value while the state does not change",
+			Value:  int(WAIT_FOR_STATUS_MAX_STEP / time.Second),
+			EnvVar: "FSAS_POLL_MAX_INTERVAL",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-keep-on-failure",
+			Usage:  "Keep the machine of a failed Create for debugging: 'off', 'always' (until the next Remove) or a duration such as '2h' after which the machine is reported as failed; Remove and the next Create always delete it",
+			Value:  keepOnFailureOff,
+			EnvVar: "FSAS_KEEP_ON_FAILURE",
+		},
+		mcnflag.StringFlag{
+			Name:   "fsas-stop-escalation",
+			Usage:  "Comma separated steps of Stop tried in turn until the machine powers off: 'graceful' (Fabric Manager graceful shutdown), 'ssh' (OS shutdown over SSH), 'poweroff' (forced power off)",
+			Value:  defaultStopEscalation,
+			EnvVar: "FSAS_STOP_ESCALATION",
+		},
 		mcnflag.StringFlag{
 			Name:   "fsas-userdata",
-			Usage:  "Warning: this field should remain empty as custom userdata are not supported!",
+			Usage:  "Path of user-data provided by Rancher; merged with --fsas-custom-userdata and driver generated cloud-config",
 			EnvVar: "FSAS_USERDATA",
 		},
+		mcnflag.StringFlag{
+			Name:   "fsas-custom-userdata",
+			Usage:  "Own user-data (cloud-config, cloud-boothook or script) given inline or as a path; merged with user-data provided by Rancher, which takes precedence",
+			EnvVar: "FSAS_CUSTOM_USERDATA",
+		},
 	}
 }
 
@@ -303,6 +565,30 @@ func (d *Driver) UnmarshalJSON(data []byte) error {
 	if _, ok := driverOpts.Values["fsas-sles-registration-email"]; ok {
 		d.SlesRegistrationEmail = driverOpts.String("fsas-sles-registration-email")
 	}
+	if _, ok := driverOpts.Values["fsas-sles-modules"]; ok {
+		d.SlesModules = driverOpts.String("fsas-sles-modules")
+	}
+	if _, ok := driverOpts.Values["fsas-os-registration-org"]; ok {
+		d.OsRegistrationOrg = driverOpts.String("fsas-os-registration-org")
+	}
+
+	// Waits are node template options, so a changed template applies to machines created before
+	waits := map[string]*int{
+		"fsas-wait-status-timeout":     &d.WaitStatusTimeout,
+		"fsas-wait-install-timeout":    &d.WaitInstallTimeout,
+		"fsas-wait-stopped-timeout":    &d.WaitStoppedTimeout,
+		"fsas-wait-removed-timeout":    &d.WaitRemovedTimeout,
+		"fsas-wait-after-reboot":       &d.WaitAfterReboot,
+		"fsas-wait-ssh-timeout":        &d.WaitSshTimeout,
+		"fsas-wait-cloud-init-timeout": &d.WaitCloudInitTimeout,
+		"fsas-poll-interval":           &d.PollInterval,
+		"fsas-poll-max-interval":       &d.PollMaxInterval,
+	}
+	for name, wait := range waits {
+		if _, ok := driverOpts.Values[name]; ok {
+			*wait = driverOpts.Int(name)
+		}
+	}
 
 	if _, ok := driverOpts.Values["fsas-userdata"]; ok {
 		userDataFile := driverOpts.String("fsas-userdata")
@@ -332,6 +618,9 @@ func (d *Driver) SetConfigFromFlags(flags drivers.DriverOptions) error {
 	d.SSHPassword = flags.String("fsas-ssh-password")
 	slog.Debug("Driver ", "ssh-password", "<hidden-for-security-reasons>")
 
+	d.SSHBootstrapKey = strings.TrimSpace(flags.String("fsas-ssh-bootstrap-key"))
+	slog.Debug("Driver ", "ssh-bootstrap-key", "<hidden-for-security-reasons>")
+
 	d.TenantUuid = strings.TrimSpace(flags.String("fsas-tenant-uuid"))
 	slog.Debug("Driver ", "Tenant Uuid", d.TenantUuid)
 
@@ -371,6 +660,12 @@ func (d *Driver) SetConfigFromFlags(flags drivers.DriverOptions) error {
 	d.NetworkProvisionDefaultGW = strings.TrimSpace(flags.String("fsas-network-provision-default-gw"))
 	slog.Debug("Driver ", "FSAS provisioning subnet Default GW", d.NetworkBaremetalDefaultGW)
 
+	d.NetworkProvisionAddressMode = strings.TrimSpace(flags.String("fsas-network-provision-address-mode"))
+	slog.Debug("Driver ", "FSAS provisioning subnet address mode", d.NetworkProvisionAddressMode)
+
+	d.NetworkBaremetalAddressMode = strings.TrimSpace(flags.String("fsas-network-baremetal-address-mode"))
+	slog.Debug("Driver ", "FSAS baremetal subnet address mode", d.NetworkBaremetalAddressMode)
+
 	d.DevicesSpecJson = strings.TrimSpace(flags.String("fsas-devices-spec-json"))
 	slog.Debug("Driver ", "FSAS devices specification JSON", d.DevicesSpecJson)
 
@@ -380,6 +675,47 @@ func (d *Driver) SetConfigFromFlags(flags drivers.DriverOptions) error {
 	d.UserDataFile = strings.TrimSpace(flags.String("fsas-userdata"))
 	slog.Debug("Driver ", "FSAS user data file", d.UserDataFile)
 
+	d.CustomUserData = strings.TrimSpace(flags.String("fsas-custom-userdata"))
+	slog.Debug("Driver ", "FSAS custom user data", d.customUserDataForLog())
+
+	d.MachineGroupUUID = strings.TrimSpace(flags.String("fsas-machine-group-uuid"))
+	slog.Debug("Driver ", "FSAS machine group UUID", d.MachineGroupUUID)
+
+	d.MachineOwner = strings.TrimSpace(flags.String("fsas-machine-owner"))
+	slog.Debug("Driver ", "FSAS machine owner", d.MachineOwner)
+
+	d.Placement = strings.TrimSpace(flags.String("fsas-placement"))
+	slog.Debug("Driver ", "FSAS placement", d.Placement)
+
+	d.PlacementFabricUUID = strings.TrimSpace(flags.String("fsas-placement-fabric-uuid"))
+	slog.Debug("Driver ", "FSAS placement fabric UUID", d.PlacementFabricUUID)
+
+	d.PlacementEnforcement = strings.TrimSpace(flags.String("fsas-placement-enforcement"))
+	slog.Debug("Driver ", "FSAS placement enforcement", d.PlacementEnforcement)
+
+	d.CreateResumeAttempts = flags.Int("fsas-create-resume-attempts")
+	slog.Debug("Driver ", "FSAS create resume attempts", d.CreateResumeAttempts)
+
+	d.WaitStatusTimeout = flags.Int("fsas-wait-status-timeout")
+	d.WaitInstallTimeout = flags.Int("fsas-wait-install-timeout")
+	d.WaitStoppedTimeout = flags.Int("fsas-wait-stopped-timeout")
+	d.WaitRemovedTimeout = flags.Int("fsas-wait-removed-timeout")
+	d.WaitAfterReboot = flags.Int("fsas-wait-after-reboot")
+	d.WaitSshTimeout = flags.Int("fsas-wait-ssh-timeout")
+	d.WaitCloudInitTimeout = flags.Int("fsas-wait-cloud-init-timeout")
+	d.PollInterval = flags.Int("fsas-poll-interval")
+	d.PollMaxInterval = flags.Int("fsas-poll-max-interval")
+	slog.Debug("Driver ", "FSAS wait status timeout", d.WaitStatusTimeout, "wait install timeout", d.WaitInstallTimeout,
+		"wait stopped timeout", d.WaitStoppedTimeout, "wait removed timeout", d.WaitRemovedTimeout,
+		"wait after reboot", d.WaitAfterReboot, "wait SSH timeout", d.WaitSshTimeout, "wait cloud-init timeout", d.WaitCloudInitTimeout,
+		"poll interval", d.PollInterval, "poll max interval", d.PollMaxInterval)
+
+	d.KeepOnFailure = strings.TrimSpace(flags.String("fsas-keep-on-failure"))
+	slog.Debug("Driver ", "FSAS keep on failure", d.KeepOnFailure)
+
+	d.StopEscalation = strings.TrimSpace(flags.String("fsas-stop-escalation"))
+	slog.Debug("Driver ", "FSAS stop escalation", d.StopEscalation)
+
 	if err := d.initClients(); err != nil {
 		slog.Error("Error while initializing Keycloak and Fabric Manager clients", "err", err)
 		return err
@@ -394,6 +730,37 @@ func (d *Driver) SetConfigFromFlags(flags drivers.DriverOptions) error {
 	d.SlesRegistrationEmail = strings.TrimSpace(flags.String("fsas-sles-registration-email"))
 	slog.Debug("Driver ", "FSAS SLES registration email", d.SlesRegistrationEmail)
 
+	d.OsRegistrar = strings.ToLower(strings.TrimSpace(flags.String("fsas-os-registrar")))
+	slog.Debug("Driver ", "FSAS OS registrar", d.OsRegistrar)
+
+	d.OsRegistrationOrg = strings.TrimSpace(flags.String("fsas-os-registration-org"))
+	slog.Debug("Driver ", "FSAS OS registration org", d.OsRegistrationOrg)
+
+	d.SlesRmtUrl = strings.TrimSpace(flags.String("fsas-sles-rmt-url"))
+	slog.Debug("Driver ", "FSAS SLES RMT URL", d.SlesRmtUrl)
+
+	d.SlesRmtCaCert = strings.TrimSpace(flags.String("fsas-sles-rmt-ca-cert"))
+	d.SlesRmtCaFingerprint = strings.TrimSpace(flags.String("fsas-sles-rmt-ca-fingerprint"))
+	slog.Debug("Driver ", "FSAS SLES RMT CA fingerprint", d.SlesRmtCaFingerprint)
+
+	d.Rke2AirgapArtifacts = strings.TrimSpace(flags.String("fsas-rke2-airgap-artifacts"))
+	slog.Debug("Driver ", "FSAS RKE2 air-gap artifacts", d.Rke2AirgapArtifacts)
+
+	d.SlesModules = strings.TrimSpace(flags.String("fsas-sles-modules"))
+	slog.Debug("Driver ", "FSAS SLES modules", sshutils.RedactSuseModules(d.SlesModules))
+
+	d.NodeLabels = strings.TrimSpace(flags.String("fsas-node-labels"))
+	slog.Debug("Driver ", "FSAS node labels", d.NodeLabels)
+
+	d.NodeTaints = strings.TrimSpace(flags.String("fsas-node-taints"))
+	slog.Debug("Driver ", "FSAS node taints", d.NodeTaints)
+
+	d.NodeLabelPrefix = strings.TrimSpace(flags.String("fsas-node-label-prefix"))
+	slog.Debug("Driver ", "FSAS node label prefix", d.NodeLabelPrefix)
+
+	d.AcceleratorModels = strings.TrimSpace(flags.String("fsas-accelerator-models"))
+	slog.Debug("Driver ", "FSAS accelerator models", d.AcceleratorModels)
+
 	return d.checkConfig()
 }
 
@@ -455,16 +822,10 @@ func (d *Driver) initSshManager() error {
 	if !d.SshManager.IsInit() {
 		slog.Warn("SSH Manager is NOT initialized then start init procedure")
 
-		// OsImageSshHostParsedKey may be nil when the driver was restored from JSON
-		// (e.g. during Remove, Start, Stop) because it is not serializable.
-		// In that case, re-parse from the raw string.
-		if d.OsImageSshHostParsedKey == nil && d.OsImageSshHostPubKey != "" {
-			parsedKey, err := sshutils.ParseSSHPublicKey(d.OsImageSshHostPubKey)
-			if err != nil {
-				slog.Error("Could not parse SSH host public key:", "err", err)
-				return fmt.Errorf("invalid SSH host public key format: %w", err)
-			}
-			d.OsImageSshHostParsedKey = parsedKey
+		hostPublicKey, err := d.sshHostPublicKey()
+		if err != nil {
+			slog.Error("Could not parse SSH host public key:", "err", err)
+			return err
 		}
 
 		hostName, err := d.GetSSHHostname()
@@ -473,7 +834,19 @@ func (d *Driver) initSshManager() error {
 			return err
 		}
 		slog.Info("Acquired SSH hostname: ", "hostname", hostName)
-		sshManager, err := sshutils.NewStandardSshManager(hostName, d.GetSSHUsername(), d.SSHPassword, d.GetSSHKeyPath(), d.OsImageSshHostParsedKey)
+		bootstrapKeyPath, err := d.bootstrapKeyPath()
+		if err != nil {
+			slog.Error("Could not prepare SSH bootstrap key: ", "err", err)
+			return err
+		}
+
+		// Images accessed with a bootstrap key have password login disabled, so the password is not tried
+		sshPassword := d.SSHPassword
+		if bootstrapKeyPath != "" {
+			sshPassword = ""
+		}
+
+		sshManager, err := sshutils.NewStandardSshManager(hostName, d.GetSSHUsername(), sshPassword, d.GetSSHKeyPath(), bootstrapKeyPath, hostPublicKey)
 		if err != nil {
 			slog.Error("Could not create SSH Manager because of an error: ", "err", err)
 			return err
@@ -491,8 +864,11 @@ func (d *Driver) checkConfig() error {
 	if d.SSHUser == "" {
 		return fmt.Errorf(errorMandatoryOption, "SSH user", "--fsas-ssh-user")
 	}
-	if d.SSHPassword == "" {
-		return fmt.Errorf(errorMandatoryOption, "SSH password", "--fsas-ssh-password")
+	if d.SSHPassword == "" && d.SSHBootstrapKey == "" {
+		return fmt.Errorf(errorMandatoryOption, "SSH password or SSH bootstrap key", "--fsas-ssh-password or --fsas-ssh-bootstrap-key")
+	}
+	if err := checkBootstrapKey(d.SSHBootstrapKey); err != nil {
+		return err
 	}
 	if d.ComputeConditionsJson == "" {
 		return fmt.Errorf(errorMandatoryOption, "Compute conditions (JSON)", "--fsas-compute-conditions-json")
@@ -515,6 +891,30 @@ func (d *Driver) checkConfig() error {
 	if d.OsImageName == "" {
 		return fmt.Errorf(errorMandatoryOption, "OS image name", "--fsas-os-image-name")
 	}
+	if _, err := renderMachineTemplate(d.MachineOwner, d.MachineName); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", "--fsas-machine-owner", err)
+	}
+	if err := d.checkPlacementConfig(); err != nil {
+		return err
+	}
+	if d.CreateResumeAttempts < 0 {
+		return fmt.Errorf("number of create resume attempts must not be negative; got %d", d.CreateResumeAttempts)
+	}
+	if err := d.checkWaitConfig(); err != nil {
+		return err
+	}
+	if _, _, err := parseKeepOnFailure(d.KeepOnFailure); err != nil {
+		return err
+	}
+	if _, err := parseStopEscalation(d.StopEscalation); err != nil {
+		return err
+	}
+	if err := d.checkCustomUserData(); err != nil {
+		return err
+	}
+	if err := d.checkNetworkConfig(); err != nil {
+		return err
+	}
 
 	if err := d.FabricManager.ValidateTenant(d.TenantUuid, d.Keycloak.GetToken()); err != nil {
 		slog.Error("tenant_uuid validation unsuccessful: ", "err", err)
@@ -532,7 +932,34 @@ func (d *Driver) checkConfig() error {
 	}
 	d.OsImageSshHostParsedKey = parsedKey
 
-	if d.SlesRegistrationCode != "" {
+	if err := sshutils.ValidateOsRegistrar(d.OsRegistrar); err != nil {
+		return fmt.Errorf("%w, use one of 'auto', 'suseconnect', 'subscription-manager', 'pro', 'none' in %s", err, "--fsas-os-registrar")
+	}
+
+	/*
+		With 'auto' the OS is known only after installation, so the registrar the registration code is meant for
+		is told by the credentials given with it; missing credentials are then reported before the OS is installed
+	*/
+	registrar := d.OsRegistrar
+	if d.SlesRegistrationCode != "" && registrar == sshutils.OS_REGISTRAR_AUTO {
+		switch {
+		case d.SlesRegistrationEmail != "" && d.OsRegistrationOrg != "":
+			return fmt.Errorf("with %s 'auto' the registration code must come either with SLES registration email or with organization ID, not both", "--fsas-os-registrar")
+		case d.SlesRegistrationEmail != "":
+			registrar = sshutils.OS_REGISTRAR_SUSECONNECT
+		case d.OsRegistrationOrg != "":
+			registrar = sshutils.OS_REGISTRAR_SUBSCRIPTION_MANAGER
+		default:
+			return fmt.Errorf("with %s 'auto' the registration code must come with SLES registration email or organization ID. Fill in param %s for SLES, %s for Red Hat, or use %s 'pro' for Ubuntu Pro",
+				"--fsas-os-registrar", "--fsas-sles-registration-email", "--fsas-os-registration-org", "--fsas-os-registrar")
+		}
+	}
+
+	if d.SlesRegistrationCode != "" && registrar == sshutils.OS_REGISTRAR_SUBSCRIPTION_MANAGER && d.OsRegistrationOrg == "" {
+		return fmt.Errorf("when OS is registered with subscription-manager then organization ID must not be empty. Fill in param %s", "--fsas-os-registration-org")
+	}
+
+	if d.SlesRegistrationCode != "" && registrar == sshutils.OS_REGISTRAR_SUSECONNECT {
 		if d.SlesRegistrationEmail == "" {
 			return fmt.Errorf("when SLES registration code is not empty then SLES registration email must also not be empty. Fill in param %s", "--fsas-sles-registration-email")
 		} else {
@@ -542,13 +969,69 @@ func (d *Driver) checkConfig() error {
 			}
 		}
 	}
-	return nil
+
+	if _, err := sshutils.ParseSuseModules(d.SlesModules); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-sles-modules", err)
+	}
+
+	if _, err := cfgutils.ParseNodeLabels(d.NodeLabels); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-node-labels", err)
+	}
+	if _, err := cfgutils.ParseNodeTaints(d.NodeTaints); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-node-taints", err)
+	}
+
+	if err := d.checkAcceleratorLabels(); err != nil {
+		return err
+	}
+
+	if err := d.checkAirgapArtifacts(); err != nil {
+		return err
+	}
+
+	return d.checkRmtConfig()
 }
 
 // Create a host using the driver's config
 func (d *Driver) Create() error {
+	if err := d.recoverFromJournal(); err != nil {
+		slog.Error("Error encountered while recovering interrupted Create: ", "err", err)
+		return err
+	}
+
+	if d.KeptOnFailure {
+		slog.Info("Removing machine kept after the previous failed Create: ", "machineUUID", d.MachineUUID)
+		if err := d.Remove(); err != nil {
+			return fmt.Errorf("cannot remove machine %s kept after failed Create: %w", d.MachineUUID, err)
+		}
+		d.MachineUUID = ""
+		d.LastCompletedPhase = ""
+		d.CreateResumeAttemptsUsed = 0
+	}
+
 	if err := d.innerCreate(); err != nil {
 		slog.Error("Error encountered during instance creation: ", "err", err)
+		if d.canResumeCreate() {
+			d.CreateResumeAttemptsUsed++
+			slog.Warn("Keeping partially created machine to resume Create on retry: ",
+				"machineUUID", d.MachineUUID,
+				"last_completed_phase", d.LastCompletedPhase,
+				"resume_attempt", d.CreateResumeAttemptsUsed,
+				"resume_attempts", d.CreateResumeAttempts)
+			if journalErr := d.writeJournal(); journalErr != nil {
+				slog.Warn("Could not update Create journal: ", "err", journalErr)
+			}
+			return err
+		}
+
+		if d.keepFailedMachine() {
+			return err
+		}
+
+		// Retried Create starts from composing a new machine
+		d.LastCompletedPhase = ""
+		d.CreateResumeAttemptsUsed = 0
+
 		slog.Info("Attempting to remove partially created machine: ", "machineUUID", d.MachineUUID)
 		if removalErr := d.Remove(); removalErr != nil {
 			slog.Error("The attempt to remove partially provisioned machine failed: ", "err", removalErr)
@@ -572,136 +1055,195 @@ func (d *Driver) innerCreate() error {
 		return err
 	}
 
-	machineSpecArgs := models.MachineSpecsArgs{
-		ComputeConditionsJson:     d.ComputeConditionsJson,
-		DevicesSpecJson:           d.DevicesSpecJson,
-		NetworkBaremetalPort:      d.NetworkBaremetalPort,
-		NetworkBaremetalUUID:      d.NetworkBaremetalUUID,
-		NetworkBaremetalDefaultGW: d.NetworkBaremetalDefaultGW,
-		NetworkProvisionPort:      d.NetworkProvisionPort,
-		NetworkProvisionUUID:      d.NetworkProvisionUUID,
-		NetworkProvisionDefaultGW: d.NetworkProvisionDefaultGW,
-		NtpServer:                 d.NtpUrl,
-		DnsServer:                 d.DnsIp,
-	}
-
-	machineUUID, err := d.FabricManager.CreateMachine(d.MachineName, d.TenantUuid, machineSpecArgs, d.Keycloak.GetToken())
-	if err != nil {
-		return err
-	}
+	return d.runCreatePhases()
+}
 
-	d.MachineUUID = machineUUID
-	slog.Info("Successfully filled MachineUUID: ", "MachineUUID", d.MachineUUID)
+// machineTemplateData holds values available in templated flags
+type machineTemplateData struct {
+	MachineName string
+	NodePool    string
+}
 
-	slog.Info("Waiting for status: ", "status", ACTIVE_POFF)
-	if err := d.waitForStatus(ACTIVE_POFF, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_TIMEOUT); err != nil {
-		return err
+// rancherNodePool Returns name of Rancher machine pool deployment ('<cluster>-<pool>').
+// Rancher names machines by appending two generated segments ('-<hash>-<suffix>') to it.
+func rancherNodePool(machineName string) string {
+	parts := strings.Split(machineName, "-")
+	if len(parts) < 3 {
+		return machineName
 	}
+	return strings.Join(parts[:len(parts)-2], "-")
+}
 
-	_, bootSsdId, _, err := d.FabricManager.GetMachineDetails(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+// renderMachineTemplate Returns text of templated flag filled in with data of the machine
+func renderMachineTemplate(text, machineName string) (string, error) {
+	tmpl, err := template.New("machine").Parse(text)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if err := d.FabricManager.ImageInstall(d.TenantUuid, bootSsdId, d.OsImageName, d.Keycloak.GetToken()); err != nil {
-		return err
+	var rendered strings.Builder
+	data := machineTemplateData{MachineName: machineName, NodePool: rancherNodePool(machineName)}
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(rendered.String()), nil
+}
 
-	slog.Info("Waiting for the installation of the operating system: ", "status", OS_INSTALLING)
-	if err := d.waitForStatus(OS_INSTALLING, WAIT_FOR_STATUS_STEP_FOR_INSTALLATION, WAIT_FOR_STATUS_TIMEOUT); err != nil {
-		return err
+// verifyMachineAssignment Verify that Fabric Manager assigned the machine to requested group and owner
+func (d *Driver) verifyMachineAssignment() error {
+	if d.MachineGroupUUID == "" && d.MachineOwner == "" {
+		return nil
 	}
 
-	slog.Info("Installing operating system: ", "OS", d.OsImageName)
-
-	slog.Info("Waiting for operating system installation to complete: ", "status", ACTIVE_POFF)
-	if err := d.waitForStatus(ACTIVE_POFF, WAIT_FOR_STATUS_INSTALL_STEP, WAIT_FOR_STATUS_INSTALL_TIMEOUT); err != nil {
+	machine, err := d.FabricManager.GetMachine(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
 		return err
 	}
 
-	if err := d.Start(); err != nil {
-		return err
+	if machine.GroupUUID != d.MachineGroupUUID || machine.MachineOwner != d.MachineOwner {
+		slog.Error("Machine assignment differs from requested one: ",
+			"grp_uuid", machine.GroupUUID, "requested_grp_uuid", d.MachineGroupUUID,
+			"mach_owner", machine.MachineOwner, "requested_mach_owner", d.MachineOwner)
+		return fmt.Errorf("machine %s was not assigned as requested: group '%s' (requested '%s'), owner '%s' (requested '%s')",
+			d.MachineUUID, machine.GroupUUID, d.MachineGroupUUID, machine.MachineOwner, d.MachineOwner)
 	}
 
-	if err := d.assignIpAddresses(); err != nil {
-		return err
-	}
+	slog.Info("Successfully verified machine assignment: ", "grp_uuid", d.MachineGroupUUID, "mach_owner", d.MachineOwner)
+	return nil
+}
 
-	if err := d.initSshManager(); err != nil {
-		slog.Error("Error while initializing SSH Manager", "err", err)
-		return err
+// checkPlacementConfig Verify if placement flags are consistent
+func (d *Driver) checkPlacementConfig() error {
+	switch d.Placement {
+	case "", placementSpread:
+	case placementPin:
+		if d.PlacementFabricUUID == "" {
+			return fmt.Errorf(errorMandatoryOption, "Fabric UUID for 'pin' placement", "--fsas-placement-fabric-uuid")
+		}
+	default:
+		return fmt.Errorf("placement must be empty, '%s' or '%s'; got '%s'", placementPin, placementSpread, d.Placement)
 	}
 
-	if err := d.SshManager.ExchangeKeys(); err != nil {
-		return err
+	switch d.PlacementEnforcement {
+	case "", placementEnforcementFail, placementEnforcementWarn:
+		return nil
+	default:
+		return fmt.Errorf("placement enforcement must be '%s' or '%s'; got '%s'", placementEnforcementFail, placementEnforcementWarn, d.PlacementEnforcement)
 	}
+}
 
-	if err := d.SshManager.RegisterOS(d.SlesRegistrationCode, d.SlesRegistrationEmail); err != nil {
-		slog.Error("Failed to register OS via SSH using SUSEConnect: ", "err", err, "email", d.SlesRegistrationEmail)
-		return err
-	}
+// placementConditions Returns fabric conditions added to the compute specification:
+// the pinned fabric, or exclusion of fabrics used by other machines of the same node pool
+func (d *Driver) placementConditions() ([]models.Condition, error) {
+	switch d.Placement {
+	case placementPin:
+		return []models.Condition{{Column: fm.FabricConditionColumn, Operator: "eq", Value: d.PlacementFabricUUID}}, nil
+	case placementSpread:
+		machines, err := d.FabricManager.ListMachines(d.TenantUuid, d.Keycloak.GetToken())
+		if err != nil {
+			return nil, err
+		}
 
-	if !d.CfgManager.IsInit() {
-		cfgManager := cfgutils.NewStandardCfgManager(d.DevicesSpecJson)
-		d.CfgManager = cfgManager
+		// Fabric Manager stores machine names with '-' replaced by '_'
+		nodePool := rancherNodePool(d.MachineName)
+		var conditions []models.Condition
+		usedFabrics := map[string]bool{}
+		for _, m := range machines {
+			state := CdiMachineState(m.MachineStatus)
+			if m.FabricUUID == "" || usedFabrics[m.FabricUUID] || state == UNBUILDING || state == UNBUILDED {
+				continue
+			}
+			if rancherNodePool(strings.ReplaceAll(m.MachineName, "_", "-")) != nodePool {
+				continue
+			}
+			usedFabrics[m.FabricUUID] = true
+			conditions = append(conditions, models.Condition{Column: fm.FabricConditionColumn, Operator: "ne", Value: m.FabricUUID})
+		}
+		slog.Info("Spreading machine across fabrics: ", "node_pool", nodePool, "excluded_fabrics", conditions)
+		return conditions, nil
+	default:
+		return nil, nil
 	}
+}
 
-	// Prepare scripts execution parameters
-	scriptPath := "" // Random paths
-	removeOnFinish := true
-	runSudo := true
-
-	// Generate script content for RKE2 setup
-	overrideProviderIdScriptContent := d.CfgManager.PrepareRke2ConfigScript("100-fsas-providerid", d.MachineUUID)
-
-	if err := d.SshManager.ExecuteScript(scriptPath, overrideProviderIdScriptContent, removeOnFinish, runSudo); err != nil {
-		return err
+// verifyMachinePlacement Verify that Fabric Manager composed the machine on a fabric satisfying requested conditions.
+// Depending on placement enforcement, a violation is an error or only a warning.
+func (d *Driver) verifyMachinePlacement(fabricConditions []models.Condition) error {
+	if d.Placement == "" {
+		return nil
 	}
 
-	if err := d.applyCloudInit(d.GetMachineName()); err != nil {
+	machine, err := d.FabricManager.GetMachine(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
 		return err
 	}
+	d.FabricUUID = machine.FabricUUID
 
-	if err := d.SshManager.DisablePasswordSSHLogin(); err != nil {
-		slog.Error("Failed to disable password login: ", "err", err)
-		return err
+	if fm.MatchFabricConditions(machine.FabricUUID, fabricConditions) {
+		slog.Info("Successfully verified machine placement: ", "placement", d.Placement, "fabric_uuid", d.FabricUUID)
+		return nil
 	}
 
-	return nil
+	if d.PlacementEnforcement == placementEnforcementWarn {
+		slog.Warn("Fabric Manager did not honour requested placement: ", "placement", d.Placement, "fabric_uuid", d.FabricUUID, "conditions", fabricConditions)
+		return nil
+	}
+	return fmt.Errorf("machine %s was composed on fabric %s which violates '%s' placement", d.MachineUUID, d.FabricUUID, d.Placement)
 }
 
 var osReadFile = os.ReadFile
 
-// applyCloudInit Save user-data and meta-data files on remote machine
+// applyCloudInit Save user-data, network-config and meta-data files on remote machine,
+// then reboot it to apply them and wait until it is ready
 func (d *Driver) applyCloudInit(sshHostName string) error {
 	userdataPath := filepath.Join(cloudInitDirPath, "user-data")
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
+	networkConfigPath := filepath.Join(cloudInitDirPath, "network-config")
 
-	if d.UserDataFile != "" {
-		userDataFileContent, err := osReadFile(d.UserDataFile)
-		if err != nil {
+	hostKey, err := generateHostKey()
+	if err != nil {
+		return err
+	}
+	userData, err := d.prepareUserData(hostKey)
+	if err != nil {
+		return err
+	}
+	if userData != "" {
+		if err := d.SshManager.WriteFileOnRemoteMachine(userdataPath, userData, 0700); err != nil {
 			return err
 		}
-
-		if err := d.SshManager.WriteFileOnRemoteMachine(userdataPath, string(userDataFileContent), 0700); err != nil {
+	}
+	networkConfig, err := d.prepareNetworkConfig()
+	if err != nil {
+		return err
+	}
+	if networkConfig != "" {
+		if err := d.SshManager.WriteFileOnRemoteMachine(networkConfigPath, networkConfig, 0700); err != nil {
 			return err
 		}
 	}
-	metadataContent := d.CfgManager.PrepareMetadata(d.MachineUUID, sshHostName)
+
+	metadataContent, err := d.prepareMetadata(sshHostName)
+	if err != nil {
+		return err
+	}
 
 	if err := d.SshManager.WriteFileOnRemoteMachine(metadataPath, metadataContent, 0700); err != nil {
 		return err
 	}
 
+	bootId, err := d.SshManager.BootId()
+	if err != nil {
+		slog.Error("Could not read boot ID before reboot: ", "err", err)
+		return err
+	}
 	if err := d.SshManager.RebootCloudInit(); err != nil {
 		slog.Error("Potential error while rebooting cloud init: ", "err", err)
 		return err
 	}
+	d.pinHostKey(hostKey.publicKey)
 
-	// Wait for the machine to reach the Running state
-	slog.Info("Waiting for the machine to reach the Running state")
-	statusClock.Sleep(WAIT_FOR_START_AFTER_REBOOT)
-	return nil
+	return d.waitForReadiness(bootId)
 }
 
 // GetSSHHostname returns hostname for use with ssh
@@ -720,8 +1262,14 @@ func (d *Driver) GetIP() (string, error) {
 	return d.IPAddress, nil
 }
 
-// GetState returns the state that the host is in (running, stopped, etc)
+// GetState returns the state that the host is in (running, stopped, etc); a kept machine with expired time-to-live is in error
 func (d *Driver) GetState() (state.State, error) {
+	if d.keptMachineExpired() {
+		slog.Warn("Time-to-live of machine kept after failed Create has run out, Remove or the next Create deletes it: ",
+			"machineUUID", d.MachineUUID, "kept_until", d.keptUntilString())
+		return state.Error, nil
+	}
+
 	cdiState, err := d.getCdiState()
 	if err != nil {
 		return state.Error, err
@@ -762,23 +1310,54 @@ func (d *Driver) getCdiState() (CdiMachineState, error) {
 func (d *Driver) mapMachineStatusToState(cdiState CdiMachineState) state.State {
 	slog.Debug("Map FM machineStatus code to State code")
 
-	switch cdiState {
-	case BUILDING, BUILDING_BEFORE_QUEUE, BOOTING:
-		return state.Starting
-	case ACTIVE_PON:
-		return state.Running
-	case POWERING_OFF:
-		return state.Stopping
-	case ACTIVE_POFF, UNBUILDED, UNBUILDING, UNBUILDING_WAIT:
-		return state.Stopped
-	case OS_INSTALLING:
-		return state.Paused
-	case ERROR:
-		return state.Error
-	default:
-		slog.Warn("Unrecognized machine status: ", "machineStatus", cdiState)
-		return state.None
+	machineState, known := cdiState.RancherState()
+	if !known {
+		slog.Warn("Unrecognized machine status: ", "machineStatus", int(cdiState), "mach_status_detail", d.machineStatusDetail())
 	}
+	return machineState
+}
+
+// machineStatusDetail Returns Fabric Manager's status detail of the machine or empty string when it cannot be read
+func (d *Driver) machineStatusDetail() string {
+	details, err := d.FabricManager.GetMachine(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
+		slog.Debug("Could not get Machine status detail: ", "err", err)
+		return ""
+	}
+	return details.MachineStatusDetail
+}
+
+// GetExtendedState returns Rancher state of the host together with the raw CDI state and
+// Fabric Manager's status details, for diagnostics
+func (d *Driver) GetExtendedState() (ExtendedState, error) {
+	if d.MachineUUID == "" {
+		slog.Error("Machine's UUID was unexpectedly empty: ", "machine_name", d.MachineName)
+		return ExtendedState{State: state.Error, CdiState: ERROR}, fmt.Errorf("machine uuid is empty")
+	}
+
+	if err := d.initClients(); err != nil {
+		return ExtendedState{State: state.Error, CdiState: ERROR}, err
+	}
+
+	details, err := d.FabricManager.GetMachine(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
+		slog.Error("Could not get Machine status: ", "err", err)
+		return ExtendedState{State: state.Error, CdiState: ERROR}, err
+	}
+
+	extendedState := ExtendedState{
+		CdiState:     CdiMachineState(details.MachineStatus),
+		OpStatus:     details.MachineOpStatus,
+		StatusDetail: details.MachineStatusDetail,
+	}
+	machineState, known := extendedState.CdiState.RancherState()
+	if !known {
+		slog.Warn("Unrecognized machine status: ", "machineStatus", details.MachineStatus, "mach_status_detail", details.MachineStatusDetail)
+	}
+	extendedState.State = machineState
+
+	slog.Debug("Extended state of the host: ", "state", extendedState)
+	return extendedState, nil
 }
 
 // GetURL returns a Docker compatible host URL for connecting to this host
@@ -796,9 +1375,13 @@ func (d *Driver) GetURL() (string, error) {
 	return fmt.Sprintf("tcp://%s:%d", ip, 2376), nil
 }
 
-// waitForStatus Wait for host status
-func (d *Driver) waitForStatus(expectedState CdiMachineState, step, timeout time.Duration) error {
+// waitForStatus Wait for host to reach the target status of the operation.
+// Fails at once when the host reaches a status from which the target cannot be reached.
+func (d *Driver) waitForStatus(op machineOperation, timeout time.Duration) error {
 	startTime := statusClock.Now()
+	expectedState := op.target
+	poller := newStatusPoller(expectedState, d.pollInterval(), d.pollMaxInterval())
+	timeline := stateTimeline{operation: op.name}
 
 	for {
 		currentState, err := d.getCdiState()
@@ -806,6 +1389,7 @@ func (d *Driver) waitForStatus(expectedState CdiMachineState, step, timeout time
 			slog.Error("Error while checking state: ", "err", err)
 			return fmt.Errorf("error getting state: %w", err)
 		}
+		timeline.observe(currentState, statusClock.Now())
 
 		if currentState == ERROR {
 			slog.Error("Received ERROR state")
@@ -817,12 +1401,19 @@ func (d *Driver) waitForStatus(expectedState CdiMachineState, step, timeout time
 			return nil
 		}
 
+		if !op.allows(currentState) {
+			slog.Error("Machine reached state which cannot lead to the required status: ", "operation", op.name, "expected state", expectedState, "current state", currentState)
+			return fmt.Errorf("machine in state %s cannot reach state %s during %s",
+				describeState(currentState), describeState(expectedState), op.name)
+		}
+
 		if statusClock.Since(startTime) >= timeout {
-			slog.Error("Required status was not achieved within the specified time: ", "expected state", expectedState, "current state", currentState, "timeout", WAIT_FOR_STATUS_TIMEOUT)
+			slog.Error("Required status was not achieved within the specified time: ", "expected state", expectedState, "current state", currentState, "timeout", timeout)
 			return fmt.Errorf("error: required status was not achieved within the specified time")
 		}
 
-		slog.Debug("Required status is not equal to received status, another attempt will occur: ", "expected state", expectedState, "current state", currentState)
+		step := poller.next(currentState)
+		slog.Debug("Required status is not equal to received status, another attempt will occur: ", "expected state", expectedState, "current state", currentState, "step", step)
 		statusClock.Sleep(step)
 	}
 }
@@ -849,7 +1440,7 @@ func (d *Driver) Kill() error {
 	}
 
 	slog.Info("Waiting for status: ", "status", ACTIVE_POFF)
-	if err := d.waitForStatus(ACTIVE_POFF, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_STOPPED_TIMEOUT); err != nil {
+	if err := d.waitForStatus(opShutdown, d.waitStoppedTimeout()); err != nil {
 		slog.Error("Error while waiting for status: ", "status", ACTIVE_POFF, "err", err)
 		return err
 	}
@@ -864,6 +1455,11 @@ func (d *Driver) Remove() error {
 	slog.Debug(fmt.Sprintf("BaseDriver struct: %+v", d.BaseDriver))
 	slog.Debug(fmt.Sprintf("Driver struct: %+v", d))
 
+	// Machine of a Create interrupted before Rancher saved driver state is known only to the journal
+	if _, err := d.adoptJournal(); err != nil {
+		slog.Warn("Could not read Create journal: ", "err", err)
+	}
+
 	if d.MachineUUID == "" {
 		slog.Warn("Machine's UUID was unexpectedly empty: ", "machine_name", d.MachineName)
 		/*
@@ -873,21 +1469,17 @@ func (d *Driver) Remove() error {
 		return nil
 	}
 
+	if d.KeptOnFailure {
+		slog.Info("Removing machine kept after failed Create for debugging: ", "machineUUID", d.MachineUUID, "kept_until", d.keptUntilString())
+	}
+
 	// in case of not initialized Fabric Manager caused by e.g. method UnmarshalJSON verify init again
 	// Fabric Manager needs also keycloak client then init both
 	if err := d.initClients(); err != nil {
 		return err
 	}
 
-	// Check if ssh manager is available for e.g. OS deregistration, if not - proceed with machine removal anyway
-	if err := d.initSshManager(); err != nil {
-		// If ssh manager cannot be initialized then do not return error and proceed with machine removal
-		slog.Warn("error while initializing SSH Manager, proceeding with machine removal: ", "err", err)
-	} else {
-		if err := d.SshManager.DeregisterOS(); err != nil {
-			slog.Warn("Could not deregister SLES OS, manual action might be required if node was previously registered:", "err", err)
-		}
-	}
+	d.deregisterOS()
 
 	if err := d.FabricManager.RemoveMachine(d.MachineUUID, d.TenantUuid, d.Keycloak.GetToken()); err != nil {
 		slog.Error("Could not remove Machine: ", "machineUUID", d.MachineUUID, "err", err)
@@ -895,15 +1487,34 @@ func (d *Driver) Remove() error {
 	}
 
 	slog.Info("Waiting for status: ", "status", UNBUILDED)
-	if err := d.waitForStatus(UNBUILDED, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT); err != nil {
+	if err := d.waitForStatus(opRemove, d.waitRemovedTimeout()); err != nil {
 		slog.Error("Error while waiting for status: ", "status", UNBUILDED, "err", err)
 		return err
 	}
 
 	slog.Info("Successfully removed Machine: ", "machineUUID", d.MachineUUID)
+	d.KeptOnFailure = false
+	d.KeptOnFailureUntil = time.Time{}
+
+	if err := d.removeJournal(); err != nil {
+		slog.Warn("Could not remove Create journal: ", "err", err)
+	}
 	return nil
 }
 
+// deregisterOS Deregister OS of the machine before it is wiped; failures only warn as the machine is going away anyway
+func (d *Driver) deregisterOS() {
+	// Check if ssh manager is available for e.g. OS deregistration, if not - proceed anyway
+	if err := d.initSshManager(); err != nil {
+		// If ssh manager cannot be initialized then do not return error and proceed
+		slog.Warn("error while initializing SSH Manager, proceeding without OS deregistration: ", "err", err)
+		return
+	}
+	if err := d.SshManager.DeregisterOS(d.osRegistration()); err != nil {
+		slog.Warn("Could not deregister OS, manual action might be required if node was previously registered:", "err", err)
+	}
+}
+
 // Restart a host. This may just call Stop(); Start() if the provider does not
 // have any special restart behaviour.
 func (d *Driver) Restart() error {
@@ -949,7 +1560,7 @@ func (d *Driver) Start() error {
 
 	// Wait for the machine to reach the Running state
 	slog.Info("Waiting for status: ", "status", ACTIVE_PON)
-	if err := d.waitForStatus(ACTIVE_PON, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_TIMEOUT); err != nil {
+	if err := d.waitForStatus(opPowerOn, d.waitStatusTimeout()); err != nil {
 		slog.Error("Error occured during waitForStatus execution: ", "err", err)
 		return err
 	}
@@ -969,15 +1580,8 @@ func (d *Driver) Stop() error {
 		}
 	}
 
-	slog.Info("requesting graceful shutdown for machine: ", "machine_uuid", d.MachineUUID)
-	if err := d.FabricManager.GracefulShutdown(d.MachineUUID, d.TenantUuid, d.Keycloak.GetToken()); err != nil {
-		slog.Error("Graceful shutdown failed for: ", "machine_uuid", d.MachineUUID, "err", err)
-		return err
-	}
-
-	slog.Info("Waiting for status: ", "status", ACTIVE_POFF)
-	if err := d.waitForStatus(ACTIVE_POFF, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_STOPPED_TIMEOUT); err != nil {
-		slog.Error("Error while waiting for status: ", "status", ACTIVE_POFF, "err", err)
+	if err := d.stopWithEscalation(); err != nil {
+		slog.Error("Could not stop Machine: ", "machineUUID", d.MachineUUID, "err", err)
 		return err
 	}
 
@@ -989,6 +1593,30 @@ func (d *Driver) Stop() error {
 // PreCreateCheck allows for pre-create operations to make sure a driver is ready for creation
 func (d *Driver) PreCreateCheck() error {
 	slog.Debug("Checks before creating host")
+	if err := d.initClients(); err != nil {
+		return err
+	}
+
+	return d.checkTenantQuota()
+}
+
+// checkTenantQuota Verify that resources requested for the machine fit into the tenant quota,
+// so creation is refused up front instead of failing inside Fabric Manager
+func (d *Driver) checkTenantQuota() error {
+	quotas, err := d.FabricManager.GetTenantQuota(d.TenantUuid, d.Keycloak.GetToken())
+	if err != nil {
+		slog.Error("Could not get tenant quota because of an error: ", "err", err)
+		return err
+	}
+	if len(quotas) == 0 {
+		slog.Info("Tenant has no resource quota defined; skipping quota check", "tenant_uuid", d.TenantUuid)
+		return nil
+	}
+
+	if err := fm.CheckTenantQuota(quotas, d.DevicesSpecJson); err != nil {
+		return fmt.Errorf("machine %s cannot be created in tenant %s: %w", d.MachineName, d.TenantUuid, err)
+	}
+	slog.Info("Requested resources fit into tenant quota", "tenant_uuid", d.TenantUuid)
 	return nil
 }
 
@@ -1053,5 +1681,5 @@ func logContentOfCloudConfigFile(cloudConfigFilePath string) {
 		slog.Error("Error while reading cloud config file: ", "path", cloudConfigFilePath, "err", err)
 	}
 	slog.Debug("Cloud config file content: ")
-	slog.Debug(string(content))
+	slog.Debug(cfgutils.RedactUserData(string(content)))
 }
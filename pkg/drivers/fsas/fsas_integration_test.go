@@ -0,0 +1,579 @@
+package fsas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cfgMock "github.com/fujitsu/docker-machine-driver-fsas/cfgutils/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/fm"
+	"github.com/fujitsu/docker-machine-driver-fsas/fm/fmfake"
+	keycloakMock "github.com/fujitsu/docker-machine-driver-fsas/keycloak/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+
+	"github.com/rancher/machine/libmachine/drivers"
+	"github.com/rancher/machine/libmachine/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const integrationDevicesSpec = `[{"res_type":"storage","res_num":1,"tags":{"is_bootstorage":true},"res_spec":{"condition":[{"column":"vendor","operator":"eq","value":"samsung"}]}}]`
+
+// newIntegrationDriver Returns driver talking over HTTP to a fake Fabric Manager.
+// Keycloak, SSH and configuration managers are mocked.
+func newIntegrationDriver(t *testing.T) (*Driver, *fmfake.Server, *sshMock.MockSshManager) {
+	return newIntegrationDriverWithOptions(t, fmfake.Options{})
+}
+
+// newIntegrationDriverWithOptions Returns driver talking to a fake Fabric Manager configured with opts;
+// the clock is always replaced by one shared with the driver
+func newIntegrationDriverWithOptions(t *testing.T, opts fmfake.Options) (*Driver, *fmfake.Server, *sshMock.MockSshManager) {
+	clock := timeutils.NewManualClock(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	statusClock = clock
+	t.Cleanup(func() { statusClock = timeutils.NewRealClock() })
+
+	opts.Clock = clock
+	server := fmfake.NewServer(opts)
+	t.Cleanup(server.Close)
+
+	tenant := "4a9587f0-e7da-4824-8127-d5ca5ddf8c34"
+	server.AddTenant(tenant)
+
+	fmc, err := fm.NewFabricManagerClient(server.URL(), defaultFabricManagerEndpoint, integrationDevicesSpec)
+	require.NoError(t, err)
+
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	mockKeycloak.On("IsInit").Return(true).Maybe()
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample).Maybe()
+
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("IsInit").Return(true).Maybe()
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "network-config", mock.Anything).Return(nil).Maybe()
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "user-data"), mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockSSH.On("SetHostPublicKey", mock.Anything).Return().Maybe()
+	mockSSH.On("CheckHostKey").Return(nil).Maybe()
+	mockSSH.On("WaitCloudInit").Return(nil).Maybe()
+	bootCount := 0
+	mockSSH.On("BootId").Return(func() (string, error) {
+		bootCount++
+		return fmt.Sprintf("boot-%d", bootCount), nil
+	}).Maybe()
+
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	mockCfg.On("IsInit").Return(true).Maybe()
+	mockCfg.On("PrepareRke2ConfigScript", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("script-content-rke2").Maybe()
+	mockCfg.On("PrepareMetadata", mock.Anything).Return("metadata", nil).Maybe()
+	mockCfg.On("PrepareNetworkConfig", mock.Anything, mock.Anything).Return("network-config", nil).Maybe()
+
+	driver := &Driver{
+		BaseDriver:                &drivers.BaseDriver{MachineName: "integration-node-01"},
+		FabricManager:             fmc,
+		Keycloak:                  mockKeycloak,
+		SshManager:                mockSSH,
+		CfgManager:                mockCfg,
+		TenantUuid:                tenant,
+		ApiUrl:                    server.URL(),
+		ComputeConditionsJson:     `[{"column":"model","operator":"eq","value":"PRIMERGY-RX2540M6"}]`,
+		DevicesSpecJson:           integrationDevicesSpec,
+		NetworkProvisionPort:      1,
+		NetworkProvisionUUID:      "5dc4769c-eef2-407f-b729-fec926ec9eda",
+		NetworkProvisionDefaultGW: "192.168.0.1",
+		NetworkBaremetalPort:      2,
+		NetworkBaremetalUUID:      "75e6b24f-c1cc-4009-a871-b5828a468f4f",
+		OsImageName:               "sles.img",
+	}
+	return driver, server, mockSSH
+}
+
+func TestIntegrationCreateAndRemove(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	require.NoError(t, driver.Create())
+
+	details, ok := server.Machine(driver.MachineUUID)
+	require.True(t, ok)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+	assert.Equal(t, details.Lanports[0].IPAddress, driver.IPAddress)
+	assert.Equal(t, details.Lanports[1].IPAddress, driver.PrivateIPAddress)
+
+	require.NoError(t, driver.Remove())
+
+	details, _ = server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+}
+
+func TestIntegrationCreateImageInstallFailRemovesMachine(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	server.InjectFault(http.MethodPut, "/resources/*/imginstall", fmfake.Fault{StatusCode: http.StatusInternalServerError, Message: "installation failed"})
+
+	// Remove triggered by the failed Create still tries to deregister the OS
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	err := driver.Create()
+	assert.ErrorContains(t, err, "installation failed")
+
+	details, ok := server.Machine(driver.MachineUUID)
+	require.True(t, ok)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+}
+
+func TestIntegrationRemoveStuckMachineTimesOut(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	machineUUID, err := driver.FabricManager.CreateMachine(driver.MachineName, driver.TenantUuid, models.MachineSpecsArgs{
+		ComputeConditionsJson: driver.ComputeConditionsJson,
+		DevicesSpecJson:       driver.DevicesSpecJson,
+		NetworkProvisionPort:  driver.NetworkProvisionPort,
+		NetworkProvisionUUID:  driver.NetworkProvisionUUID,
+	}, models.AccessTokenExample)
+	require.NoError(t, err)
+	driver.MachineUUID = machineUUID
+	server.StickMachineState(machineUUID, fmfake.StatusActivePon)
+
+	err = driver.Remove()
+	assert.EqualError(t, err, "error: required status was not achieved within the specified time")
+}
+
+func TestIntegrationCreateAssignsGroupAndOwner(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.MachineName = "cluster-a-pool1-7d9f8b6c5d-x2kq4"
+	driver.MachineGroupUUID = "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e"
+	driver.MachineOwner = defaultMachineOwnerTemplate
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+
+	require.NoError(t, driver.Create())
+
+	assert.Equal(t, "rancher:cluster-a-pool1", driver.MachineOwner)
+	details, ok := server.Machine(driver.MachineUUID)
+	require.True(t, ok)
+	assert.Equal(t, "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e", details.GroupUUID)
+	assert.Equal(t, "rancher:cluster-a-pool1", details.MachineOwner)
+}
+
+func TestIntegrationSpreadPlacesPoolMachinesOnDistinctFabrics(t *testing.T) {
+	fabrics := []string{"58f4c0f8-6c74-4e86-a560-95ed13daaa46", "68f4c0f8-6c74-4e86-a560-95ed13daaa46"}
+	driver, server, mockSSH := newIntegrationDriverWithOptions(t, fmfake.Options{Fabrics: fabrics})
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	// Every Create composes a new Rancher machine of the same pool
+	nextMachine := func(name string) {
+		driver.MachineName = name
+		driver.MachineUUID = ""
+		driver.LastCompletedPhase = ""
+	}
+
+	driver.Placement = placementSpread
+	nextMachine("cluster-a-etcd-7d9f8b6c5d-x2kq4")
+	require.NoError(t, driver.Create())
+	firstFabric := driver.FabricUUID
+
+	nextMachine("cluster-a-etcd-7d9f8b6c5d-p8fz1")
+	require.NoError(t, driver.Create())
+	assert.NotEqual(t, firstFabric, driver.FabricUUID)
+	assert.ElementsMatch(t, fabrics, []string{firstFabric, driver.FabricUUID})
+
+	// Both fabrics are used by the pool, FM cannot honour the request and composes on the first fabric
+	server.IgnorePlacement(true)
+	nextMachine("cluster-a-etcd-7d9f8b6c5d-m4hq9")
+	err := driver.Create()
+	assert.ErrorContains(t, err, "violates 'spread' placement")
+
+	details, ok := server.Machine(driver.MachineUUID)
+	require.True(t, ok)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+
+	driver.PlacementEnforcement = placementEnforcementWarn
+	nextMachine("cluster-a-etcd-7d9f8b6c5d-k2jd7")
+	assert.NoError(t, driver.Create())
+}
+
+func TestIntegrationCreateResumesFromFailedPhase(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.CreateResumeAttempts = 1
+
+	mockSSH.On("ExchangeKeys").Return(nil).Once()
+	mockSSH.On("RegisterOS", mock.Anything).Return(errors.New("SUSEConnect: connection reset")).Once()
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil).Once()
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+
+	err := driver.Create()
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, phaseSshKeys, driver.LastCompletedPhase)
+	assert.Equal(t, 1, driver.CreateResumeAttemptsUsed)
+	machineUUID := driver.MachineUUID
+
+	require.NoError(t, driver.Create())
+
+	assert.Equal(t, machineUUID, driver.MachineUUID)
+	assert.Equal(t, phaseHarden, driver.LastCompletedPhase)
+	assert.Equal(t, []string{machineUUID}, server.MachineUUIDs())
+	details, _ := server.Machine(machineUUID)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+
+	imageInstalls := 0
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodPut && strings.HasSuffix(request.Path, "/imginstall") {
+			imageInstalls++
+		}
+	}
+	assert.Equal(t, 1, imageInstalls)
+}
+
+func TestIntegrationCreateRemovesMachineWhenResumeAttemptsExhausted(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.CreateResumeAttempts = 1
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(errors.New("invalid registration code"))
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	assert.Error(t, driver.Create())
+	details, _ := server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+
+	assert.ErrorContains(t, driver.Create(), "invalid registration code")
+	details, _ = server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+	assert.Empty(t, driver.LastCompletedPhase)
+	assert.Zero(t, driver.CreateResumeAttemptsUsed)
+}
+
+func TestIntegrationRemoveUsesJournalOfInterruptedCreate(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.CreateResumeAttempts = 1
+
+	mockSSH.On("ExchangeKeys").Return(errors.New("connection refused"))
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	assert.Error(t, driver.Create())
+	machineUUID := driver.MachineUUID
+
+	// Plugin killed before Rancher saved driver state
+	driver.MachineUUID = ""
+	driver.LastCompletedPhase = ""
+
+	require.NoError(t, driver.Remove())
+
+	assert.Equal(t, machineUUID, driver.MachineUUID)
+	details, _ := server.Machine(machineUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+	assert.NoFileExists(t, driver.ResolveStorePath(journalFileName))
+}
+
+func TestIntegrationCreateRecoversJournaledMachine(t *testing.T) {
+	tests := []struct {
+		name                 string
+		createResumeAttempts int
+		wantResumed          bool
+	}{
+		{name: "resume enabled", createResumeAttempts: 2, wantResumed: true},
+		{name: "resume disabled", createResumeAttempts: 0, wantResumed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, server, mockSSH := newIntegrationDriver(t)
+			driver.StorePath = t.TempDir()
+			driver.CreateResumeAttempts = 1
+
+			mockSSH.On("ExchangeKeys").Return(errors.New("connection refused")).Once()
+			mockSSH.On("ExchangeKeys").Return(nil)
+			mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+			mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+			mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+			mockSSH.On("RebootCloudInit").Return(nil)
+			mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+			mockSSH.On("DeregisterOS", mock.Anything).Return(nil).Maybe()
+
+			assert.Error(t, driver.Create())
+			journaledUUID := driver.MachineUUID
+
+			// Plugin killed before Rancher saved driver state, the new plugin knows only the configuration
+			resumed := &Driver{
+				BaseDriver:                &drivers.BaseDriver{MachineName: driver.MachineName, StorePath: driver.StorePath},
+				FabricManager:             driver.FabricManager,
+				Keycloak:                  driver.Keycloak,
+				SshManager:                driver.SshManager,
+				CfgManager:                driver.CfgManager,
+				TenantUuid:                driver.TenantUuid,
+				ApiUrl:                    driver.ApiUrl,
+				ComputeConditionsJson:     driver.ComputeConditionsJson,
+				DevicesSpecJson:           driver.DevicesSpecJson,
+				NetworkProvisionPort:      driver.NetworkProvisionPort,
+				NetworkProvisionUUID:      driver.NetworkProvisionUUID,
+				NetworkProvisionDefaultGW: driver.NetworkProvisionDefaultGW,
+				NetworkBaremetalPort:      driver.NetworkBaremetalPort,
+				NetworkBaremetalUUID:      driver.NetworkBaremetalUUID,
+				OsImageName:               driver.OsImageName,
+				CreateResumeAttempts:      tt.createResumeAttempts,
+			}
+
+			require.NoError(t, resumed.Create())
+
+			journal, err := resumed.readJournal()
+			require.NoError(t, err)
+			require.NotNil(t, journal)
+			assert.Equal(t, resumed.MachineUUID, journal.MachineUUID)
+			assert.Equal(t, phaseHarden, journal.Phase)
+
+			machine, _ := server.Machine(resumed.MachineUUID)
+			assert.Equal(t, machine.Lanports[0].IPAddress, resumed.IPAddress)
+			assert.Equal(t, machine.Lanports[1].IPAddress, resumed.PrivateIPAddress)
+
+			details, _ := server.Machine(journaledUUID)
+			if tt.wantResumed {
+				assert.Equal(t, journaledUUID, resumed.MachineUUID)
+				assert.Equal(t, []string{journaledUUID}, server.MachineUUIDs())
+				assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+			} else {
+				assert.NotEqual(t, journaledUUID, resumed.MachineUUID)
+				assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+			}
+		})
+	}
+}
+
+func TestIntegrationCreateKeepsFailedMachine(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.KeepOnFailure = "2h"
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(errors.New("invalid registration code"))
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	assert.ErrorContains(t, driver.Create(), "invalid registration code")
+
+	assert.True(t, driver.KeptOnFailure)
+	details, _ := server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+
+	// Explicit Remove deletes the kept machine before its time-to-live runs out
+	require.NoError(t, driver.Remove())
+
+	assert.False(t, driver.KeptOnFailure)
+	details, _ = server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+}
+
+func TestIntegrationKeptMachineExpiredAfterTimeToLive(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.KeepOnFailure = "2h"
+	clock := statusClock.(*timeutils.ManualClock)
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(errors.New("invalid registration code")).Once()
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	assert.ErrorContains(t, driver.Create(), "invalid registration code")
+	keptUUID := driver.MachineUUID
+
+	machineState, err := driver.GetState()
+	require.NoError(t, err)
+	assert.Equal(t, state.Running, machineState)
+
+	clock.Advance(2 * time.Hour)
+	machineState, err = driver.GetState()
+	require.NoError(t, err)
+	assert.Equal(t, state.Error, machineState)
+
+	// GetState only reports the expired machine, the next Create finds it in the journal and removes it
+	assert.True(t, driver.KeptOnFailure)
+	details, _ := server.Machine(keptUUID)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+
+	recreated := &Driver{
+		BaseDriver:                &drivers.BaseDriver{MachineName: driver.MachineName, StorePath: driver.StorePath},
+		FabricManager:             driver.FabricManager,
+		Keycloak:                  driver.Keycloak,
+		SshManager:                driver.SshManager,
+		CfgManager:                driver.CfgManager,
+		TenantUuid:                driver.TenantUuid,
+		ApiUrl:                    driver.ApiUrl,
+		ComputeConditionsJson:     driver.ComputeConditionsJson,
+		DevicesSpecJson:           driver.DevicesSpecJson,
+		NetworkProvisionPort:      driver.NetworkProvisionPort,
+		NetworkProvisionUUID:      driver.NetworkProvisionUUID,
+		NetworkProvisionDefaultGW: driver.NetworkProvisionDefaultGW,
+		NetworkBaremetalPort:      driver.NetworkBaremetalPort,
+		NetworkBaremetalUUID:      driver.NetworkBaremetalUUID,
+		OsImageName:               driver.OsImageName,
+		CreateResumeAttempts:      1,
+	}
+	require.NoError(t, recreated.Create())
+
+	assert.NotEqual(t, keptUUID, recreated.MachineUUID)
+	assert.False(t, recreated.KeptOnFailure)
+	details, _ = server.Machine(keptUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+}
+
+func TestIntegrationCreateRemovesMachineKeptByPreviousCreate(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.KeepOnFailure = "always"
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(errors.New("invalid registration code")).Once()
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+
+	assert.Error(t, driver.Create())
+	keptUUID := driver.MachineUUID
+
+	require.NoError(t, driver.Create())
+
+	assert.NotEqual(t, keptUUID, driver.MachineUUID)
+	assert.False(t, driver.KeptOnFailure)
+	details, _ := server.Machine(keptUUID)
+	assert.Equal(t, fmfake.StatusUnbuilded, details.MachineStatus)
+}
+
+func TestIntegrationWaitFailsFastOnImpossibleState(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	clock := statusClock.(*timeutils.ManualClock)
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+
+	require.NoError(t, driver.Create())
+
+	// Machine decomposed behind the driver's back while waiting for it to power on
+	server.SetMachineState(driver.MachineUUID, fmfake.StatusUnbuilded)
+	start := clock.Now()
+
+	err := driver.waitForStatus(opPowerOn, driver.waitStatusTimeout())
+
+	assert.EqualError(t, err, "machine in state UNBUILDED (17) cannot reach state ACTIVE_PON (13) during power on")
+	assert.Zero(t, clock.Since(start))
+}
+
+func TestIntegrationStopEscalatesToPowerOff(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.StopEscalation = "graceful,ssh,poweroff"
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+	// OS hangs: neither the graceful shutdown nor the one over SSH stops the machine
+	mockSSH.On("ShutdownOS").Return(errors.New("connection reset by peer"))
+
+	require.NoError(t, driver.Create())
+	server.IgnoreGracefulShutdown(true)
+
+	require.NoError(t, driver.Stop())
+
+	details, _ := server.Machine(driver.MachineUUID)
+	assert.Equal(t, fmfake.StatusActivePoff, details.MachineStatus)
+	mockSSH.AssertCalled(t, "ShutdownOS")
+
+	var actions []string
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request.Path, "/graceful") || strings.HasSuffix(request.Path, "/poff") {
+			actions = append(actions, request.Path[strings.LastIndex(request.Path, "/")+1:])
+		}
+	}
+	assert.Equal(t, []string{"graceful", "poff"}, actions)
+}
+
+func TestIntegrationStopBySshShutdown(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+	driver.StopEscalation = "graceful,ssh,poweroff"
+
+	mockSSH.On("ExchangeKeys").Return(nil)
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil)
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil)
+	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
+
+	require.NoError(t, driver.Create())
+	server.IgnoreGracefulShutdown(true)
+	mockSSH.On("ShutdownOS").Run(func(mock.Arguments) {
+		server.SetMachineState(driver.MachineUUID, fmfake.StatusActivePoff)
+	}).Return(nil)
+
+	require.NoError(t, driver.Stop())
+
+	for _, request := range server.Requests() {
+		assert.False(t, strings.HasSuffix(request.Path, "/poff"), "unexpected forced power off")
+	}
+}
+
+func TestIntegrationReimageKeepsMachine(t *testing.T) {
+	driver, server, mockSSH := newIntegrationDriver(t)
+
+	mockSSH.On("ExchangeKeys").Return(nil).Twice()
+	mockSSH.On("RegisterOS", mock.Anything).Return(nil).Twice()
+	mockSSH.On("ExecuteScript", "", "script-content-rke2", true, true).Return(nil).Twice()
+	mockSSH.On("WriteFileOnRemoteMachine", mock.Anything, "metadata", mock.Anything).Return(nil).Twice()
+	mockSSH.On("RebootCloudInit").Return(nil).Twice()
+	mockSSH.On("DisablePasswordSSHLogin").Return(nil).Twice()
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil).Once()
+
+	require.NoError(t, driver.Create())
+	machineUUID := driver.MachineUUID
+
+	require.NoError(t, driver.Reimage())
+
+	assert.Equal(t, machineUUID, driver.MachineUUID)
+	assert.Equal(t, phaseHarden, driver.LastCompletedPhase)
+	assert.Equal(t, []string{machineUUID}, server.MachineUUIDs())
+	details, _ := server.Machine(machineUUID)
+	assert.Equal(t, fmfake.StatusActivePon, details.MachineStatus)
+
+	imageInstalls := 0
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodPut && strings.HasSuffix(request.Path, "/imginstall") {
+			imageInstalls++
+		}
+	}
+	assert.Equal(t, 2, imageInstalls)
+}
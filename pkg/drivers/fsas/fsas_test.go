@@ -214,6 +214,10 @@ func TestGetStateUnknownStatus(t *testing.T) {
 		"902cc002-3775-4be0-be00-535a677b2ab4",
 		987,
 		nil)
+	// Unrecognized status is logged with its detail
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(
+		&models.MachineDetails{MachineUUID: driver.MachineUUID, MachineStatus: 987, MachineStatusDetail: "firmware update"},
+		nil)
 
 	observed, err := driver.GetState()
 	expected := state.None
@@ -387,6 +391,7 @@ func TestCheckConfig_SlesParamsFail(t *testing.T) {
 		UserDataFile:              "userData.json",
 		OsImageSshHostPubKey:      hostPublicKey,
 		SlesRegistrationCode:      "123",
+		OsRegistrar:               sshutils.OS_REGISTRAR_SUSECONNECT,
 	}
 	driver.SSHUser = "user"
 
@@ -422,6 +427,39 @@ func TestCheckConfig_SlesParamsFail(t *testing.T) {
 			},
 			expected: "Email address is not valid: alice@",
 		},
+		{name: "invalid SLES modules",
+			input: func() {
+				driver.SlesRegistrationEmail = "hoge@example.com"
+				driver.SlesModules = "none,hpc"
+			},
+			expected: "invalid --fsas-sles-modules: 'none' cannot be combined with other modules",
+		},
+		{name: "invalid node labels",
+			input: func() {
+				driver.SlesModules = ""
+				driver.NodeLabels = "node-role.kubernetes.io/worker=true"
+			},
+			expected: "invalid --fsas-node-labels: label 'node-role.kubernetes.io/worker' is in a namespace reserved for Kubernetes",
+		},
+		{name: "invalid node taints",
+			input: func() {
+				driver.NodeLabels = "tier=gpu"
+				driver.NodeTaints = "dedicated=gpu"
+			},
+			expected: "invalid --fsas-node-taints: taint 'dedicated=gpu' must be in form 'key[=value]:effect'",
+		},
+		{name: "unknown registrar",
+			input: func() {
+				driver.OsRegistrar = "yast"
+			},
+			expected: "unknown OS registrar 'yast', use one of",
+		},
+		{name: "subscription-manager without organization",
+			input: func() {
+				driver.OsRegistrar = sshutils.OS_REGISTRAR_SUBSCRIPTION_MANAGER
+			},
+			expected: "Fill in param --fsas-os-registration-org",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -434,6 +472,93 @@ func TestCheckConfig_SlesParamsFail(t *testing.T) {
 	mockFM.AssertCalled(t, "ValidateTenant", "cdi-test", models.AccessTokenExample)
 }
 
+func TestCheckConfig_OsRegistrarWithoutEmail(t *testing.T) {
+	mockFM := fmmock.NewMockFabricManager(t)
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	hostPublicKey := "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBNlLkDgzQ7FWYLi7wl3ljvaF/n0FEpSrML23hJjvv3HfEvNJxNbjm1GomnefDM9/qYV2pRAganbMMnCG8gs7KD8="
+
+	driver := &Driver{
+		BaseDriver:                &drivers.BaseDriver{},
+		FabricManager:             mockFM,
+		Keycloak:                  mockKeycloak,
+		SSHPassword:               "pass",
+		ComputeConditionsJson:     "test",
+		NetworkBaremetalPort:      1,
+		NetworkBaremetalUUID:      "test",
+		NetworkBaremetalDefaultGW: "192.168.0.254",
+		NetworkProvisionPort:      1,
+		NetworkProvisionUUID:      "test",
+		NetworkProvisionDefaultGW: "192.168.0.254",
+		DevicesSpecJson:           models.DeviceSpecsValid,
+		ApiUrl:                    "http://192.168.0.1",
+		TenantUuid:                "cdi-test",
+		OsImageName:               "Ubuntu",
+		UserDataFile:              "userData.json",
+		OsImageSshHostPubKey:      hostPublicKey,
+		SlesRegistrationCode:      "123",
+		OsRegistrar:               sshutils.OS_REGISTRAR_PRO,
+	}
+	driver.SSHUser = "user"
+
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("ValidateTenant", "cdi-test", mockKeycloak.GetToken()).Return(nil)
+
+	assert.NoError(t, driver.checkConfig())
+
+	driver.OsRegistrar = sshutils.OS_REGISTRAR_SUBSCRIPTION_MANAGER
+	driver.OsRegistrationOrg = "1234567"
+	assert.NoError(t, driver.checkConfig())
+}
+
+func TestCheckConfig_OsRegistrarAuto(t *testing.T) {
+	mockFM := fmmock.NewMockFabricManager(t)
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	hostPublicKey := "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBNlLkDgzQ7FWYLi7wl3ljvaF/n0FEpSrML23hJjvv3HfEvNJxNbjm1GomnefDM9/qYV2pRAganbMMnCG8gs7KD8="
+
+	driver := &Driver{
+		BaseDriver:                &drivers.BaseDriver{},
+		FabricManager:             mockFM,
+		Keycloak:                  mockKeycloak,
+		SSHPassword:               "pass",
+		ComputeConditionsJson:     "test",
+		NetworkBaremetalPort:      1,
+		NetworkBaremetalUUID:      "test",
+		NetworkBaremetalDefaultGW: "192.168.0.254",
+		NetworkProvisionPort:      1,
+		NetworkProvisionUUID:      "test",
+		NetworkProvisionDefaultGW: "192.168.0.254",
+		DevicesSpecJson:           models.DeviceSpecsValid,
+		ApiUrl:                    "http://192.168.0.1",
+		TenantUuid:                "cdi-test",
+		OsImageName:               "sles",
+		UserDataFile:              "userData.json",
+		OsImageSshHostPubKey:      hostPublicKey,
+		OsRegistrar:               sshutils.OS_REGISTRAR_AUTO,
+	}
+	driver.SSHUser = "user"
+
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("ValidateTenant", "cdi-test", mockKeycloak.GetToken()).Return(nil)
+
+	// Nothing to register
+	assert.NoError(t, driver.checkConfig())
+
+	driver.SlesRegistrationCode = "123"
+	assert.ErrorContains(t, driver.checkConfig(), "with --fsas-os-registrar 'auto' the registration code must come with SLES registration email or organization ID")
+
+	driver.SlesRegistrationEmail = "alice@"
+	assert.EqualError(t, driver.checkConfig(), "Email address is not valid: alice@")
+
+	driver.SlesRegistrationEmail = "hoge@example.com"
+	assert.NoError(t, driver.checkConfig())
+
+	driver.OsRegistrationOrg = "1234567"
+	assert.ErrorContains(t, driver.checkConfig(), "either with SLES registration email or with organization ID, not both")
+
+	driver.SlesRegistrationEmail = ""
+	assert.NoError(t, driver.checkConfig())
+}
+
 func TestCheckConfigTenantFailed(t *testing.T) {
 	mockFM := fmmock.NewMockFabricManager(t)
 	mockKeycloak := keycloakMock.NewMockKeycloak(t)
@@ -645,7 +770,7 @@ func TestRemoveSuccess(t *testing.T) {
 	mockKeycloak.On("IsInit").Return(true)
 	mockFM.On("IsInit").Return(true)
 	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
-	mockSshManager.On("DeregisterOS").Return(nil)
+	mockSshManager.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("GetMachineDetails", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(
 		models.ExpectedLanports,
 		"902cc002-3775-4be0-be00-535a677b2ab4",
@@ -706,7 +831,7 @@ func TestRemoveFMRemoveMachineFail(t *testing.T) {
 	mockKeycloak.On("IsInit").Return(true)
 	mockFM.On("IsInit").Return(true)
 	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
-	mockSshManager.On("DeregisterOS").Return(nil)
+	mockSshManager.On("DeregisterOS", mock.Anything).Return(nil)
 	expectedError := fmt.Errorf("request failed")
 	mockFM.On("RemoveMachine", driver.MachineUUID, "cdi-test", models.AccessTokenExample).Return(expectedError)
 
@@ -733,7 +858,7 @@ func TestRemoveDeregisterOSFail(t *testing.T) {
 	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
 	mockError := fmt.Errorf("Deregister mock fail")
 	// This error should only notify via WARN log as not removing machine can be disastrous
-	mockSshManager.On("DeregisterOS").Return(mockError)
+	mockSshManager.On("DeregisterOS", mock.Anything).Return(mockError)
 	mockFM.On("GetMachineDetails", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(
 		models.ExpectedLanports,
 		"902cc002-3775-4be0-be00-535a677b2ab4",
@@ -993,7 +1118,7 @@ func TestWaitForStatusCorrectStatus(t *testing.T) {
 	mock_now_time := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
 	mockClock.On("Now").Return(mock_now_time)
 
-	err := driver.waitForStatus(ACTIVE_PON, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_TIMEOUT)
+	err := driver.waitForStatus(opPowerOn, WAIT_FOR_STATUS_TIMEOUT)
 
 	mockClock.AssertCalled(t, "Now")
 	mockClock.AssertNotCalled(t, "Since", mock.Anything)
@@ -1024,7 +1149,7 @@ func TestWaitForStatusCorrectSecondCallStatus(t *testing.T) {
 	mockClock.On("Since", mock_now_time).Return(mock_duration)
 	mockClock.On("Sleep", WAIT_FOR_STATUS_STEP).Return(nil)
 
-	err := driver.waitForStatus(ACTIVE_PON, WAIT_FOR_STATUS_STEP, WAIT_FOR_STATUS_TIMEOUT)
+	err := driver.waitForStatus(opPowerOn, WAIT_FOR_STATUS_TIMEOUT)
 
 	assert.NoError(t, err)
 	mockClock.AssertCalled(t, "Now")
@@ -1055,10 +1180,13 @@ func TestWaitForStatusTimeout(t *testing.T) {
 	mockClock.On("Now").Return(mock_now_time)
 	mockClock.On("Since", mock_now_time).Return(time.Millisecond * 100).Once()
 	mockClock.On("Since", mock_now_time).Return(time.Millisecond*100 + mock_time_step*1).Once()
-	mockClock.On("Since", mock_now_time).Return(time.Millisecond*100 + mock_time_step*2).Once()
-	mockClock.On("Sleep", mock_time_step)
+	mockClock.On("Since", mock_now_time).Return(time.Millisecond*100 + mock_time_step*3).Once()
+	mockClock.On("Sleep", mock_time_step).Once()
+	// Unchanged state doubles the interval
+	mockClock.On("Sleep", 2*mock_time_step).Once()
+	driver.PollInterval = 1
 
-	err := driver.waitForStatus(ACTIVE_PON, mock_time_step, 2*mock_time_step)
+	err := driver.waitForStatus(opPowerOn, 2*mock_time_step)
 
 	assert.EqualError(t, err, "error: required status was not achieved within the specified time")
 	mockClock.AssertExpectations(t)
@@ -1088,7 +1216,7 @@ func TestWaitForStatusError(t *testing.T) {
 	mock_now_time := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
 	mockClock.On("Now").Return(mock_now_time)
 
-	err := driver.waitForStatus(ACTIVE_PON, time.Duration(1*time.Second), time.Duration(2*time.Second))
+	err := driver.waitForStatus(opPowerOn, time.Duration(2*time.Second))
 
 	assert.EqualError(t, err, "error getting state: Request GET /machines/a1b2c3d4-e5f6-7890-1234-567890abcdef failed")
 	mockClock.AssertExpectations(t)
@@ -1154,27 +1282,34 @@ func TestCreate(t *testing.T) {
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 18, nil).Once()
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 15, nil).Once()
 	mockFM.On("PowerOn", testMachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
-	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Twice()
-	mockSSH.On("RegisterOS", driver.SlesRegistrationCode, driver.SlesRegistrationEmail).Return(nil)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Times(4)
+	mockSSH.On("RegisterOS", driver.osRegistration()).Return(nil)
 	mockSSH.On("ExchangeKeys").Return(nil)
 	mockRKE2ScriptContent := "script-content-rke2"
-	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f").Return(mockRKE2ScriptContent)
+	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f", []string(nil), []string(nil)).Return(mockRKE2ScriptContent)
 	mockSSH.On("ExecuteScript", "", mockRKE2ScriptContent, true, true).Return(nil).Once()
 	// applyCloudInit
 	userdataPath := filepath.Join(cloudInitDirPath, "user-data")
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
-	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, mockRKE2ScriptContent, fs.FileMode(0700)).Return(nil)
-	mockCfg.On("PrepareMetadata", testMachineUUID, driver.MachineName).Return("")
+	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, userDataWithHostKey(t, mergedUserDataExample), fs.FileMode(0700)).Return(nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("network-config", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), "network-config", fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachine", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{BootSSD: bootSsdUUID}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
 	mockSSH.On("WriteFileOnRemoteMachine", metadataPath, "", fs.FileMode(0700)).Return(nil)
+	mockSSH.On("BootId").Return("boot-1", nil).Once()
 	mockSSH.On("RebootCloudInit").Return(nil)
+	mockSSH.On("BootId").Return("boot-2", nil)
+	mockSSH.On("SetHostPublicKey", mock.Anything).Return()
+	mockSSH.On("CheckHostKey").Return(nil)
+	mockSSH.On("WaitCloudInit").Return(nil)
 	mockSSH.On("DisablePasswordSSHLogin").Return(nil)
-	mockClock.On("Sleep", WAIT_FOR_START_AFTER_REBOOT).Return(nil)
 
 	// Mock implementation of os.ReadFile
 	originalOsReadFile := osReadFile
 	defer func() { osReadFile = originalOsReadFile }()
 	osReadFile = func(path string) ([]byte, error) {
-		return []byte("script-content-rke2"), nil
+		return []byte(rancherUserDataExample), nil
 	}
 
 	err := driver.Create()
@@ -1241,15 +1376,15 @@ func TestCreateCloudInitFail(t *testing.T) {
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 15, nil).Once()
 	mockFM.On("PowerOn", testMachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Twice()
-	mockSSH.On("RegisterOS", driver.SlesRegistrationCode, driver.SlesRegistrationEmail).Return(nil)
+	mockSSH.On("RegisterOS", driver.osRegistration()).Return(nil)
 	mockSSH.On("ExchangeKeys").Return(nil)
 	mockRKE2ScriptContent := "script-content-rke2"
-	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f").Return(mockRKE2ScriptContent)
+	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f", []string(nil), []string(nil)).Return(mockRKE2ScriptContent)
 	mockSSH.On("ExecuteScript", "", mockRKE2ScriptContent, true, true).Return(nil).Once()
 	// applyCloudInit
 	userdataPath := filepath.Join(cloudInitDirPath, "user-data")
-	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, "custom-user-data.yaml", fs.FileMode(0700)).Return(fmt.Errorf("WriteFileOnRemoteMachine failed"))
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, userDataWithHostKey(t, mergedUserDataExample), fs.FileMode(0700)).Return(fmt.Errorf("WriteFileOnRemoteMachine failed"))
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 17, nil).Once()
 
@@ -1257,7 +1392,7 @@ func TestCreateCloudInitFail(t *testing.T) {
 	originalOsReadFile := osReadFile
 	defer func() { osReadFile = originalOsReadFile }()
 	osReadFile = func(path string) ([]byte, error) {
-		return []byte("custom-user-data.yaml"), nil
+		return []byte(rancherUserDataExample), nil
 	}
 
 	err := driver.Create()
@@ -1327,7 +1462,7 @@ func TestCreateMachineFail(t *testing.T) {
 
 	testError := fmt.Errorf("CreateMachine unsucessfull")
 	mockFM.On("CreateMachine", driver.MachineName, driver.TenantUuid, machineSpecArgs, models.AccessTokenExample).Return("", testError)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(UNBUILDED), nil)
 
@@ -1389,16 +1524,16 @@ func TestCreateWaitForStatusFail(t *testing.T) {
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", int(UNBUILDED), nil)
 	mockSSH.On("IsInit").Return(true)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 
 	mock_now_time := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
 	mockClock.On("Now").Return(mock_now_time)
-	mockClock.On("Since", mock_now_time).Return(WAIT_FOR_STATUS_TIMEOUT + time.Microsecond*100)
 
 	err := driver.Create()
 
-	assert.ErrorContains(t, err, "error: required status was not achieved within the specified time")
-	mockClock.AssertNumberOfCalls(t, "Since", 1)
+	// Decomposed machine cannot become ACTIVE_POFF, so Create fails without waiting for the timeout
+	assert.ErrorContains(t, err, "machine in state UNBUILDED (17) cannot reach state ACTIVE_POFF (15) during create")
+	mockClock.AssertNotCalled(t, "Since", mock.Anything)
 }
 
 func TestCreateGetMachineDetailsFail(t *testing.T) {
@@ -1450,7 +1585,7 @@ func TestCreateGetMachineDetailsFail(t *testing.T) {
 	// bootSSD call
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 15, testError).Once()
 	mockSSH.On("IsInit").Return(true)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// waitForStatus call in RemoveMachine
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1507,7 +1642,7 @@ func TestCreateImageInstallFail(t *testing.T) {
 	testError := fmt.Errorf("ImageInstall unsucessfull")
 	mockFM.On("ImageInstall", driver.TenantUuid, bootSsdUUID, driver.OsImageName, models.AccessTokenExample).Return(testError)
 	mockSSH.On("IsInit").Return(true)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// Call in Remove
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1569,7 +1704,7 @@ func TestCreateStartFail(t *testing.T) {
 	testError := fmt.Errorf("PowerOn unsucessfull")
 	mockFM.On("PowerOn", testMachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(testError)
 	mockSSH.On("IsInit").Return(true)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// last waitForStatus in Remove
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1635,7 +1770,7 @@ func TestCreateGetMachineDetailsFailSecond(t *testing.T) {
 	testError := fmt.Errorf("GetMachineDetails unsucessfull")
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, testError).Once()
 	mockSSH.On("IsInit").Return(true)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// Remove call
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1698,7 +1833,7 @@ func TestCreateExchangeKeysFail(t *testing.T) {
 	testError := fmt.Errorf("ExchangeKeys unsuccessful")
 	mockSSH.On("IsInit").Return(true)
 	mockSSH.On("ExchangeKeys").Return(testError)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// waitForStatus in Remove call
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1764,8 +1899,8 @@ func TestCreateOSRegistrationFail(t *testing.T) {
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Twice()
 	mockSSH.On("ExchangeKeys").Return(nil)
 	mockError := fmt.Errorf("Registration failed")
-	mockSSH.On("RegisterOS", driver.SlesRegistrationCode, driver.SlesRegistrationEmail).Return(mockError)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("RegisterOS", driver.osRegistration()).Return(mockError)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// waitForStatus in Remove call
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1830,13 +1965,13 @@ func TestCreateExecuteScriptFail(t *testing.T) {
 	mockFM.On("PowerOn", testMachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// PowerOn waitForStatus check && Lanports reading check
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Twice()
-	mockSSH.On("RegisterOS", driver.SlesRegistrationCode, driver.SlesRegistrationEmail).Return(nil)
+	mockSSH.On("RegisterOS", driver.osRegistration()).Return(nil)
 	mockSSH.On("ExchangeKeys").Return(nil)
 	mockRKE2ScriptContent := "test RKE2 script content"
-	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", testMachineUUID).Return(mockRKE2ScriptContent)
+	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", testMachineUUID, []string(nil), []string(nil)).Return(mockRKE2ScriptContent)
 	mockError := fmt.Errorf("ExecuteScript unsuccessful")
 	mockSSH.On("ExecuteScript", "", mockRKE2ScriptContent, true, true).Return(mockError)
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// waitForStatus in Remove call
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return([]models.Lanport{}, "", 17, nil)
@@ -1901,14 +2036,14 @@ func TestCreateFailRemoveFail(t *testing.T) {
 	mockFM.On("PowerOn", testMachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
 	// PowerOn waitForStatus check && Lanports reading check
 	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, bootSsdUUID, 13, nil).Twice()
-	mockSSH.On("RegisterOS", driver.SlesRegistrationCode, driver.SlesRegistrationEmail).Return(nil)
+	mockSSH.On("RegisterOS", driver.osRegistration()).Return(nil)
 	mockSSH.On("ExchangeKeys").Return(nil)
 	mockRKE2ScriptContent := "test RKE2 script content"
-	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", testMachineUUID).Return(mockRKE2ScriptContent)
+	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", testMachineUUID, []string(nil), []string(nil)).Return(mockRKE2ScriptContent)
 	mockError := fmt.Errorf("ExecuteScript unsuccessful")
 	mockSSH.On("ExecuteScript", "", mockRKE2ScriptContent, true, true).Return(mockError)
 	removeError := fmt.Errorf("Remove after failed inner Create failed as well")
-	mockSSH.On("DeregisterOS").Return(nil)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
 	mockFM.On("RemoveMachine", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(removeError)
 
 	err := driver.Create()
@@ -2014,10 +2149,11 @@ func TestStop_GracefulShutdown_failed(t *testing.T) {
 			IPAddress: "10.1.2.3",
 			SSHUser:   "user-1",
 		},
-		SSHPassword:   "password1",
-		MachineUUID:   "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
-		FabricManager: mockFM,
-		Keycloak:      mockKeycloak,
+		SSHPassword:    "password1",
+		MachineUUID:    "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+		FabricManager:  mockFM,
+		Keycloak:       mockKeycloak,
+		StopEscalation: stopStepGraceful,
 	}
 
 	mockKeycloak.On("IsInit").Return(true).Maybe()
@@ -2032,6 +2168,11 @@ func TestStop_GracefulShutdown_failed(t *testing.T) {
 }
 
 func TestStop_waitForStatus_failed(t *testing.T) {
+	mockClock := timeutilsmock.NewMockClock(t)
+	statusClock = mockClock
+	mock_now_time := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockClock.On("Now").Return(mock_now_time)
+	mockClock.On("Since", mock_now_time).Return(WAIT_FOR_STATUS_STOPPED_TIMEOUT + time.Microsecond*100)
 
 	mockFM := fmmock.NewMockFabricManager(t)
 	mockKeycloak := keycloakMock.NewMockKeycloak(t)
@@ -2041,10 +2182,11 @@ func TestStop_waitForStatus_failed(t *testing.T) {
 			IPAddress: "10.1.2.3",
 			SSHUser:   "user-1",
 		},
-		SSHPassword:   "password1",
-		MachineUUID:   "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
-		FabricManager: mockFM,
-		Keycloak:      mockKeycloak,
+		SSHPassword:    "password1",
+		MachineUUID:    "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+		FabricManager:  mockFM,
+		Keycloak:       mockKeycloak,
+		StopEscalation: stopStepGraceful,
 	}
 
 	mockKeycloak.On("IsInit").Return(true)
@@ -2091,6 +2233,12 @@ func TestRestartSuccess(t *testing.T) {
 }
 
 func TestRestartFail_Stop(t *testing.T) {
+	mockClock := timeutilsmock.NewMockClock(t)
+	statusClock = mockClock
+	mock_now_time := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockClock.On("Now").Return(mock_now_time)
+	mockClock.On("Since", mock_now_time).Return(WAIT_FOR_STATUS_STOPPED_TIMEOUT + time.Microsecond*100)
+
 	mockFM := fmmock.NewMockFabricManager(t)
 	mockKeycloak := keycloakMock.NewMockKeycloak(t)
 
@@ -2099,10 +2247,11 @@ func TestRestartFail_Stop(t *testing.T) {
 			IPAddress: "10.1.2.3",
 			SSHUser:   "user-1",
 		},
-		SSHPassword:   "password1",
-		MachineUUID:   "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
-		FabricManager: mockFM,
-		Keycloak:      mockKeycloak,
+		SSHPassword:    "password1",
+		MachineUUID:    "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+		FabricManager:  mockFM,
+		Keycloak:       mockKeycloak,
+		StopEscalation: stopStepGraceful,
 	}
 	mockKeycloak.On("IsInit").Return(true)
 	mockFM.On("IsInit").Return(true)
@@ -2135,10 +2284,11 @@ func TestRestartFail_Start(t *testing.T) {
 			IPAddress: "10.1.2.3",
 			SSHUser:   "user-1",
 		},
-		SSHPassword:   "password1",
-		MachineUUID:   "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
-		FabricManager: mockFM,
-		Keycloak:      mockKeycloak,
+		SSHPassword:    "password1",
+		MachineUUID:    "cdd792f2-5591-4c18-a8bd-1c39e55dedfa",
+		FabricManager:  mockFM,
+		Keycloak:       mockKeycloak,
+		StopEscalation: stopStepGraceful,
 	}
 	mockKeycloak.On("IsInit").Return(true)
 	mockFM.On("IsInit").Return(true)
@@ -2251,15 +2401,29 @@ func Test_applyCloudInit_success(t *testing.T) {
 		UserDataFile:  "",
 	}
 
-	mockCfg.On("PrepareMetadata", testMachineUUID, "a20-pool1-d5h97-lmjkr").Return("")
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "user-data"), userDataWithHostKey(t, ""), fs.FileMode(0700)).Return(nil)
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("network-config", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), "network-config", fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachine", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
 	mockSSH.On("WriteFileOnRemoteMachine", metadataPath, "", fs.FileMode(0700)).Return(nil)
+	mockSSH.On("BootId").Return("boot-1", nil).Once()
 	mockSSH.On("RebootCloudInit").Return(nil)
-	mockClock.On("Sleep", WAIT_FOR_START_AFTER_REBOOT).Return(nil)
+	mockSSH.On("BootId").Return("boot-2", nil)
+	mockSSH.On("SetHostPublicKey", mock.Anything).Return()
+	mockClock.On("Now").Return(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	mockFM.On("IsInit").Return(true)
+	mockKeycloak.On("IsInit").Return(true)
+	mockSSH.On("CheckHostKey").Return(nil)
+	mockSSH.On("WaitCloudInit").Return(nil)
 
 	testhostname := "a20-pool1-d5h97-lmjkr"
 	err := driver.applyCloudInit(testhostname)
 	assert.NoError(t, err)
+	assert.Equal(t, authorizedKey(mockGenerateHostKey(t).publicKey), driver.SshHostPubKey)
 }
 
 func Test_applyCloudInit_fail_write_file(t *testing.T) {
@@ -2279,7 +2443,13 @@ func Test_applyCloudInit_fail_write_file(t *testing.T) {
 		UserDataFile:  "",
 	}
 
-	mockCfg.On("PrepareMetadata", testMachineUUID, "a20-pool1-d5h97-lmjkr").Return("")
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "user-data"), userDataWithHostKey(t, ""), fs.FileMode(0700)).Return(nil)
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("network-config", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), "network-config", fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachine", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
 	mockSSH.On("WriteFileOnRemoteMachine", metadataPath, "", fs.FileMode(0700)).Return(fmt.Errorf("WriteFileOnRemoteMachine failed"))
 
@@ -2305,9 +2475,16 @@ func Test_applyCloudInit_fail_reboot_cloudinit(t *testing.T) {
 		UserDataFile:  "",
 	}
 
-	mockCfg.On("PrepareMetadata", testMachineUUID, "a20-pool1-d5h97-lmjkr").Return("")
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "user-data"), userDataWithHostKey(t, ""), fs.FileMode(0700)).Return(nil)
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("network-config", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), "network-config", fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachine", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
 	mockSSH.On("WriteFileOnRemoteMachine", metadataPath, "", fs.FileMode(0700)).Return(nil)
+	mockSSH.On("BootId").Return("boot-1", nil)
 	mockSSH.On("RebootCloudInit").Return(fmt.Errorf("RebootCloudInit failed"))
 
 	testhostname := "a20-pool1-d5h97-lmjkr"
@@ -2315,6 +2492,28 @@ func Test_applyCloudInit_fail_reboot_cloudinit(t *testing.T) {
 	assert.EqualError(t, err, errors.New("RebootCloudInit failed").Error())
 }
 
+func Test_applyCloudInit_without_network_config(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	driver.SshManager = mockSSH
+	driver.CfgManager = mockCfg
+
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "user-data"), userDataWithHostKey(t, ""), fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("", nil)
+	mockFM.On("GetMachine", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "meta-data"), "", fs.FileMode(0700)).Return(nil)
+	mockSSH.On("BootId").Return("boot-1", nil)
+	mockSSH.On("RebootCloudInit").Return(fmt.Errorf("RebootCloudInit failed"))
+
+	err := driver.applyCloudInit("a20-pool1-d5h97-lmjkr")
+
+	assert.EqualError(t, err, "RebootCloudInit failed")
+	mockSSH.AssertNotCalled(t, "WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), mock.Anything, mock.Anything)
+}
+
 func Test_applyCloudInit_success_with_userdata(t *testing.T) {
 	mockClock := timeutilsmock.NewMockClock(t)
 	statusClock = mockClock
@@ -2336,17 +2535,29 @@ func Test_applyCloudInit_success_with_userdata(t *testing.T) {
 	}
 
 	userdataPath := filepath.Join(cloudInitDirPath, "user-data")
-	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, "script-content-rke2", fs.FileMode(0700)).Return(nil)
-	mockCfg.On("PrepareMetadata", testMachineUUID, "a20-pool1-d5h97-lmjkr").Return("")
+	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, userDataWithHostKey(t, mergedUserDataExample), fs.FileMode(0700)).Return(nil)
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, mock.Anything).Return("network-config", nil)
+	mockSSH.On("WriteFileOnRemoteMachine", filepath.Join(cloudInitDirPath, "network-config"), "network-config", fs.FileMode(0700)).Return(nil)
+	mockFM.On("GetMachine", driver.TenantUuid, testMachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", mock.AnythingOfType("cfgutils.Metadata")).Return("", nil)
 	metadataPath := filepath.Join(cloudInitDirPath, "meta-data")
 	mockSSH.On("WriteFileOnRemoteMachine", metadataPath, "", fs.FileMode(0700)).Return(nil)
+	mockSSH.On("BootId").Return("boot-1", nil).Once()
 	mockSSH.On("RebootCloudInit").Return(nil)
-	mockClock.On("Sleep", WAIT_FOR_START_AFTER_REBOOT).Return(nil)
+	mockSSH.On("BootId").Return("boot-2", nil)
+	mockSSH.On("SetHostPublicKey", mock.Anything).Return()
+	mockClock.On("Now").Return(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	mockFM.On("IsInit").Return(true)
+	mockKeycloak.On("IsInit").Return(true)
+	mockSSH.On("CheckHostKey").Return(nil)
+	mockSSH.On("WaitCloudInit").Return(nil)
 
 	originalOsReadFile := osReadFile
 	defer func() { osReadFile = originalOsReadFile }()
 	osReadFile = func(path string) ([]byte, error) {
-		return []byte("script-content-rke2"), nil
+		return []byte(rancherUserDataExample), nil
 	}
 
 	testhostname := "a20-pool1-d5h97-lmjkr"
@@ -2372,15 +2583,285 @@ func Test_applyCloudInit_success_with_userdata_fail(t *testing.T) {
 	}
 
 	userdataPath := filepath.Join(cloudInitDirPath, "user-data")
-	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, "script-content-rke2", fs.FileMode(0700)).Return(fmt.Errorf("WriteFileOnRemoteMachine failed"))
+	mockSSH.On("WriteFileOnRemoteMachine", userdataPath, userDataWithHostKey(t, mergedUserDataExample), fs.FileMode(0700)).Return(fmt.Errorf("WriteFileOnRemoteMachine failed"))
 
 	originalOsReadFile := osReadFile
 	defer func() { osReadFile = originalOsReadFile }()
 	osReadFile = func(path string) ([]byte, error) {
-		return []byte("script-content-rke2"), nil
+		return []byte(rancherUserDataExample), nil
 	}
 
 	testhostname := "a20-pool1-d5h97-lmjkr"
 	err := driver.applyCloudInit(testhostname)
 	assert.EqualError(t, err, errors.New("WriteFileOnRemoteMachine failed").Error())
 }
+
+// newManualClock Replace status clock with manual clock for the duration of the test
+func newManualClock(t *testing.T) *timeutils.ManualClock {
+	clock := timeutils.NewManualClock(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	statusClock = clock
+	t.Cleanup(func() { statusClock = timeutils.NewRealClock() })
+	return clock
+}
+
+// newMockedDriver Returns driver of a composed machine talking to mocked Fabric Manager and Keycloak
+func newMockedDriver(t *testing.T) (*Driver, *fmmock.MockFabricManager) {
+	mockFM := fmmock.NewMockFabricManager(t)
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	mockFM.On("IsInit").Return(true).Maybe()
+	mockKeycloak.On("IsInit").Return(true).Maybe()
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample).Maybe()
+
+	driver := &Driver{
+		BaseDriver:    &drivers.BaseDriver{MachineName: "machineNameTest"},
+		FabricManager: mockFM,
+		Keycloak:      mockKeycloak,
+		TenantUuid:    "cdi-test",
+		MachineUUID:   "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+	}
+	return driver, mockFM
+}
+
+func TestPreCreateCheck_quota_success(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.DevicesSpecJson = models.DeviceSpecsValid
+	mockFM.On("GetTenantQuota", "cdi-test", models.AccessTokenExample).Return([]models.ResourceQuota{
+		{ResourceType: "compute", Limit: 2, Used: 1},
+		{ResourceType: "storage", Limit: 4, Used: 2},
+	}, nil)
+
+	assert.NoError(t, driver.PreCreateCheck())
+}
+
+func TestPreCreateCheck_no_quota_success(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.DevicesSpecJson = models.DeviceSpecsValid
+	mockFM.On("GetTenantQuota", "cdi-test", models.AccessTokenExample).Return(nil, nil)
+
+	assert.NoError(t, driver.PreCreateCheck())
+}
+
+func TestPreCreateCheck_quota_exceeded(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.DevicesSpecJson = models.DeviceSpecsValid
+	mockFM.On("GetTenantQuota", "cdi-test", models.AccessTokenExample).Return([]models.ResourceQuota{
+		{ResourceType: "compute", Limit: 2, Used: 2},
+	}, nil)
+
+	err := driver.PreCreateCheck()
+
+	assert.ErrorIs(t, err, fm.ErrTenantQuotaExceeded)
+	assert.EqualError(t, err, "machine machineNameTest cannot be created in tenant cdi-test: tenant quota exceeded: 'compute' requested 1, in use 2 of 2 (available 0)")
+}
+
+func TestPreCreateCheck_GetTenantQuota_failed(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.DevicesSpecJson = models.DeviceSpecsValid
+	mockFM.On("GetTenantQuota", "cdi-test", models.AccessTokenExample).Return(nil, errors.New("request failed"))
+
+	assert.EqualError(t, driver.PreCreateCheck(), "request failed")
+}
+
+func Test_renderMachineTemplate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		text        string
+		machineName string
+		expected    string
+		expectErr   bool
+	}{
+		{name: "default template", text: defaultMachineOwnerTemplate, machineName: "cluster-a-pool1-7d9f8b6c5d-x2kq4", expected: "rancher:cluster-a-pool1"},
+		{name: "machine name", text: "{{.MachineName}} managed by Rancher", machineName: "c1-p1-abc-def", expected: "c1-p1-abc-def managed by Rancher"},
+		{name: "not generated by Rancher", text: "{{.NodePool}}", machineName: "node-01", expected: "node-01"},
+		{name: "plain text", text: " team-a ", machineName: "node-01", expected: "team-a"},
+		{name: "empty", text: "", machineName: "node-01", expected: ""},
+		{name: "syntax error", text: "{{.NodePool", machineName: "node-01", expectErr: true},
+		{name: "unknown field", text: "{{.Cluster}}", machineName: "node-01", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered, err := renderMachineTemplate(tc.text, tc.machineName)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rendered)
+		})
+	}
+}
+
+func Test_verifyMachineAssignment_success(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.MachineGroupUUID = "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e"
+	driver.MachineOwner = "rancher:cluster-a-pool1"
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{
+		MachineUUID:  driver.MachineUUID,
+		GroupUUID:    "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e",
+		MachineOwner: "rancher:cluster-a-pool1",
+	}, nil)
+
+	assert.NoError(t, driver.verifyMachineAssignment())
+}
+
+func Test_verifyMachineAssignment_nothing_requested(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+
+	assert.NoError(t, driver.verifyMachineAssignment())
+	mockFM.AssertNotCalled(t, "GetMachine", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_verifyMachineAssignment_mismatch(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.MachineGroupUUID = "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e"
+	driver.MachineOwner = "rancher:cluster-a-pool1"
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{
+		MachineUUID: driver.MachineUUID,
+	}, nil)
+
+	err := driver.verifyMachineAssignment()
+
+	assert.EqualError(t, err, fmt.Sprintf("machine %s was not assigned as requested: group '' (requested '0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e'), owner '' (requested 'rancher:cluster-a-pool1')", driver.MachineUUID))
+}
+
+func Test_verifyMachineAssignment_GetMachine_failed(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.MachineGroupUUID = "0f6c7a4e-2d5b-4a59-9c0e-6f0a1b2c3d4e"
+	driver.MachineOwner = "rancher:cluster-a-pool1"
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(nil, errors.New("request failed"))
+
+	assert.EqualError(t, driver.verifyMachineAssignment(), "request failed")
+}
+
+func Test_checkPlacementConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		placement   string
+		fabricUUID  string
+		enforcement string
+		errSubstr   string
+	}{
+		{name: "no placement", enforcement: placementEnforcementFail},
+		{name: "spread", placement: placementSpread, enforcement: placementEnforcementWarn},
+		{name: "pin", placement: placementPin, fabricUUID: "58f4c0f8-6c74-4e86-a560-95ed13daaa46"},
+		{name: "pin without fabric", placement: placementPin, errSubstr: "--fsas-placement-fabric-uuid"},
+		{name: "unknown placement", placement: "random", errSubstr: "placement must be empty, 'pin' or 'spread'; got 'random'"},
+		{name: "unknown enforcement", enforcement: "ignore", errSubstr: "placement enforcement must be 'fail' or 'warn'; got 'ignore'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &Driver{Placement: tc.placement, PlacementFabricUUID: tc.fabricUUID, PlacementEnforcement: tc.enforcement}
+			err := driver.checkPlacementConfig()
+			if tc.errSubstr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.errSubstr)
+		})
+	}
+}
+
+func Test_placementConditions_none(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+
+	conditions, err := driver.placementConditions()
+
+	assert.NoError(t, err)
+	assert.Empty(t, conditions)
+}
+
+func Test_placementConditions_pin(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.Placement = placementPin
+	driver.PlacementFabricUUID = "58f4c0f8-6c74-4e86-a560-95ed13daaa46"
+
+	conditions, err := driver.placementConditions()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Condition{{Column: fm.FabricConditionColumn, Operator: "eq", Value: "58f4c0f8-6c74-4e86-a560-95ed13daaa46"}}, conditions)
+}
+
+func Test_placementConditions_spread(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.MachineName = "cluster-a-etcd-7d9f8b6c5d-x2kq4"
+	driver.Placement = placementSpread
+	mockFM.On("ListMachines", "cdi-test", models.AccessTokenExample).Return([]models.MachineDetails{
+		{MachineName: "cluster_a_etcd_7d9f8b6c5d_abcde", FabricUUID: "fabric-1", MachineStatus: int(ACTIVE_PON)},
+		{MachineName: "cluster_a_etcd_7d9f8b6c5d_fghij", FabricUUID: "fabric-1", MachineStatus: int(ACTIVE_PON)},
+		{MachineName: "cluster_a_etcd_7d9f8b6c5d_klmno", FabricUUID: "fabric-2", MachineStatus: int(UNBUILDED)},
+		{MachineName: "cluster_a_worker_5c8d7e6f4a_pqrst", FabricUUID: "fabric-3", MachineStatus: int(ACTIVE_PON)},
+		{MachineName: "cluster_a_etcd_6b7c8d9e0f_uvwxy", FabricUUID: "fabric-4", MachineStatus: int(BUILDING)},
+	}, nil)
+
+	conditions, err := driver.placementConditions()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Condition{
+		{Column: fm.FabricConditionColumn, Operator: "ne", Value: "fabric-1"},
+		{Column: fm.FabricConditionColumn, Operator: "ne", Value: "fabric-4"},
+	}, conditions)
+}
+
+func Test_placementConditions_spread_ListMachines_failed(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.MachineName = "cluster-a-etcd-7d9f8b6c5d-x2kq4"
+	driver.Placement = placementSpread
+	mockFM.On("ListMachines", "cdi-test", models.AccessTokenExample).Return(nil, errors.New("request failed"))
+
+	_, err := driver.placementConditions()
+
+	assert.EqualError(t, err, "request failed")
+}
+
+func Test_verifyMachinePlacement(t *testing.T) {
+	pinned := []models.Condition{{Column: fm.FabricConditionColumn, Operator: "eq", Value: "58f4c0f8-6c74-4e86-a560-95ed13daaa46"}}
+
+	testCases := []struct {
+		name        string
+		fabricUUID  string
+		enforcement string
+		errSubstr   string
+	}{
+		{name: "honoured", fabricUUID: "58f4c0f8-6c74-4e86-a560-95ed13daaa46", enforcement: placementEnforcementFail},
+		{name: "violated with fail", fabricUUID: "other-fabric", enforcement: placementEnforcementFail,
+			errSubstr: "machine ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f was composed on fabric other-fabric which violates 'pin' placement"},
+		{name: "violated with warn", fabricUUID: "other-fabric", enforcement: placementEnforcementWarn},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver, mockFM := newMockedDriver(t)
+			driver.Placement = placementPin
+			driver.PlacementFabricUUID = "58f4c0f8-6c74-4e86-a560-95ed13daaa46"
+			driver.PlacementEnforcement = tc.enforcement
+			mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{FabricUUID: tc.fabricUUID}, nil)
+
+			err := driver.verifyMachinePlacement(pinned)
+
+			assert.Equal(t, tc.fabricUUID, driver.FabricUUID)
+			if tc.errSubstr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.errSubstr)
+		})
+	}
+}
+
+func TestUnmarshalJSON_ReloadsWaits(t *testing.T) {
+	t.Setenv("FSAS_WAIT_SSH_TIMEOUT", "900")
+	t.Setenv("FSAS_WAIT_CLOUD_INIT_TIMEOUT", "1800")
+	t.Setenv("FSAS_POLL_INTERVAL", "7")
+	driver := &Driver{BaseDriver: &drivers.BaseDriver{}}
+
+	err := driver.UnmarshalJSON([]byte(`{"WaitSshTimeout": 300, "WaitCloudInitTimeout": 600, "PollInterval": 5, "WaitInstallTimeout": 3600}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, 900, driver.WaitSshTimeout)
+	assert.Equal(t, 1800, driver.WaitCloudInitTimeout)
+	assert.Equal(t, 7, driver.PollInterval)
+	// Waits not given in environment or arguments keep the saved value
+	assert.Equal(t, 3600, driver.WaitInstallTimeout)
+}
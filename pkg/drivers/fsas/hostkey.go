@@ -0,0 +1,115 @@
+package fsas
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/sshutils"
+	gossh "golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// userDataSourceHostKey is the user-data part installing the host key; it takes precedence over all other parts
+const userDataSourceHostKey = "hostkey"
+
+// hostKeyPair is the SSH host key the machine presents after the reboot applying cloud-init.
+// 'cloud-init clean' makes the ssh module replace host keys on the next boot; providing the key in
+// user-data uploaded over the session verified with the pinned key lets the driver know the new key in advance.
+type hostKeyPair struct {
+	privateKey string // OpenSSH PEM
+	publicKey  gossh.PublicKey
+}
+
+// generateHostKey Returns new ed25519 host key
+var generateHostKey = func() (*hostKeyPair, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate SSH host key: %w", err)
+	}
+	block, err := gossh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode SSH host key: %w", err)
+	}
+	sshPublicKey, err := gossh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode SSH host key: %w", err)
+	}
+	return &hostKeyPair{privateKey: string(pem.EncodeToMemory(block)), publicKey: sshPublicKey}, nil
+}
+
+// authorizedKey Returns public key in authorized_keys format without trailing newline
+func authorizedKey(key gossh.PublicKey) string {
+	return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key)))
+}
+
+// userData Returns cloud-config installing the host key with the cloud-init ssh module
+func (k *hostKeyPair) userData() (string, error) {
+	config := map[string]any{
+		"ssh_keys": map[string]string{
+			"ed25519_private": k.privateKey,
+			"ed25519_public":  authorizedKey(k.publicKey),
+		},
+	}
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return "#cloud-config\n" + string(content), nil
+}
+
+// sshHostPublicKey Returns host key pinned for SSH connections: the key installed by cloud-init once
+// the machine was rebooted with it, otherwise the host key of the OS image
+func (d *Driver) sshHostPublicKey() (gossh.PublicKey, error) {
+	if d.SshHostPubKey != "" {
+		parsedKey, err := sshutils.ParseSSHPublicKey(d.SshHostPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH host public key in driver state: %w", err)
+		}
+		return parsedKey, nil
+	}
+
+	// OsImageSshHostParsedKey may be nil when the driver was restored from JSON
+	// (e.g. during Remove, Start, Stop) because it is not serializable.
+	// In that case, re-parse from the raw string.
+	if d.OsImageSshHostParsedKey == nil && d.OsImageSshHostPubKey != "" {
+		parsedKey, err := sshutils.ParseSSHPublicKey(d.OsImageSshHostPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH host public key format: %w", err)
+		}
+		d.OsImageSshHostParsedKey = parsedKey
+	}
+	return d.OsImageSshHostParsedKey, nil
+}
+
+// pinHostKey Store the host key the machine presents from now on in driver state and use it for later SSH connections
+func (d *Driver) pinHostKey(key gossh.PublicKey) {
+	d.SshHostPubKey = authorizedKey(key)
+	d.SshManager.SetHostPublicKey(key)
+	slog.Info("SSH host key of the machine rotated: ", "machineUUID", d.MachineUUID, "fingerprint", gossh.FingerprintSHA256(key))
+
+	// Record the new host key in the journal
+	if err := d.writeJournal(); err != nil {
+		slog.Warn("Could not update Create journal: ", "err", err)
+	}
+}
+
+// unpinHostKey Return to the host key of the OS image, e.g. before the image is installed again
+func (d *Driver) unpinHostKey() error {
+	if d.SshHostPubKey == "" {
+		return nil
+	}
+	d.SshHostPubKey = ""
+
+	imageKey, err := d.sshHostPublicKey()
+	if err != nil {
+		return err
+	}
+	if d.SshManager.IsInit() && imageKey != nil {
+		d.SshManager.SetHostPublicKey(imageKey)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package fsas
+
+import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+const testOsImageHostKey = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBNlLkDgzQ7FWYLi7wl3ljvaF/n0FEpSrML23hJjvv3HfEvNJxNbjm1GomnefDM9/qYV2pRAganbMMnCG8gs7KD8="
+
+// mockGenerateHostKey Make the driver generate always the same host key
+func mockGenerateHostKey(t *testing.T) *hostKeyPair {
+	privateKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	block, err := gossh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+	publicKey, err := gossh.NewPublicKey(privateKey.Public())
+	require.NoError(t, err)
+	hostKey := &hostKeyPair{privateKey: string(pem.EncodeToMemory(block)), publicKey: publicKey}
+
+	originalGenerateHostKey := generateHostKey
+	t.Cleanup(func() { generateHostKey = originalGenerateHostKey })
+	generateHostKey = func() (*hostKeyPair, error) { return hostKey, nil }
+	return hostKey
+}
+
+// userDataWithHostKey Returns user-data uploaded by the driver generating the mocked host key;
+// empty userData stands for user-data generated by the driver alone
+func userDataWithHostKey(t *testing.T, userData string) string {
+	hostKeyUserData, err := mockGenerateHostKey(t).userData()
+	require.NoError(t, err)
+	if userData == "" {
+		userData = driverUserData
+	}
+	merged, err := cfgutils.MergeUserData(
+		cfgutils.UserDataPart{Source: "expected", Content: userData},
+		cfgutils.UserDataPart{Source: userDataSourceHostKey, Content: hostKeyUserData},
+	)
+	require.NoError(t, err)
+	return merged
+}
+
+func Test_generateHostKey(t *testing.T) {
+	hostKey, err := generateHostKey()
+	require.NoError(t, err)
+
+	signer, err := gossh.ParsePrivateKey([]byte(hostKey.privateKey))
+	require.NoError(t, err)
+	assert.Equal(t, gossh.KeyAlgoED25519, hostKey.publicKey.Type())
+	assert.Equal(t, hostKey.publicKey.Marshal(), signer.PublicKey().Marshal())
+}
+
+func Test_hostKeyPair_userData(t *testing.T) {
+	hostKey := mockGenerateHostKey(t)
+
+	userData, err := hostKey.userData()
+	require.NoError(t, err)
+
+	var config struct {
+		SshKeys map[string]string `yaml:"ssh_keys"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(userData), &config))
+	assert.Equal(t, hostKey.privateKey, config.SshKeys["ed25519_private"])
+	assert.Equal(t, authorizedKey(hostKey.publicKey), config.SshKeys["ed25519_public"])
+	assert.NotContains(t, cfgutils.RedactUserData(userData), "PRIVATE KEY")
+}
+
+func Test_prepareUserData_host_key_takes_precedence(t *testing.T) {
+	hostKey := mockGenerateHostKey(t)
+	driver := &Driver{CustomUserData: "#cloud-config\nssh_keys:\n  ed25519_public: ssh-ed25519 AAAA other\n"}
+
+	userData, err := driver.prepareUserData(hostKey)
+	require.NoError(t, err)
+
+	assert.Contains(t, userData, "ed25519_public: "+authorizedKey(hostKey.publicKey))
+	assert.Contains(t, userData, "ssh_pwauth: false")
+}
+
+func Test_sshHostPublicKey(t *testing.T) {
+	hostKey := mockGenerateHostKey(t)
+
+	driver := &Driver{OsImageSshHostPubKey: testOsImageHostKey}
+	imageKey, err := driver.sshHostPublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, testOsImageHostKey, authorizedKey(imageKey))
+
+	driver.SshHostPubKey = authorizedKey(hostKey.publicKey)
+	rotatedKey, err := driver.sshHostPublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, hostKey.publicKey.Marshal(), rotatedKey.Marshal())
+
+	driver.SshHostPubKey = "not a key"
+	_, err = driver.sshHostPublicKey()
+	assert.ErrorContains(t, err, "invalid SSH host public key in driver state")
+}
+
+func Test_pinHostKey(t *testing.T) {
+	hostKey := mockGenerateHostKey(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver := &Driver{SshManager: mockSSH}
+	mockSSH.On("SetHostPublicKey", hostKey.publicKey).Return()
+
+	driver.pinHostKey(hostKey.publicKey)
+
+	assert.Equal(t, authorizedKey(hostKey.publicKey), driver.SshHostPubKey)
+}
+
+func Test_unpinHostKey(t *testing.T) {
+	hostKey := mockGenerateHostKey(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver := &Driver{SshManager: mockSSH, OsImageSshHostPubKey: testOsImageHostKey, SshHostPubKey: authorizedKey(hostKey.publicKey)}
+	var pinnedKey gossh.PublicKey
+	mockSSH.On("IsInit").Return(true)
+	mockSSH.On("SetHostPublicKey", mock.Anything).Run(func(args mock.Arguments) {
+		pinnedKey = args.Get(0).(gossh.PublicKey)
+	}).Return()
+
+	require.NoError(t, driver.unpinHostKey())
+
+	assert.Empty(t, driver.SshHostPubKey)
+	assert.Equal(t, testOsImageHostKey, authorizedKey(pinnedKey))
+}
+
+func Test_unpinHostKey_not_pinned(t *testing.T) {
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver := &Driver{SshManager: mockSSH}
+
+	assert.NoError(t, driver.unpinHostKey())
+}
+
+func Test_applyCloudInit_generate_host_key_fails(t *testing.T) {
+	originalGenerateHostKey := generateHostKey
+	t.Cleanup(func() { generateHostKey = originalGenerateHostKey })
+	generateHostKey = func() (*hostKeyPair, error) { return nil, errors.New("cannot generate SSH host key: no entropy") }
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver := &Driver{SshManager: mockSSH}
+
+	err := driver.applyCloudInit("node-1")
+
+	assert.EqualError(t, err, "cannot generate SSH host key: no entropy")
+}
@@ -0,0 +1,176 @@
+package fsas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Rancher saves driver state only after Create returns, so a plugin killed in the middle of Create
+// would forget the composed machine. The journal written to the machine's store path as soon as
+// Fabric Manager returns the machine UUID lets Remove and a later Create find the machine again.
+const journalFileName = "fsas-journal.json"
+
+// createJournal holds the part of driver state needed to clean up or resume an interrupted Create
+type createJournal struct {
+	MachineUUID        string    `json:"machine_uuid"`
+	TenantUUID         string    `json:"tenant_uuid"`
+	Phase              string    `json:"phase"` // Last completed phase of Create
+	ResumeAttemptsUsed int       `json:"resume_attempts_used,omitempty"`
+	KeptOnFailure      bool      `json:"kept_on_failure,omitempty"`
+	KeptOnFailureUntil time.Time `json:"kept_on_failure_until"`
+	SshHostPubKey      string    `json:"ssh_host_pub_key,omitempty"` // Host key installed by cloud-init
+	IPAddress          string    `json:"ip_address,omitempty"`       // Set by the ip-assign phase, needed by SSH phases on resume
+	PrivateIPAddress   string    `json:"private_ip_address,omitempty"`
+}
+
+// journalPath Returns path of the journal file or empty string when the driver has no store path
+func (d *Driver) journalPath() string {
+	if d.BaseDriver == nil || d.StorePath == "" {
+		return ""
+	}
+	return d.ResolveStorePath(journalFileName)
+}
+
+// writeJournal Save machine UUID, tenant and last completed phase of Create to the journal file
+func (d *Driver) writeJournal() error {
+	path := d.journalPath()
+	if path == "" {
+		slog.Debug("Store path is not set, skipping write of Create journal")
+		return nil
+	}
+
+	data, err := json.Marshal(createJournal{
+		MachineUUID:        d.MachineUUID,
+		TenantUUID:         d.TenantUuid,
+		Phase:              d.LastCompletedPhase,
+		ResumeAttemptsUsed: d.CreateResumeAttemptsUsed,
+		KeptOnFailure:      d.KeptOnFailure,
+		KeptOnFailureUntil: d.KeptOnFailureUntil,
+		SshHostPubKey:      d.SshHostPubKey,
+		IPAddress:          d.IPAddress,
+		PrivateIPAddress:   d.PrivateIPAddress,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create directory for Create journal: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted write never leaves a truncated journal
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("cannot write Create journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("cannot write Create journal: %w", err)
+	}
+
+	slog.Debug("Create journal written: ", "path", path, "machineUUID", d.MachineUUID, "phase", d.LastCompletedPhase)
+	return nil
+}
+
+// readJournal Returns content of the journal file or nil when there is no journal
+func (d *Driver) readJournal() (*createJournal, error) {
+	path := d.journalPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Create journal: %w", err)
+	}
+
+	var journal createJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("cannot parse Create journal %s: %w", path, err)
+	}
+	if journal.MachineUUID == "" {
+		return nil, nil
+	}
+	return &journal, nil
+}
+
+// removeJournal Delete the journal file once its machine is removed
+func (d *Driver) removeJournal() error {
+	path := d.journalPath()
+	if path == "" {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove Create journal: %w", err)
+	}
+	return nil
+}
+
+// adoptJournal Take over machine recorded in the journal when driver state does not know any machine.
+// Returns true when the machine was adopted.
+func (d *Driver) adoptJournal() (bool, error) {
+	if d.MachineUUID != "" {
+		return false, nil
+	}
+
+	journal, err := d.readJournal()
+	if err != nil || journal == nil {
+		return false, err
+	}
+
+	if d.TenantUuid != "" && journal.TenantUUID != d.TenantUuid {
+		slog.Warn("Tenant in Create journal differs from driver configuration, using the journaled one: ",
+			"journal_tenant", journal.TenantUUID, "tenant", d.TenantUuid)
+	}
+
+	d.MachineUUID = journal.MachineUUID
+	d.TenantUuid = journal.TenantUUID
+	d.LastCompletedPhase = journal.Phase
+	d.CreateResumeAttemptsUsed = journal.ResumeAttemptsUsed
+	d.KeptOnFailure = journal.KeptOnFailure
+	d.KeptOnFailureUntil = journal.KeptOnFailureUntil
+	d.SshHostPubKey = journal.SshHostPubKey
+	d.IPAddress = journal.IPAddress
+	d.PrivateIPAddress = journal.PrivateIPAddress
+	slog.Warn("Found machine of interrupted Create in journal: ",
+		"machineUUID", d.MachineUUID,
+		"tenant", d.TenantUuid,
+		"last_completed_phase", d.LastCompletedPhase)
+	return true, nil
+}
+
+// recoverFromJournal Resume or remove machine left behind by Create which did not return
+func (d *Driver) recoverFromJournal() error {
+	adopted, err := d.adoptJournal()
+	if err != nil || !adopted {
+		return err
+	}
+
+	// The interrupted Create did not count as a resume attempt, resuming only needs to be enabled
+	if d.LastCompletedPhase != "" && d.CreateResumeAttempts > 0 {
+		slog.Info("Resuming Create of journaled machine: ", "machineUUID", d.MachineUUID)
+		return nil
+	}
+
+	slog.Info("Removing journaled machine before composing a new one: ", "machineUUID", d.MachineUUID)
+	if err := d.Remove(); err != nil {
+		return fmt.Errorf("cannot remove machine %s left by interrupted Create: %w", d.MachineUUID, err)
+	}
+
+	d.MachineUUID = ""
+	d.LastCompletedPhase = ""
+	d.CreateResumeAttemptsUsed = 0
+	d.SshHostPubKey = ""
+	d.IPAddress = ""
+	d.PrivateIPAddress = ""
+	return nil
+}
@@ -0,0 +1,105 @@
+package fsas
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rancher/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_write_read_remove(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.MachineUUID = "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f"
+	driver.LastCompletedPhase = phaseImageInstall
+	driver.CreateResumeAttemptsUsed = 1
+
+	require.NoError(t, driver.writeJournal())
+	assert.NoFileExists(t, driver.ResolveStorePath(journalFileName+".tmp"))
+
+	journal, err := driver.readJournal()
+	require.NoError(t, err)
+	assert.Equal(t, &createJournal{
+		MachineUUID:        "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f",
+		TenantUUID:         "cdi-test",
+		Phase:              phaseImageInstall,
+		ResumeAttemptsUsed: 1,
+	}, journal)
+
+	require.NoError(t, driver.removeJournal())
+	assert.NoFileExists(t, driver.ResolveStorePath(journalFileName))
+
+	journal, err = driver.readJournal()
+	assert.NoError(t, err)
+	assert.Nil(t, journal)
+
+	// Removing missing journal is not an error
+	assert.NoError(t, driver.removeJournal())
+}
+
+func TestJournal_no_store_path(t *testing.T) {
+	driver := &Driver{BaseDriver: &drivers.BaseDriver{MachineName: "machineNameTest"}, MachineUUID: "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f"}
+
+	assert.Empty(t, driver.journalPath())
+	assert.NoError(t, driver.writeJournal())
+	assert.NoFileExists(t, driver.ResolveStorePath(journalFileName))
+
+	journal, err := driver.readJournal()
+	assert.NoError(t, err)
+	assert.Nil(t, journal)
+	assert.NoError(t, driver.removeJournal())
+}
+
+func TestJournal_corrupted(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.StorePath = t.TempDir()
+	require.NoError(t, os.MkdirAll(driver.ResolveStorePath(""), 0700))
+	require.NoError(t, os.WriteFile(driver.ResolveStorePath(journalFileName), []byte("{\"machine_uuid\":"), 0600))
+
+	_, err := driver.readJournal()
+	assert.ErrorContains(t, err, "cannot parse Create journal")
+}
+
+func Test_adoptJournal(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.MachineUUID = "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f"
+	driver.LastCompletedPhase = phaseSshKeys
+	driver.SshHostPubKey = testOsImageHostKey
+	driver.IPAddress = "192.168.0.10"
+	driver.PrivateIPAddress = "10.0.0.10"
+	require.NoError(t, driver.writeJournal())
+
+	// Driver state knowing a machine takes precedence over the journal
+	adopted, err := driver.adoptJournal()
+	assert.NoError(t, err)
+	assert.False(t, adopted)
+
+	driver.MachineUUID = ""
+	driver.LastCompletedPhase = ""
+	driver.TenantUuid = ""
+	driver.SshHostPubKey = ""
+	driver.IPAddress = ""
+	driver.PrivateIPAddress = ""
+
+	adopted, err = driver.adoptJournal()
+	assert.NoError(t, err)
+	assert.True(t, adopted)
+	assert.Equal(t, "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f", driver.MachineUUID)
+	assert.Equal(t, "cdi-test", driver.TenantUuid)
+	assert.Equal(t, phaseSshKeys, driver.LastCompletedPhase)
+	assert.Equal(t, testOsImageHostKey, driver.SshHostPubKey)
+	assert.Equal(t, "192.168.0.10", driver.IPAddress)
+	assert.Equal(t, "10.0.0.10", driver.PrivateIPAddress)
+}
+
+func Test_recoverFromJournal_no_journal(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.MachineUUID = ""
+
+	assert.NoError(t, driver.recoverFromJournal())
+	assert.Empty(t, driver.MachineUUID)
+}
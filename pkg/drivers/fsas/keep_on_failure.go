@@ -0,0 +1,76 @@
+package fsas
+
+import (
+	"fmt"
+	"time"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Values of --fsas-keep-on-failure besides a time-to-live such as "2h"
+const (
+	keepOnFailureOff    = "off"
+	keepOnFailureAlways = "always"
+)
+
+// parseKeepOnFailure Returns whether failed machines are kept and after how long GetState reports them as failed;
+// zero time-to-live means the machine is kept until the next Remove or Create
+func parseKeepOnFailure(value string) (bool, time.Duration, error) {
+	switch value {
+	case "", keepOnFailureOff:
+		return false, 0, nil
+	case keepOnFailureAlways:
+		return true, 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return false, 0, fmt.Errorf("keep on failure must be '%s', '%s' or a positive duration such as '2h'; got '%s'",
+			keepOnFailureOff, keepOnFailureAlways, value)
+	}
+	return true, ttl, nil
+}
+
+// keepFailedMachine Mark machine of the failed Create as kept for debugging instead of removing it.
+// Returns false when keeping is disabled or there is no machine to keep.
+func (d *Driver) keepFailedMachine() bool {
+	keep, ttl, err := parseKeepOnFailure(d.KeepOnFailure)
+	if err != nil || !keep || d.MachineUUID == "" {
+		return false
+	}
+
+	d.KeptOnFailure = true
+	d.KeptOnFailureUntil = time.Time{}
+	if ttl > 0 {
+		d.KeptOnFailureUntil = statusClock.Now().Add(ttl)
+	}
+
+	slog.Warn("Keeping machine of the failed Create for debugging, Remove cleans it up: ",
+		"machineUUID", d.MachineUUID,
+		"ip", d.IPAddress,
+		"private_ip", d.PrivateIPAddress,
+		"ssh_user", d.GetSSHUsername(),
+		"ssh_key_path", d.GetSSHKeyPath(),
+		"last_completed_phase", d.LastCompletedPhase,
+		"kept_until", d.keptUntilString())
+
+	if err := d.writeJournal(); err != nil {
+		slog.Warn("Could not update Create journal: ", "err", err)
+	}
+	return true
+}
+
+// keptMachineExpired Returns true when time-to-live of the machine kept for debugging has run out
+func (d *Driver) keptMachineExpired() bool {
+	if !d.KeptOnFailure || d.KeptOnFailureUntil.IsZero() {
+		return false
+	}
+	return !statusClock.Now().Before(d.KeptOnFailureUntil)
+}
+
+func (d *Driver) keptUntilString() string {
+	if d.KeptOnFailureUntil.IsZero() {
+		return "next Remove"
+	}
+	return d.KeptOnFailureUntil.UTC().Format(time.RFC3339)
+}
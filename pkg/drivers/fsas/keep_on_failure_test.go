@@ -0,0 +1,92 @@
+package fsas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+	"github.com/rancher/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseKeepOnFailure(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantKeep bool
+		wantTTL  time.Duration
+		wantErr  bool
+	}{
+		{value: "", wantKeep: false},
+		{value: "off", wantKeep: false},
+		{value: "always", wantKeep: true},
+		{value: "2h", wantKeep: true, wantTTL: 2 * time.Hour},
+		{value: "90m", wantKeep: true, wantTTL: 90 * time.Minute},
+		{value: "0s", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			keep, ttl, err := parseKeepOnFailure(tt.value)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "keep on failure must be 'off', 'always' or a positive duration")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantKeep, keep)
+			assert.Equal(t, tt.wantTTL, ttl)
+		})
+	}
+}
+
+func Test_keepFailedMachine(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	clock := timeutils.NewManualClock(now)
+	statusClock = clock
+	t.Cleanup(func() { statusClock = timeutils.NewRealClock() })
+
+	newDriver := func(keepOnFailure string) *Driver {
+		return &Driver{
+			BaseDriver:    &drivers.BaseDriver{MachineName: "machineNameTest", IPAddress: "10.0.0.5", SSHKeyPath: "/tmp/test-key"},
+			MachineUUID:   "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f",
+			KeepOnFailure: keepOnFailure,
+		}
+	}
+
+	driver := newDriver("off")
+	assert.False(t, driver.keepFailedMachine())
+	assert.False(t, driver.KeptOnFailure)
+
+	driver = newDriver("always")
+	driver.MachineUUID = ""
+	assert.False(t, driver.keepFailedMachine())
+
+	driver = newDriver("always")
+	assert.True(t, driver.keepFailedMachine())
+	assert.True(t, driver.KeptOnFailure)
+	assert.True(t, driver.KeptOnFailureUntil.IsZero())
+	assert.False(t, driver.keptMachineExpired())
+
+	driver = newDriver("2h")
+	assert.True(t, driver.keepFailedMachine())
+	assert.Equal(t, now.Add(2*time.Hour), driver.KeptOnFailureUntil)
+	assert.False(t, driver.keptMachineExpired())
+
+	clock.Advance(2 * time.Hour)
+	assert.True(t, driver.keptMachineExpired())
+}
+
+func Test_keepFailedMachine_journal(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.StorePath = t.TempDir()
+	driver.MachineUUID = "a5ee3d1b-0f3e-4c59-a38e-8c8e2d5a6b4f"
+	driver.KeepOnFailure = "always"
+
+	require.True(t, driver.keepFailedMachine())
+
+	journal, err := driver.readJournal()
+	require.NoError(t, err)
+	assert.True(t, journal.KeptOnFailure)
+}
@@ -0,0 +1,49 @@
+package fsas
+
+import (
+	"strings"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// driverPublicKey Returns public part of the driver SSH key or empty string when the key was not generated yet
+func (d *Driver) driverPublicKey() string {
+	content, err := osReadFile(d.GetSSHKeyPath() + ".pub")
+	if err != nil {
+		slog.Warn("Public SSH key of the driver not added to meta-data: ", "err", err)
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// prepareMetadata Returns NoCloud meta-data of the machine including facts Fabric Manager reports about it
+func (d *Driver) prepareMetadata(hostName string) (string, error) {
+	machine, err := d.FabricManager.GetMachine(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
+		return "", err
+	}
+
+	metadata := cfgutils.Metadata{
+		InstanceId: d.MachineUUID,
+		Hostname:   hostName,
+		Cdi: &cfgutils.CdiMetadata{
+			TenantUUID:  d.TenantUuid,
+			FabricUUID:  machine.FabricUUID,
+			MachineUUID: d.MachineUUID,
+			BootSSD:     machine.BootSSD,
+			Resources:   machine.Resources,
+		},
+	}
+	if publicKey := d.driverPublicKey(); publicKey != "" {
+		metadata.PublicKeys = []string{publicKey}
+	}
+
+	content, err := d.CfgManager.PrepareMetadata(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	slog.Debug("Metadata: ", "metadata", content)
+	return content, nil
+}
@@ -0,0 +1,73 @@
+package fsas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	cfgMock "github.com/fujitsu/docker-machine-driver-fsas/cfgutils/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_prepareMetadata(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	driver.CfgManager = mockCfg
+	driver.SSHKeyPath = "/store/machines/node-1/id_rsa"
+	mockOsReadFile(t, map[string]string{"/store/machines/node-1/id_rsa.pub": "ssh-rsa AAAAB3NzaC1yc2E docker-machine\n"})
+	resources := []models.Resource{{ResourceType: "storage", ResourceUUID: "ssd-uuid"}}
+
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{
+		FabricUUID: "fabric-uuid",
+		BootSSD:    "ssd-uuid",
+		Resources:  resources,
+	}, nil)
+	mockCfg.On("PrepareMetadata", cfgutils.Metadata{
+		InstanceId: driver.MachineUUID,
+		Hostname:   "node-1",
+		PublicKeys: []string{"ssh-rsa AAAAB3NzaC1yc2E docker-machine"},
+		Cdi: &cfgutils.CdiMetadata{
+			TenantUUID:  "cdi-test",
+			FabricUUID:  "fabric-uuid",
+			MachineUUID: driver.MachineUUID,
+			BootSSD:     "ssd-uuid",
+			Resources:   resources,
+		},
+	}).Return("metadata", nil)
+
+	metadata, err := driver.prepareMetadata("node-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "metadata", metadata)
+}
+
+func Test_prepareMetadata_without_public_key(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	driver.CfgManager = mockCfg
+	driver.SSHKeyPath = "/store/machines/node-1/id_rsa"
+	mockOsReadFile(t, map[string]string{})
+
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{}, nil)
+	mockCfg.On("PrepareMetadata", cfgutils.Metadata{
+		InstanceId: driver.MachineUUID,
+		Hostname:   "node-1",
+		Cdi:        &cfgutils.CdiMetadata{TenantUUID: "cdi-test", MachineUUID: driver.MachineUUID},
+	}).Return("metadata", nil)
+
+	_, err := driver.prepareMetadata("node-1")
+
+	assert.NoError(t, err)
+}
+
+func Test_prepareMetadata_get_machine_fails(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	driver.SSHKeyPath = "/store/machines/node-1/id_rsa"
+
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(nil, errors.New("request failed"))
+
+	_, err := driver.prepareMetadata("node-1")
+
+	assert.EqualError(t, err, "request failed")
+}
@@ -0,0 +1,114 @@
+package fsas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Address modes of subnet roles: DHCP or the static address Fabric Manager assigned to the lanport
+const (
+	addressModeDhcp   = "dhcp"
+	addressModeStatic = "static"
+)
+
+// Roles of subnets in network-config; the provisioning subnet carries the preferred default route
+const (
+	networkRoleProvision = "provision"
+	networkRoleBaremetal = "baremetal"
+
+	provisionRouteMetric = 100
+	baremetalRouteMetric = 200
+)
+
+// parseAddressMode Returns whether the subnet uses DHCP and prefix length of its static address;
+// value is either 'dhcp' or 'static/<prefix length>'
+func parseAddressMode(value string) (bool, int, error) {
+	if value == "" || value == addressModeDhcp {
+		return true, 0, nil
+	}
+
+	prefix, found := strings.CutPrefix(value, addressModeStatic+"/")
+	if found {
+		if prefixLength, err := strconv.Atoi(prefix); err == nil && prefixLength > 0 && prefixLength <= 32 {
+			return false, prefixLength, nil
+		}
+	}
+	return false, 0, fmt.Errorf("address mode must be '%s' or '%s/<prefix length>' such as '%s/24'; got '%s'",
+		addressModeDhcp, addressModeStatic, addressModeStatic, value)
+}
+
+// checkNetworkConfig Verify address modes of subnet roles
+func (d *Driver) checkNetworkConfig() error {
+	if _, _, err := parseAddressMode(d.NetworkProvisionAddressMode); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", "--fsas-network-provision-address-mode", err)
+	}
+	if _, _, err := parseAddressMode(d.NetworkBaremetalAddressMode); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", "--fsas-network-baremetal-address-mode", err)
+	}
+	return nil
+}
+
+// networkRoles Returns configuration of the subnets the machine is connected to
+func (d *Driver) networkRoles() ([]cfgutils.NetworkRole, error) {
+	var nameservers []string
+	for _, dns := range strings.Split(d.DnsIp, ",") {
+		if dns = strings.TrimSpace(dns); dns != "" {
+			nameservers = append(nameservers, dns)
+		}
+	}
+
+	dhcp, prefixLength, err := parseAddressMode(d.NetworkProvisionAddressMode)
+	if err != nil {
+		return nil, err
+	}
+	roles := []cfgutils.NetworkRole{{
+		Name:         networkRoleProvision,
+		SubnetUUID:   d.NetworkProvisionUUID,
+		Dhcp:         dhcp,
+		PrefixLength: prefixLength,
+		Gateway:      d.NetworkProvisionDefaultGW,
+		RouteMetric:  provisionRouteMetric,
+		Nameservers:  nameservers,
+	}}
+
+	if d.NetworkBaremetalUUID != "" {
+		dhcp, prefixLength, err := parseAddressMode(d.NetworkBaremetalAddressMode)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, cfgutils.NetworkRole{
+			Name:         networkRoleBaremetal,
+			SubnetUUID:   d.NetworkBaremetalUUID,
+			Dhcp:         dhcp,
+			PrefixLength: prefixLength,
+			Gateway:      d.NetworkBaremetalDefaultGW,
+			RouteMetric:  baremetalRouteMetric,
+		})
+	}
+	return roles, nil
+}
+
+// prepareNetworkConfig Returns cloud-init network-config built from lanports Fabric Manager reports for the machine
+func (d *Driver) prepareNetworkConfig() (string, error) {
+	lanports, _, _, err := d.FabricManager.GetMachineDetails(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
+		return "", err
+	}
+
+	roles, err := d.networkRoles()
+	if err != nil {
+		return "", err
+	}
+
+	networkConfig, err := d.CfgManager.PrepareNetworkConfig(lanports, roles)
+	if err != nil {
+		return "", err
+	}
+
+	slog.Debug("Network config: ", "network_config", networkConfig)
+	return networkConfig, nil
+}
@@ -0,0 +1,105 @@
+package fsas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	cfgMock "github.com/fujitsu/docker-machine-driver-fsas/cfgutils/mock"
+	fmmock "github.com/fujitsu/docker-machine-driver-fsas/fm/mock"
+	keycloakMock "github.com/fujitsu/docker-machine-driver-fsas/keycloak/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAddressMode(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantDhcp   bool
+		wantPrefix int
+		wantErr    bool
+	}{
+		{value: "", wantDhcp: true},
+		{value: "dhcp", wantDhcp: true},
+		{value: "static/24", wantPrefix: 24},
+		{value: "static/32", wantPrefix: 32},
+		{value: "static", wantErr: true},
+		{value: "static/0", wantErr: true},
+		{value: "static/33", wantErr: true},
+		{value: "manual", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			dhcp, prefix, err := parseAddressMode(tt.value)
+			if tt.wantErr {
+				assert.EqualError(t, err, "address mode must be 'dhcp' or 'static/<prefix length>' such as 'static/24'; got '"+tt.value+"'")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDhcp, dhcp)
+			assert.Equal(t, tt.wantPrefix, prefix)
+		})
+	}
+}
+
+func Test_checkNetworkConfig(t *testing.T) {
+	assert.NoError(t, (&Driver{NetworkProvisionAddressMode: "static/24", NetworkBaremetalAddressMode: "dhcp"}).checkNetworkConfig())
+	assert.ErrorContains(t, (&Driver{NetworkBaremetalAddressMode: "static"}).checkNetworkConfig(),
+		"invalid value of --fsas-network-baremetal-address-mode")
+}
+
+func Test_networkRoles(t *testing.T) {
+	driver := &Driver{
+		NetworkProvisionUUID:        "provision-subnet",
+		NetworkProvisionDefaultGW:   "192.168.2.1",
+		NetworkProvisionAddressMode: "static/24",
+		NetworkBaremetalUUID:        "baremetal-subnet",
+		NetworkBaremetalDefaultGW:   "10.0.0.1",
+		DnsIp:                       "192.168.2.53, 192.168.2.54",
+	}
+
+	roles, err := driver.networkRoles()
+
+	require.NoError(t, err)
+	assert.Equal(t, []cfgutils.NetworkRole{
+		{Name: "provision", SubnetUUID: "provision-subnet", PrefixLength: 24, Gateway: "192.168.2.1", RouteMetric: 100, Nameservers: []string{"192.168.2.53", "192.168.2.54"}},
+		{Name: "baremetal", SubnetUUID: "baremetal-subnet", Dhcp: true, Gateway: "10.0.0.1", RouteMetric: 200},
+	}, roles)
+}
+
+func Test_networkRoles_without_baremetal_subnet(t *testing.T) {
+	driver := &Driver{NetworkProvisionUUID: "provision-subnet"}
+
+	roles, err := driver.networkRoles()
+
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "provision", roles[0].Name)
+	assert.True(t, roles[0].Dhcp)
+	assert.Empty(t, roles[0].Nameservers)
+}
+
+func Test_prepareNetworkConfig_fail(t *testing.T) {
+	mockFM := fmmock.NewMockFabricManager(t)
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	driver := &Driver{
+		FabricManager:        mockFM,
+		Keycloak:             mockKeycloak,
+		CfgManager:           mockCfg,
+		MachineUUID:          "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+		NetworkProvisionUUID: "unknown-subnet",
+	}
+
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockFM.On("GetMachineDetails", "", driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", 13, nil)
+	mockCfg.On("PrepareNetworkConfig", models.ExpectedLanports, []cfgutils.NetworkRole{
+		{Name: "provision", SubnetUUID: "unknown-subnet", Dhcp: true, RouteMetric: 100},
+	}).Return("", errors.New("no lanport of the machine is connected to provision subnet unknown-subnet"))
+
+	_, err := driver.prepareNetworkConfig()
+
+	assert.EqualError(t, err, "no lanport of the machine is connected to provision subnet unknown-subnet")
+}
@@ -0,0 +1,262 @@
+package fsas
+
+import (
+	"fmt"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+)
+
+// Phases of Create in order of execution.
+// The last completed phase is kept in driver state, so a retried Create resumes from the failed one.
+const (
+	phaseCompose      = "compose"
+	phaseWaitPoff     = "wait-poff"
+	phaseImageInstall = "image-install"
+	phasePowerOn      = "power-on"
+	phaseIpAssign     = "ip-assign"
+	phaseSshKeys      = "ssh-keys"
+	phaseRegister     = "register"
+	phaseAirgap       = "airgap"
+	phaseRke2Config   = "rke2-config"
+	phaseCloudInit    = "cloud-init"
+	phaseHarden       = "harden"
+)
+
+type createPhase struct {
+	name string
+	run  func() error
+}
+
+// createPhases Returns phases of Create in order of execution
+func (d *Driver) createPhases() []createPhase {
+	return []createPhase{
+		{name: phaseCompose, run: d.compose},
+		{name: phaseWaitPoff, run: d.waitForComposed},
+		{name: phaseImageInstall, run: d.installImage},
+		{name: phasePowerOn, run: d.Start},
+		{name: phaseIpAssign, run: d.assignIpAddresses},
+		{name: phaseSshKeys, run: d.exchangeSshKeys},
+		{name: phaseRegister, run: d.registerOS},
+		{name: phaseAirgap, run: d.uploadAirgapArtifacts},
+		{name: phaseRke2Config, run: d.configureRke2},
+		{name: phaseCloudInit, run: func() error { return d.applyCloudInit(d.GetMachineName()) }},
+		{name: phaseHarden, run: d.harden},
+	}
+}
+
+// phaseIndex Returns position of the phase in Create or -1 if there is no such phase
+func phaseIndex(phases []createPhase, name string) int {
+	for idx, phase := range phases {
+		if phase.name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// canResumeCreate Returns true when failed Create keeps the composed machine for a retried Create
+func (d *Driver) canResumeCreate() bool {
+	return d.MachineUUID != "" && d.LastCompletedPhase != "" && d.CreateResumeAttemptsUsed < d.CreateResumeAttempts
+}
+
+// runCreatePhases Run Create phases following the last completed one
+func (d *Driver) runCreatePhases() error {
+	phases := d.createPhases()
+
+	start := 0
+	if d.MachineUUID != "" && d.LastCompletedPhase != "" {
+		idx := phaseIndex(phases, d.LastCompletedPhase)
+		if idx < 0 {
+			return fmt.Errorf("unknown create phase '%s' in driver state", d.LastCompletedPhase)
+		}
+		start = idx + 1
+		slog.Info("Resuming Create of machine: ", "machineUUID", d.MachineUUID, "last_completed_phase", d.LastCompletedPhase)
+
+		// Phases after key exchange expect SSH Manager which was initialized by the failed Create
+		if start > phaseIndex(phases, phaseSshKeys) && start < len(phases) {
+			if err := d.initSshManager(); err != nil {
+				slog.Error("Error while initializing SSH Manager", "err", err)
+				return err
+			}
+		}
+	} else {
+		d.LastCompletedPhase = ""
+	}
+
+	return d.runPhases(phases[start:])
+}
+
+// runPhases Run given phases in order recording each completed one in driver state and journal
+func (d *Driver) runPhases(phases []createPhase) error {
+	for _, phase := range phases {
+		slog.Info("Starting Create phase: ", "phase", phase.name)
+		if err := phase.run(); err != nil {
+			slog.Error("Create phase failed: ", "phase", phase.name, "err", err)
+			return err
+		}
+		d.LastCompletedPhase = phase.name
+		if err := d.writeJournal(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compose Request new machine from Fabric Manager and verify its assignment and placement
+func (d *Driver) compose() error {
+	machineOwner, err := renderMachineTemplate(d.MachineOwner, d.MachineName)
+	if err != nil {
+		return err
+	}
+	d.MachineOwner = machineOwner
+
+	fabricConditions, err := d.placementConditions()
+	if err != nil {
+		return err
+	}
+
+	machineSpecArgs := models.MachineSpecsArgs{
+		ComputeConditionsJson:     d.ComputeConditionsJson,
+		DevicesSpecJson:           d.DevicesSpecJson,
+		NetworkBaremetalPort:      d.NetworkBaremetalPort,
+		NetworkBaremetalUUID:      d.NetworkBaremetalUUID,
+		NetworkBaremetalDefaultGW: d.NetworkBaremetalDefaultGW,
+		NetworkProvisionPort:      d.NetworkProvisionPort,
+		NetworkProvisionUUID:      d.NetworkProvisionUUID,
+		NetworkProvisionDefaultGW: d.NetworkProvisionDefaultGW,
+		NtpServer:                 d.NtpUrl,
+		DnsServer:                 d.DnsIp,
+		MachineOwner:              d.MachineOwner,
+		GroupUUID:                 d.MachineGroupUUID,
+		FabricConditions:          fabricConditions,
+	}
+
+	machineUUID, err := d.FabricManager.CreateMachine(d.MachineName, d.TenantUuid, machineSpecArgs, d.Keycloak.GetToken())
+	if err != nil {
+		return err
+	}
+
+	d.MachineUUID = machineUUID
+	slog.Info("Successfully filled MachineUUID: ", "MachineUUID", d.MachineUUID)
+
+	// Record the machine in the journal before anything else can fail
+	if err := d.writeJournal(); err != nil {
+		return err
+	}
+
+	if err := d.verifyMachineAssignment(); err != nil {
+		return err
+	}
+
+	return d.verifyMachinePlacement(fabricConditions)
+}
+
+// waitForComposed Wait until composed machine is powered off and ready for OS installation
+func (d *Driver) waitForComposed() error {
+	slog.Info("Waiting for status: ", "status", ACTIVE_POFF)
+	return d.waitForStatus(opCreate, d.waitStatusTimeout())
+}
+
+// installImage Install OS image on boot storage and wait for the installation to complete
+func (d *Driver) installImage() error {
+	_, bootSsdId, status, err := d.FabricManager.GetMachineDetails(d.TenantUuid, d.MachineUUID, d.Keycloak.GetToken())
+	if err != nil {
+		return err
+	}
+
+	// Installation requested by the failed Create may still be in progress
+	if CdiMachineState(status) == OS_INSTALLING {
+		slog.Info("Operating system installation already in progress: ", "OS", d.OsImageName)
+	} else {
+		if err := d.FabricManager.ImageInstall(d.TenantUuid, bootSsdId, d.OsImageName, d.Keycloak.GetToken()); err != nil {
+			return err
+		}
+
+		slog.Info("Waiting for the installation of the operating system: ", "status", OS_INSTALLING)
+		if err := d.waitForStatus(opInstallStart, d.waitStatusTimeout()); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Installing operating system: ", "OS", d.OsImageName)
+
+	slog.Info("Waiting for operating system installation to complete: ", "status", ACTIVE_POFF)
+	return d.waitForStatus(opInstall, d.waitInstallTimeout())
+}
+
+// exchangeSshKeys Connect to installed OS and replace password login with driver SSH key
+func (d *Driver) exchangeSshKeys() error {
+	if err := d.initSshManager(); err != nil {
+		slog.Error("Error while initializing SSH Manager", "err", err)
+		return err
+	}
+
+	return d.SshManager.ExchangeKeys()
+}
+
+// registerOS Register installed OS
+func (d *Driver) registerOS() error {
+	if err := d.SshManager.RegisterOS(d.osRegistration()); err != nil {
+		slog.Error("Failed to register OS via SSH: ", "err", err, "registrar", d.OsRegistrar)
+		return err
+	}
+	return nil
+}
+
+// osRegistration Returns parameters of the OS registration
+func (d *Driver) osRegistration() models.OsRegistration {
+	return models.OsRegistration{
+		Registrar: d.OsRegistrar,
+		Code:      d.SlesRegistrationCode,
+		Email:     d.SlesRegistrationEmail,
+		Org:       d.OsRegistrationOrg,
+
+		ServerUrl:           d.SlesRmtUrl,
+		ServerCaCert:        d.SlesRmtCaCert,
+		ServerCaFingerprint: d.SlesRmtCaFingerprint,
+		Modules:             d.SlesModules,
+	}
+}
+
+// configureRke2 Upload RKE2 configuration overriding provider ID of the node
+func (d *Driver) configureRke2() error {
+	if !d.CfgManager.IsInit() {
+		acceleratorLabels, err := d.acceleratorLabels()
+		if err != nil {
+			return err
+		}
+		cfgManager := cfgutils.NewStandardCfgManager(d.DevicesSpecJson, acceleratorLabels)
+		d.CfgManager = cfgManager
+	}
+
+	// Prepare scripts execution parameters
+	scriptPath := "" // Random paths
+	removeOnFinish := true
+	runSudo := true
+
+	nodeLabels, err := cfgutils.ParseNodeLabels(d.NodeLabels)
+	if err != nil {
+		return err
+	}
+	nodeTaints, err := cfgutils.ParseNodeTaints(d.NodeTaints)
+	if err != nil {
+		return err
+	}
+
+	// Generate script content for RKE2 setup
+	overrideProviderIdScriptContent := d.CfgManager.PrepareRke2ConfigScript("100-fsas-providerid", d.MachineUUID, nodeLabels, nodeTaints)
+
+	return d.SshManager.ExecuteScript(scriptPath, overrideProviderIdScriptContent, removeOnFinish, runSudo)
+}
+
+// harden Disable SSH password login
+func (d *Driver) harden() error {
+	if err := d.SshManager.DisablePasswordSSHLogin(); err != nil {
+		slog.Error("Failed to disable password login: ", "err", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package fsas
+
+import (
+	"testing"
+	"time"
+
+	cfgMock "github.com/fujitsu/docker-machine-driver-fsas/cfgutils/mock"
+	fmmock "github.com/fujitsu/docker-machine-driver-fsas/fm/mock"
+	keycloakMock "github.com/fujitsu/docker-machine-driver-fsas/keycloak/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/fujitsu/docker-machine-driver-fsas/timeutils"
+
+	"github.com/rancher/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePhasesOrder(t *testing.T) {
+	driver := NewDriver()
+
+	var names []string
+	for _, phase := range driver.createPhases() {
+		names = append(names, phase.name)
+	}
+
+	assert.Equal(t, []string{
+		"compose", "wait-poff", "image-install", "power-on", "ip-assign",
+		"ssh-keys", "register", "airgap", "rke2-config", "cloud-init", "harden",
+	}, names)
+}
+
+func Test_phaseIndex(t *testing.T) {
+	phases := NewDriver().createPhases()
+
+	assert.Equal(t, 0, phaseIndex(phases, phaseCompose))
+	assert.Equal(t, 10, phaseIndex(phases, phaseHarden))
+	assert.Equal(t, -1, phaseIndex(phases, "unknown"))
+}
+
+func Test_canResumeCreate(t *testing.T) {
+	testCases := []struct {
+		name               string
+		machineUUID        string
+		lastCompletedPhase string
+		attempts           int
+		attemptsUsed       int
+		expected           bool
+	}{
+		{name: "resume allowed", machineUUID: "uuid", lastCompletedPhase: phaseImageInstall, attempts: 2, attemptsUsed: 1, expected: true},
+		{name: "resume disabled", machineUUID: "uuid", lastCompletedPhase: phaseImageInstall, attempts: 0, expected: false},
+		{name: "attempts exhausted", machineUUID: "uuid", lastCompletedPhase: phaseImageInstall, attempts: 2, attemptsUsed: 2, expected: false},
+		{name: "compose not completed", machineUUID: "uuid", attempts: 2, expected: false},
+		{name: "no machine", lastCompletedPhase: phaseImageInstall, attempts: 2, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver := &Driver{
+				MachineUUID:              tc.machineUUID,
+				LastCompletedPhase:       tc.lastCompletedPhase,
+				CreateResumeAttempts:     tc.attempts,
+				CreateResumeAttemptsUsed: tc.attemptsUsed,
+			}
+			assert.Equal(t, tc.expected, driver.canResumeCreate())
+		})
+	}
+}
+
+func Test_runCreatePhases_unknown_phase(t *testing.T) {
+	driver := &Driver{
+		BaseDriver:         &drivers.BaseDriver{},
+		MachineUUID:        "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+		LastCompletedPhase: "deploy",
+	}
+
+	assert.EqualError(t, driver.runCreatePhases(), "unknown create phase 'deploy' in driver state")
+}
+
+func Test_runCreatePhases_all_completed(t *testing.T) {
+	driver := &Driver{
+		BaseDriver:         &drivers.BaseDriver{},
+		MachineUUID:        "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+		LastCompletedPhase: phaseHarden,
+	}
+
+	assert.NoError(t, driver.runCreatePhases())
+}
+
+func Test_installImage_installation_in_progress(t *testing.T) {
+	statusClock = timeutils.NewManualClock(time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(func() { statusClock = timeutils.NewRealClock() })
+
+	mockFM := fmmock.NewMockFabricManager(t)
+	mockKeycloak := keycloakMock.NewMockKeycloak(t)
+	mockKeycloak.On("GetToken").Return(models.AccessTokenExample)
+	mockKeycloak.On("IsInit").Return(true)
+	mockFM.On("IsInit").Return(true)
+
+	driver := &Driver{
+		BaseDriver:    &drivers.BaseDriver{},
+		FabricManager: mockFM,
+		Keycloak:      mockKeycloak,
+		TenantUuid:    "cdi-test",
+		MachineUUID:   "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+		OsImageName:   "sles.img",
+	}
+
+	// ImageInstall is not expected
+	mockFM.On("GetMachineDetails", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "ssd", int(OS_INSTALLING), nil).Twice()
+	mockFM.On("GetMachineDetails", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "ssd", int(ACTIVE_POFF), nil).Once()
+
+	assert.NoError(t, driver.installImage())
+}
+
+func Test_configureRke2_node_labels_and_taints(t *testing.T) {
+	mockCfg := cfgMock.NewMockCfgManager(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver := &Driver{
+		CfgManager:  mockCfg,
+		SshManager:  mockSSH,
+		MachineUUID: "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f",
+		NodeLabels:  "tier=gpu, example.com/team=ml",
+		NodeTaints:  "nvidia.com/gpu=present:NoSchedule",
+	}
+
+	mockCfg.On("IsInit").Return(true)
+	mockCfg.On("PrepareRke2ConfigScript", "100-fsas-providerid", driver.MachineUUID,
+		[]string{"tier=gpu", "example.com/team=ml"}, []string{"nvidia.com/gpu=present:NoSchedule"}).Return("script")
+	mockSSH.On("ExecuteScript", "", "script", true, true).Return(nil)
+
+	assert.NoError(t, driver.configureRke2())
+}
@@ -0,0 +1,127 @@
+package fsas
+
+import (
+	"fmt"
+	"time"
+)
+
+// precedingStates CDI states from which the machine moves directly to the expected state
+var precedingStates = map[CdiMachineState][]CdiMachineState{
+	ACTIVE_POFF:   {POWERING_OFF},
+	ACTIVE_PON:    {BOOTING},
+	UNBUILDED:     {UNBUILDING, UNBUILDING_WAIT},
+	OS_INSTALLING: {ACTIVE_POFF},
+}
+
+// isNearTransition Returns true when the machine is one transition away from the expected state
+func isNearTransition(current, expected CdiMachineState) bool {
+	for _, state := range precedingStates[expected] {
+		if state == current {
+			return true
+		}
+	}
+	return false
+}
+
+// statusPoller Adapts the interval between state queries while waiting for the expected state.
+// The interval doubles up to maxInterval while the state does not change and returns to
+// baseInterval whenever the state changes or the machine is about to reach the expected state.
+type statusPoller struct {
+	expected     CdiMachineState
+	baseInterval time.Duration
+	maxInterval  time.Duration
+	interval     time.Duration
+	lastState    CdiMachineState
+}
+
+func newStatusPoller(expected CdiMachineState, baseInterval, maxInterval time.Duration) *statusPoller {
+	return &statusPoller{
+		expected:     expected,
+		baseInterval: baseInterval,
+		maxInterval:  max(baseInterval, maxInterval),
+		lastState:    None,
+	}
+}
+
+// next Returns how long to wait before the next state query given the current state
+func (p *statusPoller) next(current CdiMachineState) time.Duration {
+	if current != p.lastState || isNearTransition(current, p.expected) {
+		p.interval = p.baseInterval
+	} else {
+		p.interval = min(2*p.interval, p.maxInterval)
+	}
+	p.lastState = current
+	return p.interval
+}
+
+// durationSeconds Returns flag value given in seconds as duration, or the default when the flag is not set
+func durationSeconds(seconds int, defaultDuration time.Duration) time.Duration {
+	if seconds <= 0 {
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (d *Driver) waitStatusTimeout() time.Duration {
+	return durationSeconds(d.WaitStatusTimeout, WAIT_FOR_STATUS_TIMEOUT)
+}
+
+func (d *Driver) waitInstallTimeout() time.Duration {
+	return durationSeconds(d.WaitInstallTimeout, WAIT_FOR_STATUS_INSTALL_TIMEOUT)
+}
+
+func (d *Driver) waitStoppedTimeout() time.Duration {
+	return durationSeconds(d.WaitStoppedTimeout, WAIT_FOR_STATUS_STOPPED_TIMEOUT)
+}
+
+func (d *Driver) waitRemovedTimeout() time.Duration {
+	return durationSeconds(d.WaitRemovedTimeout, WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT)
+}
+
+func (d *Driver) waitAfterReboot() time.Duration {
+	return durationSeconds(d.WaitAfterReboot, WAIT_FOR_START_AFTER_REBOOT)
+}
+
+func (d *Driver) waitSshTimeout() time.Duration {
+	return durationSeconds(d.WaitSshTimeout, WAIT_FOR_SSH_TIMEOUT)
+}
+
+func (d *Driver) waitCloudInitTimeout() time.Duration {
+	return durationSeconds(d.WaitCloudInitTimeout, WAIT_FOR_CLOUD_INIT_TIMEOUT)
+}
+
+func (d *Driver) pollInterval() time.Duration {
+	return durationSeconds(d.PollInterval, WAIT_FOR_STATUS_STEP)
+}
+
+func (d *Driver) pollMaxInterval() time.Duration {
+	return durationSeconds(d.PollMaxInterval, WAIT_FOR_STATUS_MAX_STEP)
+}
+
+// checkWaitConfig Validate flags controlling lifecycle waits
+func (d *Driver) checkWaitConfig() error {
+	waits := []struct {
+		flag    string
+		seconds int
+	}{
+		{"--fsas-wait-status-timeout", d.WaitStatusTimeout},
+		{"--fsas-wait-install-timeout", d.WaitInstallTimeout},
+		{"--fsas-wait-stopped-timeout", d.WaitStoppedTimeout},
+		{"--fsas-wait-removed-timeout", d.WaitRemovedTimeout},
+		{"--fsas-wait-after-reboot", d.WaitAfterReboot},
+		{"--fsas-wait-ssh-timeout", d.WaitSshTimeout},
+		{"--fsas-wait-cloud-init-timeout", d.WaitCloudInitTimeout},
+		{"--fsas-poll-interval", d.PollInterval},
+		{"--fsas-poll-max-interval", d.PollMaxInterval},
+	}
+	for _, wait := range waits {
+		if wait.seconds < 0 {
+			return fmt.Errorf("%s must not be negative; got %d", wait.flag, wait.seconds)
+		}
+	}
+
+	if d.pollMaxInterval() < d.pollInterval() {
+		return fmt.Errorf("--fsas-poll-max-interval (%s) must not be shorter than --fsas-poll-interval (%s)", d.pollMaxInterval(), d.pollInterval())
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package fsas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isNearTransition(t *testing.T) {
+	assert.True(t, isNearTransition(POWERING_OFF, ACTIVE_POFF))
+	assert.True(t, isNearTransition(BOOTING, ACTIVE_PON))
+	assert.True(t, isNearTransition(UNBUILDING, UNBUILDED))
+	assert.True(t, isNearTransition(UNBUILDING_WAIT, UNBUILDED))
+	assert.True(t, isNearTransition(ACTIVE_POFF, OS_INSTALLING))
+	assert.False(t, isNearTransition(BUILDING, ACTIVE_POFF))
+	assert.False(t, isNearTransition(ACTIVE_POFF, ACTIVE_PON))
+	assert.False(t, isNearTransition(ACTIVE_PON, ERASING))
+}
+
+func Test_statusPoller_next(t *testing.T) {
+	poller := newStatusPoller(ACTIVE_PON, 5*time.Second, 30*time.Second)
+
+	steps := []struct {
+		state CdiMachineState
+		want  time.Duration
+	}{
+		{ACTIVE_POFF, 5 * time.Second},
+		{ACTIVE_POFF, 10 * time.Second},
+		{ACTIVE_POFF, 20 * time.Second},
+		{ACTIVE_POFF, 30 * time.Second},
+		{ACTIVE_POFF, 30 * time.Second},
+		// Machine is booting, the expected state is next
+		{BOOTING, 5 * time.Second},
+		{BOOTING, 5 * time.Second},
+		{ACTIVE_POFF, 5 * time.Second},
+		{ACTIVE_POFF, 10 * time.Second},
+	}
+	for _, step := range steps {
+		assert.Equal(t, step.want, poller.next(step.state), "state %s", step.state)
+	}
+}
+
+func Test_statusPoller_max_interval_shorter_than_base(t *testing.T) {
+	poller := newStatusPoller(ACTIVE_PON, 5*time.Second, time.Second)
+
+	assert.Equal(t, 5*time.Second, poller.next(ACTIVE_POFF))
+	assert.Equal(t, 5*time.Second, poller.next(ACTIVE_POFF))
+}
+
+func Test_waitDurations(t *testing.T) {
+	driver := &Driver{}
+	assert.Equal(t, WAIT_FOR_STATUS_TIMEOUT, driver.waitStatusTimeout())
+	assert.Equal(t, WAIT_FOR_STATUS_INSTALL_TIMEOUT, driver.waitInstallTimeout())
+	assert.Equal(t, WAIT_FOR_STATUS_STOPPED_TIMEOUT, driver.waitStoppedTimeout())
+	assert.Equal(t, WAIT_FOR_STATUS_NOT_FOUND_TIMEOUT, driver.waitRemovedTimeout())
+	assert.Equal(t, WAIT_FOR_START_AFTER_REBOOT, driver.waitAfterReboot())
+	assert.Equal(t, WAIT_FOR_SSH_TIMEOUT, driver.waitSshTimeout())
+	assert.Equal(t, WAIT_FOR_CLOUD_INIT_TIMEOUT, driver.waitCloudInitTimeout())
+	assert.Equal(t, WAIT_FOR_STATUS_STEP, driver.pollInterval())
+	assert.Equal(t, WAIT_FOR_STATUS_MAX_STEP, driver.pollMaxInterval())
+
+	driver = &Driver{
+		WaitStatusTimeout:    600,
+		WaitInstallTimeout:   3600,
+		WaitStoppedTimeout:   120,
+		WaitRemovedTimeout:   90,
+		WaitAfterReboot:      30,
+		WaitSshTimeout:       300,
+		WaitCloudInitTimeout: 1200,
+		PollInterval:         2,
+		PollMaxInterval:      60,
+	}
+	assert.Equal(t, 10*time.Minute, driver.waitStatusTimeout())
+	assert.Equal(t, time.Hour, driver.waitInstallTimeout())
+	assert.Equal(t, 2*time.Minute, driver.waitStoppedTimeout())
+	assert.Equal(t, 90*time.Second, driver.waitRemovedTimeout())
+	assert.Equal(t, 30*time.Second, driver.waitAfterReboot())
+	assert.Equal(t, 5*time.Minute, driver.waitSshTimeout())
+	assert.Equal(t, 20*time.Minute, driver.waitCloudInitTimeout())
+	assert.Equal(t, 2*time.Second, driver.pollInterval())
+	assert.Equal(t, time.Minute, driver.pollMaxInterval())
+}
+
+func Test_checkWaitConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  Driver
+		wantErr string
+	}{
+		{name: "defaults", driver: Driver{}},
+		{name: "custom", driver: Driver{WaitStoppedTimeout: 300, PollInterval: 10, PollMaxInterval: 10}},
+		{name: "negative timeout", driver: Driver{WaitStoppedTimeout: -1}, wantErr: "--fsas-wait-stopped-timeout must not be negative; got -1"},
+		{name: "negative cloud-init timeout", driver: Driver{WaitCloudInitTimeout: -1}, wantErr: "--fsas-wait-cloud-init-timeout must not be negative; got -1"},
+		{name: "negative poll interval", driver: Driver{PollInterval: -5}, wantErr: "--fsas-poll-interval must not be negative; got -5"},
+		{name: "max interval shorter than interval", driver: Driver{PollInterval: 10, PollMaxInterval: 5},
+			wantErr: "--fsas-poll-max-interval (5s) must not be shorter than --fsas-poll-interval (10s)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.driver.checkWaitConfig()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
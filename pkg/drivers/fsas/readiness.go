@@ -0,0 +1,85 @@
+package fsas
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/sshutils"
+)
+
+/*
+waitForReadiness Wait until the machine rebooted to apply cloud-init is ready:
+Fabric Manager reports ACTIVE_PON, the machine booted again, SSH answers with the pinned host key and cloud-init finished.
+Fabric Manager reports ACTIVE_PON during the whole OS reboot, so the boot ID read before the reboot must change
+before the other probes can trust the answers. Each step has its own timeout. Errors reported by cloud-init fail
+at once with its detailed status.
+*/
+func (d *Driver) waitForReadiness(previousBootId string) error {
+	slog.Info("Waiting for the machine to reach the Running state")
+	if err := d.waitForStatus(opReboot, d.waitAfterReboot()); err != nil {
+		return fmt.Errorf("machine did not power on after reboot: %w", err)
+	}
+
+	slog.Info("Waiting for the machine to boot again")
+	if err := waitForProbe("reboot", d.waitSshTimeout(), d.pollInterval(), d.probeRebooted(previousBootId),
+		func(error) bool { return false }); err != nil {
+		return err
+	}
+
+	// sshd of the system before the reboot still presents the host key of the OS image, so a mismatch
+	// is retried until the SSH timeout instead of failing at once
+	slog.Info("Waiting for SSH to come back with the pinned host key")
+	if err := waitForProbe("SSH", d.waitSshTimeout(), d.pollInterval(), d.SshManager.CheckHostKey,
+		func(error) bool { return false }); err != nil {
+		return err
+	}
+
+	slog.Info("Waiting for cloud-init to finish")
+	if err := waitForProbe("cloud-init", d.waitCloudInitTimeout(), d.pollInterval(), d.SshManager.WaitCloudInit,
+		func(err error) bool { return errors.Is(err, sshutils.ErrCloudInitFailed) }); err != nil {
+		return err
+	}
+
+	slog.Info("Machine is ready after reboot")
+	return nil
+}
+
+// probeRebooted Returns probe succeeding once the machine runs with a boot ID other than previousBootId
+func (d *Driver) probeRebooted(previousBootId string) func() error {
+	return func() error {
+		bootId, err := d.SshManager.BootId()
+		if err != nil {
+			return err
+		}
+		if bootId == previousBootId {
+			return errors.New("machine has not rebooted yet")
+		}
+		slog.Debug("Machine booted again: ", "boot_id", bootId)
+		return nil
+	}
+}
+
+// waitForProbe Repeat the probe until it succeeds, fails with an error which cannot go away
+// by waiting, or the timeout elapses
+func waitForProbe(step string, timeout, interval time.Duration, probe func() error, isFatal func(error) bool) error {
+	startTime := statusClock.Now()
+	for {
+		err := probe()
+		if err == nil {
+			return nil
+		}
+		if isFatal(err) {
+			slog.Error("Readiness probe failed: ", "step", step, "err", err)
+			return err
+		}
+		if statusClock.Since(startTime) >= timeout {
+			slog.Error("Readiness probe did not succeed within the specified time: ", "step", step, "timeout", timeout, "err", err)
+			return fmt.Errorf("%s not ready within %s: %w", step, timeout, err)
+		}
+
+		slog.Debug("Readiness probe not successful yet, another attempt will occur: ", "step", step, "err", err, "step_interval", interval)
+		statusClock.Sleep(interval)
+	}
+}
@@ -0,0 +1,183 @@
+package fsas
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/fujitsu/docker-machine-driver-fsas/sshutils"
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var errFatalProbe = errors.New("fatal")
+
+func isFatalProbeError(err error) bool { return errors.Is(err, errFatalProbe) }
+
+func Test_waitForProbe_retries_until_success(t *testing.T) {
+	clock := newManualClock(t)
+	start := clock.Now()
+	attempts := 0
+
+	err := waitForProbe("SSH", time.Minute, 5*time.Second, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, isFatalProbeError)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 10*time.Second, clock.Since(start))
+}
+
+func Test_waitForProbe_fatal_error(t *testing.T) {
+	newManualClock(t)
+	attempts := 0
+
+	err := waitForProbe("SSH", time.Minute, 5*time.Second, func() error {
+		attempts++
+		return fmt.Errorf("probe: %w", errFatalProbe)
+	}, isFatalProbeError)
+
+	assert.ErrorIs(t, err, errFatalProbe)
+	assert.Equal(t, 1, attempts)
+}
+
+func Test_waitForProbe_timeout(t *testing.T) {
+	newManualClock(t)
+
+	err := waitForProbe("cloud-init", 30*time.Second, 10*time.Second, func() error {
+		return errors.New("connection refused")
+	}, isFatalProbeError)
+
+	assert.EqualError(t, err, "cloud-init not ready within 30s: connection refused")
+}
+
+func Test_waitForReadiness(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	mockSSH.On("CheckHostKey").Return(errors.New("connection refused")).Twice()
+	mockSSH.On("CheckHostKey").Return(nil).Once()
+	mockSSH.On("WaitCloudInit").Return(errors.New("ssh: unexpected EOF")).Once()
+	mockSSH.On("WaitCloudInit").Return(nil).Once()
+
+	assert.NoError(t, driver.waitForReadiness("boot-1"))
+}
+
+func Test_waitForReadiness_host_key_mismatch_retried(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	// sshd from before the reboot still presents the host key of the OS image
+	mockSSH.On("CheckHostKey").Return(fmt.Errorf("failed to dial SSH server: %w", sshutils.ErrHostKeyMismatch)).Once()
+	mockSSH.On("CheckHostKey").Return(nil).Once()
+	mockSSH.On("WaitCloudInit").Return(nil).Once()
+
+	assert.NoError(t, driver.waitForReadiness("boot-1"))
+	mockSSH.AssertNumberOfCalls(t, "CheckHostKey", 2)
+}
+
+func Test_waitForReadiness_host_key_mismatch_timeout(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	driver.WaitSshTimeout = 20
+	mockSSH.On("CheckHostKey").Return(fmt.Errorf("failed to dial SSH server: %w", sshutils.ErrHostKeyMismatch))
+
+	err := driver.waitForReadiness("boot-1")
+
+	assert.ErrorIs(t, err, sshutils.ErrHostKeyMismatch)
+	assert.ErrorContains(t, err, "SSH not ready within 20s")
+	mockSSH.AssertNotCalled(t, "WaitCloudInit")
+}
+
+func Test_waitForReadiness_cloud_init_errors(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	mockSSH.On("CheckHostKey").Return(nil)
+	mockSSH.On("WaitCloudInit").Return(fmt.Errorf("%w:\nstatus: error\nerrors:\n\t- runcmd failed", sshutils.ErrCloudInitFailed)).Once()
+
+	err := driver.waitForReadiness("boot-1")
+
+	assert.ErrorIs(t, err, sshutils.ErrCloudInitFailed)
+	assert.ErrorContains(t, err, "runcmd failed")
+}
+
+func Test_waitForReadiness_ssh_timeout(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	driver.WaitSshTimeout = 20
+	mockSSH.On("CheckHostKey").Return(errors.New("connection refused"))
+
+	err := driver.waitForReadiness("boot-1")
+
+	assert.EqualError(t, err, "SSH not ready within 20s: connection refused")
+	mockSSH.AssertNotCalled(t, "WaitCloudInit")
+}
+
+func Test_waitForReadiness_power_on_fails(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(UNBUILDED), nil)
+
+	err := driver.waitForReadiness("boot-1")
+
+	assert.ErrorContains(t, err, "machine did not power on after reboot: machine in state UNBUILDED")
+	mockSSH.AssertNotCalled(t, "CheckHostKey")
+}
+
+func Test_waitForReadiness_waits_for_reboot(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	// Fabric Manager reports ACTIVE_PON while the OS is still rebooting
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-1", nil).Once()
+	mockSSH.On("BootId").Return("", errors.New("connection refused")).Once()
+	mockSSH.On("BootId").Return("boot-2", nil).Once()
+	mockSSH.On("CheckHostKey").Return(nil).Once()
+	mockSSH.On("WaitCloudInit").Return(nil).Once()
+
+	assert.NoError(t, driver.waitForReadiness("boot-1"))
+	mockSSH.AssertNumberOfCalls(t, "BootId", 3)
+}
+
+func Test_waitForReadiness_not_rebooted(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	mockSSH := sshMock.NewMockSshManager(t)
+	driver.SshManager = mockSSH
+	driver.WaitSshTimeout = 20
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(nil, "", int(ACTIVE_PON), nil)
+	mockSSH.On("BootId").Return("boot-1", nil)
+
+	err := driver.waitForReadiness("boot-1")
+
+	assert.EqualError(t, err, "reboot not ready within 20s: machine has not rebooted yet")
+	mockSSH.AssertNotCalled(t, "CheckHostKey")
+}
@@ -0,0 +1,51 @@
+package fsas
+
+import (
+	"fmt"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Reimage Reinstall the operating system of the composed machine in place.
+// Unlike Remove followed by Create, the Fabric Manager machine and its hardware are kept, so the node
+// stays on the same server with the same MachineUUID. The machine is powered off, the OS image is
+// installed again on its boot storage and the Create phases following the installation are repeated.
+func (d *Driver) Reimage() error {
+	slog.Info("Attempting to reimage host: ", "machineName", d.MachineName, "machineUUID", d.MachineUUID)
+
+	if d.MachineUUID == "" {
+		slog.Error("Machine's UUID was unexpectedly empty: ", "machine_name", d.MachineName)
+		return fmt.Errorf("machine uuid is empty")
+	}
+
+	if err := d.initClients(); err != nil {
+		return err
+	}
+
+	// Registration of the OS being wiped would otherwise stay occupied
+	d.deregisterOS()
+
+	cdiState, err := d.getCdiState()
+	if err != nil {
+		return err
+	}
+	if cdiState != ACTIVE_POFF && cdiState != OS_INSTALLING {
+		if err := d.stopWithEscalation(); err != nil {
+			slog.Error("Could not power off machine for reimage: ", "machineUUID", d.MachineUUID, "err", err)
+			return err
+		}
+	}
+
+	// The image installed again comes with its own host key
+	if err := d.unpinHostKey(); err != nil {
+		return err
+	}
+
+	phases := d.createPhases()
+	if err := d.runPhases(phases[phaseIndex(phases, phaseImageInstall):]); err != nil {
+		return fmt.Errorf("cannot reimage machine %s: %w", d.MachineUUID, err)
+	}
+
+	slog.Info("Successfully reimaged Machine: ", "machineUUID", d.MachineUUID)
+	return nil
+}
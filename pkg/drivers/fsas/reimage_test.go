@@ -0,0 +1,58 @@
+package fsas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	sshMock "github.com/fujitsu/docker-machine-driver-fsas/sshutils/mock"
+	"github.com/rancher/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReimage_empty_machine_uuid(t *testing.T) {
+	driver := &Driver{BaseDriver: &drivers.BaseDriver{MachineName: "node-01"}}
+
+	assert.EqualError(t, driver.Reimage(), "machine uuid is empty")
+}
+
+func TestReimage_stop_fails(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	driver.StopEscalation = "poweroff"
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("IsInit").Return(true)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(errors.New("SUSEConnect: not registered"))
+	driver.SshManager = mockSSH
+	driver.BaseDriver = &drivers.BaseDriver{MachineName: "node-01"}
+
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(ACTIVE_PON), nil)
+	mockFM.On("PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(errors.New("BMC not reachable"))
+
+	err := driver.Reimage()
+
+	assert.EqualError(t, err, "machine ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f did not stop after steps poweroff: BMC not reachable")
+	mockFM.AssertNotCalled(t, "ImageInstall")
+}
+
+func TestReimage_image_install_fails(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	driver.StopEscalation = "poweroff"
+	mockSSH := sshMock.NewMockSshManager(t)
+	mockSSH.On("IsInit").Return(true)
+	mockSSH.On("DeregisterOS", mock.Anything).Return(nil)
+	driver.SshManager = mockSSH
+	driver.BaseDriver = &drivers.BaseDriver{MachineName: "node-01"}
+	driver.OsImageName = "sles.img"
+
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "boot-ssd", int(ACTIVE_POFF), nil)
+	mockFM.On("ImageInstall", driver.TenantUuid, "boot-ssd", "sles.img", models.AccessTokenExample).Return(errors.New("image not found"))
+
+	err := driver.Reimage()
+
+	assert.EqualError(t, err, "cannot reimage machine ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f: image not found")
+	mockFM.AssertNotCalled(t, "PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample)
+	assert.Equal(t, "ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f", driver.MachineUUID)
+}
@@ -0,0 +1,53 @@
+package fsas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/sshutils"
+)
+
+// readRmtCaCert Returns CA certificate of the RMT server given inline in PEM or as a path to a file
+func (d *Driver) readRmtCaCert() (string, error) {
+	if d.SlesRmtCaCert == "" || strings.HasPrefix(d.SlesRmtCaCert, "-----BEGIN") {
+		return d.SlesRmtCaCert, nil
+	}
+
+	content, err := osReadFile(d.SlesRmtCaCert)
+	if err != nil {
+		return "", fmt.Errorf("cannot read RMT CA certificate: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+/*
+checkRmtConfig Verify RMT server parameters. A CA certificate given as a path is replaced with its content,
+so it does not have to be present when the node is registered
+*/
+func (d *Driver) checkRmtConfig() error {
+	if d.SlesRmtUrl == "" {
+		if d.SlesRmtCaCert != "" || d.SlesRmtCaFingerprint != "" {
+			return fmt.Errorf("RMT CA certificate and fingerprint require RMT server URL. Fill in param %s", "--fsas-sles-rmt-url")
+		}
+		return nil
+	}
+
+	if d.OsRegistrar != sshutils.OS_REGISTRAR_SUSECONNECT && d.OsRegistrar != sshutils.OS_REGISTRAR_AUTO {
+		return fmt.Errorf("RMT server can only be used with %s '%s' or '%s'", "--fsas-os-registrar", sshutils.OS_REGISTRAR_SUSECONNECT, sshutils.OS_REGISTRAR_AUTO)
+	}
+
+	if err := sshutils.ValidateRmtUrl(d.SlesRmtUrl); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-sles-rmt-url", err)
+	}
+
+	caCert, err := d.readRmtCaCert()
+	if err != nil || caCert == "" {
+		return err
+	}
+
+	if err := sshutils.VerifyCaCertFingerprint(caCert, d.SlesRmtCaFingerprint); err != nil {
+		return fmt.Errorf("invalid %s: %w", "--fsas-sles-rmt-ca-cert", err)
+	}
+	d.SlesRmtCaCert = caCert
+	return nil
+}
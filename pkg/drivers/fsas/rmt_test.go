@@ -0,0 +1,104 @@
+package fsas
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/sshutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRmtCaCert Returns self-signed PEM CA certificate with its SHA-256 fingerprint
+func testRmtCaCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RMT Certificate Authority"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(der)
+	caCert := strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	return caCert, hex.EncodeToString(sum[:])
+}
+
+func Test_checkRmtConfig_none(t *testing.T) {
+	driver := &Driver{OsRegistrar: sshutils.OS_REGISTRAR_SUSECONNECT}
+
+	assert.NoError(t, driver.checkRmtConfig())
+}
+
+func Test_checkRmtConfig_caCertFromPath(t *testing.T) {
+	caCert, fingerprint := testRmtCaCert(t)
+	mockOsReadFile(t, map[string]string{"/etc/rancher/rmt-ca.pem": caCert + "\n"})
+	driver := &Driver{
+		OsRegistrar:          sshutils.OS_REGISTRAR_AUTO,
+		SlesRmtUrl:           "https://rmt.example.com",
+		SlesRmtCaCert:        "/etc/rancher/rmt-ca.pem",
+		SlesRmtCaFingerprint: fingerprint,
+	}
+
+	err := driver.checkRmtConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, caCert, driver.SlesRmtCaCert)
+	assert.Equal(t, caCert, driver.osRegistration().ServerCaCert)
+	assert.Equal(t, "https://rmt.example.com", driver.osRegistration().ServerUrl)
+}
+
+func Test_checkRmtConfig_fail(t *testing.T) {
+	caCert, _ := testRmtCaCert(t)
+	mockOsReadFile(t, map[string]string{})
+
+	testCases := []struct {
+		name     string
+		driver   *Driver
+		expected string
+	}{
+		{name: "CA without URL",
+			driver:   &Driver{OsRegistrar: sshutils.OS_REGISTRAR_SUSECONNECT, SlesRmtCaCert: caCert},
+			expected: "Fill in param --fsas-sles-rmt-url",
+		},
+		{name: "registrar other than SUSEConnect",
+			driver:   &Driver{OsRegistrar: sshutils.OS_REGISTRAR_PRO, SlesRmtUrl: "https://rmt.example.com"},
+			expected: "RMT server can only be used with --fsas-os-registrar 'suseconnect' or 'auto'",
+		},
+		{name: "invalid URL",
+			driver:   &Driver{OsRegistrar: sshutils.OS_REGISTRAR_SUSECONNECT, SlesRmtUrl: "rmt.example.com"},
+			expected: "invalid --fsas-sles-rmt-url",
+		},
+		{name: "missing CA file",
+			driver:   &Driver{OsRegistrar: sshutils.OS_REGISTRAR_SUSECONNECT, SlesRmtUrl: "https://rmt.example.com", SlesRmtCaCert: "/missing.pem"},
+			expected: "cannot read RMT CA certificate",
+		},
+		{name: "fingerprint mismatch",
+			driver: &Driver{OsRegistrar: sshutils.OS_REGISTRAR_SUSECONNECT, SlesRmtUrl: "https://rmt.example.com",
+				SlesRmtCaCert: caCert, SlesRmtCaFingerprint: strings.Repeat("0", 64)},
+			expected: sshutils.ErrCaFingerprintMismatch.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorContains(t, tc.driver.checkRmtConfig(), tc.expected)
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package fsas
 
+import (
+	"fmt"
+
+	"github.com/rancher/machine/libmachine/state"
+)
+
 // State represents the state of the FSAS host
 type CdiMachineState int
 
@@ -21,38 +27,53 @@ const (
 	ERROR                 CdiMachineState = 90
 )
 
+// cdiStateInfo describes a CDI machine state known to the driver
+type cdiStateInfo struct {
+	name    string
+	rancher state.State // State reported to Rancher
+}
+
+// cdiStates Every CDI machine state known to the driver and the Rancher state it is reported as
+var cdiStates = map[CdiMachineState]cdiStateInfo{
+	BUILDING_BEFORE_QUEUE: {name: "BUILDING_BEFORE_QUEUE", rancher: state.Starting},
+	BUILDING:              {name: "BUILDING", rancher: state.Starting},
+	BOOTING:               {name: "BOOTING", rancher: state.Starting},
+	ACTIVE_PON:            {name: "ACTIVE_PON", rancher: state.Running},
+	POWERING_OFF:          {name: "POWERING_OFF", rancher: state.Stopping},
+	ACTIVE_POFF:           {name: "ACTIVE_POFF", rancher: state.Stopped},
+	UNBUILDING:            {name: "UNBUILDING", rancher: state.Stopped},
+	UNBUILDED:             {name: "UNBUILDED", rancher: state.Stopped},
+	OS_INSTALLING:         {name: "OS_INSTALLING", rancher: state.Starting},  // Machine is still being provisioned
+	ERASING:               {name: "ERASING", rancher: state.Stopping},        // Disks are erased before decomposition
+	ADDING_RESOURCE:       {name: "ADDING_RESOURCE", rancher: state.Stopped}, // Resources are changed on a powered off machine
+	DELETING_RESOURCE:     {name: "DELETING_RESOURCE", rancher: state.Stopped},
+	UNBUILDING_WAIT:       {name: "UNBUILDING_WAIT", rancher: state.Stopped},
+	ERROR:                 {name: "ERROR", rancher: state.Error},
+}
+
 // Given a State type, returns its string representation
 func (s CdiMachineState) String() string {
-	switch s {
-	case BUILDING_BEFORE_QUEUE:
-		return "BUILDING_BEFORE_QUEUE"
-	case BUILDING:
-		return "BUILDING"
-	case BOOTING:
-		return "BOOTING"
-	case ACTIVE_PON:
-		return "ACTIVE_PON"
-	case POWERING_OFF:
-		return "POWERING_OFF"
-	case ACTIVE_POFF:
-		return "ACTIVE_POFF"
-	case UNBUILDING:
-		return "UNBUILDING"
-	case UNBUILDED:
-		return "UNBUILDED"
-	case OS_INSTALLING:
-		return "OS_INSTALLING"
-	case ERASING:
-		return "ERASING"
-	case ADDING_RESOURCE:
-		return "ADDING_RESOURCE"
-	case DELETING_RESOURCE:
-		return "DELETING_RESOURCE"
-	case UNBUILDING_WAIT:
-		return "UNBUILDING_WAIT"
-	case ERROR:
-		return "ERROR"
-	default:
-		return ""
+	return cdiStates[s].name
+}
+
+// RancherState Returns Rancher state the CDI state is reported as; false for codes unknown to the driver
+func (s CdiMachineState) RancherState() (state.State, bool) {
+	info, ok := cdiStates[s]
+	if !ok {
+		return state.None, false
 	}
+	return info.rancher, true
+}
+
+// ExtendedState holds Rancher state of the host together with the raw CDI state it was mapped from
+type ExtendedState struct {
+	State        state.State
+	CdiState     CdiMachineState
+	OpStatus     string // Fabric Manager's mach_op_status
+	StatusDetail string // Fabric Manager's mach_status_detail
+}
+
+func (s ExtendedState) String() string {
+	return fmt.Sprintf("%s (CDI state %s, op status '%s', status detail '%s')",
+		s.State, describeState(s.CdiState), s.OpStatus, s.StatusDetail)
 }
@@ -0,0 +1,110 @@
+package fsas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/rancher/machine/libmachine/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCdiMachineState_RancherState(t *testing.T) {
+	tests := []struct {
+		cdiState CdiMachineState
+		name     string
+		want     state.State
+	}{
+		{BUILDING_BEFORE_QUEUE, "BUILDING_BEFORE_QUEUE", state.Starting},
+		{BUILDING, "BUILDING", state.Starting},
+		{BOOTING, "BOOTING", state.Starting},
+		{ACTIVE_PON, "ACTIVE_PON", state.Running},
+		{POWERING_OFF, "POWERING_OFF", state.Stopping},
+		{ACTIVE_POFF, "ACTIVE_POFF", state.Stopped},
+		{UNBUILDING, "UNBUILDING", state.Stopped},
+		{UNBUILDED, "UNBUILDED", state.Stopped},
+		{OS_INSTALLING, "OS_INSTALLING", state.Starting},
+		{ERASING, "ERASING", state.Stopping},
+		{ADDING_RESOURCE, "ADDING_RESOURCE", state.Stopped},
+		{DELETING_RESOURCE, "DELETING_RESOURCE", state.Stopped},
+		{UNBUILDING_WAIT, "UNBUILDING_WAIT", state.Stopped},
+		{ERROR, "ERROR", state.Error},
+	}
+	assert.Len(t, tests, len(cdiStates), "every known CDI state must be tested")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.cdiState.String())
+			got, known := tt.cdiState.RancherState()
+			assert.True(t, known)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, (&Driver{}).mapMachineStatusToState(tt.cdiState))
+		})
+	}
+}
+
+func TestCdiMachineState_RancherState_unknown(t *testing.T) {
+	for _, code := range []CdiMachineState{None, 22, 99} {
+		got, known := code.RancherState()
+		assert.False(t, known)
+		assert.Equal(t, state.None, got)
+		assert.Empty(t, code.String())
+	}
+}
+
+func TestExtendedState_String(t *testing.T) {
+	extendedState := ExtendedState{State: state.Stopped, CdiState: ADDING_RESOURCE, OpStatus: "running", StatusDetail: "adding gpu"}
+
+	assert.Equal(t, "Stopped (CDI state ADDING_RESOURCE (20), op status 'running', status detail 'adding gpu')", extendedState.String())
+}
+
+func TestGetExtendedState(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{
+		MachineUUID:         driver.MachineUUID,
+		MachineStatus:       int(OS_INSTALLING),
+		MachineOpStatus:     "running",
+		MachineStatusDetail: "writing image",
+	}, nil)
+
+	observed, err := driver.GetExtendedState()
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExtendedState{State: state.Starting, CdiState: OS_INSTALLING, OpStatus: "running", StatusDetail: "writing image"}, observed)
+}
+
+func TestGetExtendedState_unknown_status(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(&models.MachineDetails{
+		MachineUUID:         driver.MachineUUID,
+		MachineStatus:       987,
+		MachineStatusDetail: "firmware update",
+	}, nil)
+
+	observed, err := driver.GetExtendedState()
+
+	assert.NoError(t, err)
+	assert.Equal(t, state.None, observed.State)
+	assert.Equal(t, CdiMachineState(987), observed.CdiState)
+	assert.Equal(t, "firmware update", observed.StatusDetail)
+}
+
+func TestGetExtendedState_error(t *testing.T) {
+	driver, mockFM := newMockedDriver(t)
+	mockFM.On("GetMachine", "cdi-test", driver.MachineUUID, models.AccessTokenExample).Return(nil, errors.New("request failed"))
+
+	observed, err := driver.GetExtendedState()
+
+	assert.EqualError(t, err, "request failed")
+	assert.Equal(t, state.Error, observed.State)
+}
+
+func TestGetExtendedState_empty_machine_uuid(t *testing.T) {
+	driver, _ := newMockedDriver(t)
+	driver.MachineUUID = ""
+
+	observed, err := driver.GetExtendedState()
+
+	assert.EqualError(t, err, "machine uuid is empty")
+	assert.Equal(t, state.Error, observed.State)
+}
@@ -0,0 +1,101 @@
+package fsas
+
+import (
+	"fmt"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Steps of Stop escalation in the only order they may be configured
+const (
+	stopStepGraceful = "graceful"
+	stopStepSsh      = "ssh"
+	stopStepPowerOff = "poweroff"
+)
+
+var stopSteps = []string{stopStepGraceful, stopStepSsh, stopStepPowerOff}
+
+const defaultStopEscalation = stopStepGraceful + "," + stopStepPowerOff
+
+// parseStopEscalation Returns steps of Stop escalation listed in comma separated value
+func parseStopEscalation(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		value = defaultStopEscalation
+	}
+
+	var steps []string
+	last := -1
+	for _, step := range strings.Split(value, ",") {
+		step = strings.TrimSpace(step)
+		idx := -1
+		for i, known := range stopSteps {
+			if known == step {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown stop escalation step '%s'; allowed steps in order: %s", step, strings.Join(stopSteps, ","))
+		}
+		if idx <= last {
+			return nil, fmt.Errorf("stop escalation steps must be unique and ordered as %s; got '%s'", strings.Join(stopSteps, ","), value)
+		}
+		last = idx
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// runStopStep Request the machine to stop in the way of given step
+func (d *Driver) runStopStep(step string) error {
+	switch step {
+	case stopStepGraceful:
+		slog.Info("requesting graceful shutdown for machine: ", "machine_uuid", d.MachineUUID)
+		return d.FabricManager.GracefulShutdown(d.MachineUUID, d.TenantUuid, d.Keycloak.GetToken())
+	case stopStepSsh:
+		if err := d.initSshManager(); err != nil {
+			return err
+		}
+		slog.Info("requesting OS shutdown over SSH for machine: ", "machine_uuid", d.MachineUUID)
+		// Connection is usually dropped by the shutting down OS, the wait below tells whether it worked
+		if err := d.SshManager.ShutdownOS(); err != nil {
+			slog.Warn("OS shutdown over SSH reported an error, waiting for the machine anyway: ", "err", err)
+		}
+		return nil
+	case stopStepPowerOff:
+		slog.Info("requesting power off for machine: ", "machine_uuid", d.MachineUUID)
+		return d.FabricManager.PowerOff(d.MachineUUID, d.TenantUuid, d.Keycloak.GetToken())
+	default:
+		return fmt.Errorf("unknown stop escalation step '%s'", step)
+	}
+}
+
+// stopWithEscalation Run the configured steps until one of them powers the machine off.
+// Every step gets the grace period set by --fsas-wait-stopped-timeout.
+func (d *Driver) stopWithEscalation() error {
+	steps, err := parseStopEscalation(d.StopEscalation)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, step := range steps {
+		if err := d.runStopStep(step); err != nil {
+			slog.Warn("Stop step failed, escalating: ", "step", step, "machine_uuid", d.MachineUUID, "err", err)
+			lastErr = err
+			continue
+		}
+
+		slog.Info("Waiting for status: ", "status", ACTIVE_POFF, "step", step, "grace_period", d.waitStoppedTimeout())
+		if err := d.waitForStatus(opShutdown, d.waitStoppedTimeout()); err != nil {
+			slog.Warn("Machine did not stop after step, escalating: ", "step", step, "machine_uuid", d.MachineUUID, "err", err)
+			lastErr = err
+			continue
+		}
+
+		slog.Info("Machine stopped by step: ", "step", step, "machine_uuid", d.MachineUUID)
+		return nil
+	}
+
+	return fmt.Errorf("machine %s did not stop after steps %s: %w", d.MachineUUID, strings.Join(steps, ","), lastErr)
+}
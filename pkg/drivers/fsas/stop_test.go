@@ -0,0 +1,86 @@
+package fsas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseStopEscalation(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "", want: []string{"graceful", "poweroff"}},
+		{value: "graceful", want: []string{"graceful"}},
+		{value: "graceful, ssh, poweroff", want: []string{"graceful", "ssh", "poweroff"}},
+		{value: "ssh,poweroff", want: []string{"ssh", "poweroff"}},
+		{value: "poweroff", want: []string{"poweroff"}},
+		{value: "graceful,reboot", wantErr: "unknown stop escalation step 'reboot'; allowed steps in order: graceful,ssh,poweroff"},
+		{value: "poweroff,graceful", wantErr: "stop escalation steps must be unique and ordered as graceful,ssh,poweroff; got 'poweroff,graceful'"},
+		{value: "graceful,graceful", wantErr: "stop escalation steps must be unique and ordered as graceful,ssh,poweroff; got 'graceful,graceful'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseStopEscalation(tt.value)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_stopWithEscalation_graceful_request_fails(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	driver.StopEscalation = "graceful,poweroff"
+
+	mockFM.On("GracefulShutdown", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(errors.New("graceful shutdown not supported"))
+	mockFM.On("PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(ACTIVE_POFF), nil)
+
+	assert.NoError(t, driver.stopWithEscalation())
+	mockFM.AssertCalled(t, "PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample)
+}
+
+func Test_stopWithEscalation_graceful_stops_machine(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	driver.StopEscalation = "graceful,poweroff"
+
+	mockFM.On("GracefulShutdown", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(POWERING_OFF), nil).Once()
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(ACTIVE_POFF), nil).Once()
+
+	assert.NoError(t, driver.stopWithEscalation())
+	mockFM.AssertNotCalled(t, "PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample)
+}
+
+func Test_stopWithEscalation_all_steps_fail(t *testing.T) {
+	newManualClock(t)
+	driver, mockFM := newMockedDriver(t)
+	driver.StopEscalation = "graceful,poweroff"
+
+	mockFM.On("GracefulShutdown", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(nil)
+	mockFM.On("PowerOff", driver.MachineUUID, driver.TenantUuid, models.AccessTokenExample).Return(errors.New("BMC not reachable"))
+	mockFM.On("GetMachineDetails", driver.TenantUuid, driver.MachineUUID, models.AccessTokenExample).Return(models.ExpectedLanports, "", int(ACTIVE_PON), nil)
+
+	err := driver.stopWithEscalation()
+
+	assert.EqualError(t, err, "machine ff3a4a18-1ef9-4e17-9c8d-eec35b3c638f did not stop after steps graceful,poweroff: BMC not reachable")
+}
+
+func Test_stopWithEscalation_invalid_policy(t *testing.T) {
+	newManualClock(t)
+	driver, _ := newMockedDriver(t)
+	driver.StopEscalation = "reboot"
+
+	assert.ErrorContains(t, driver.stopWithEscalation(), "unknown stop escalation step 'reboot'")
+}
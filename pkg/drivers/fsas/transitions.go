@@ -0,0 +1,103 @@
+package fsas
+
+import (
+	"fmt"
+	"time"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// machineOperation describes the CDI state an operation waits for and the states the machine
+// may pass through on the way. Any other state cannot lead to the target, so waiting stops at once.
+type machineOperation struct {
+	name   string
+	target CdiMachineState
+	via    []CdiMachineState
+}
+
+var (
+	// Composition ends powered off; Fabric Manager may boot the machine to discover added resources
+	opCreate = machineOperation{
+		name:   "create",
+		target: ACTIVE_POFF,
+		via:    []CdiMachineState{BUILDING_BEFORE_QUEUE, BUILDING, ADDING_RESOURCE, BOOTING, ACTIVE_PON, POWERING_OFF},
+	}
+	opInstallStart = machineOperation{
+		name:   "install start",
+		target: OS_INSTALLING,
+		via:    []CdiMachineState{ACTIVE_POFF},
+	}
+	// The installer boots the machine and powers it off when done
+	opInstall = machineOperation{
+		name:   "install",
+		target: ACTIVE_POFF,
+		via:    []CdiMachineState{OS_INSTALLING, BOOTING, ACTIVE_PON, POWERING_OFF},
+	}
+	opPowerOn = machineOperation{
+		name:   "power on",
+		target: ACTIVE_PON,
+		via:    []CdiMachineState{ACTIVE_POFF, BOOTING},
+	}
+	// OS reboot may pass through power off and boot, depending on the firmware
+	opReboot = machineOperation{
+		name:   "reboot",
+		target: ACTIVE_PON,
+		via:    []CdiMachineState{POWERING_OFF, ACTIVE_POFF, BOOTING},
+	}
+	opShutdown = machineOperation{
+		name:   "shutdown",
+		target: ACTIVE_POFF,
+		via:    []CdiMachineState{ACTIVE_PON, BOOTING, POWERING_OFF},
+	}
+	// Machine may be removed from any state
+	opRemove = machineOperation{
+		name:   "remove",
+		target: UNBUILDED,
+		via: []CdiMachineState{BUILDING_BEFORE_QUEUE, BUILDING, BOOTING, ACTIVE_PON, POWERING_OFF, ACTIVE_POFF,
+			UNBUILDING, OS_INSTALLING, ERASING, ADDING_RESOURCE, DELETING_RESOURCE, UNBUILDING_WAIT},
+	}
+)
+
+// allows Returns true when the machine in given state may still reach the target of the operation.
+// Codes unknown to the driver are allowed, they may be transitional states of a newer Fabric Manager.
+func (op machineOperation) allows(state CdiMachineState) bool {
+	if state == op.target || state.String() == "" {
+		return true
+	}
+	for _, via := range op.via {
+		if via == state {
+			return true
+		}
+	}
+	return false
+}
+
+// describeState Returns name and code of the state, e.g. "ACTIVE_PON (13)"
+func describeState(state CdiMachineState) string {
+	if state.String() == "" {
+		return fmt.Sprintf("UNKNOWN (%d)", int(state))
+	}
+	return fmt.Sprintf("%s (%d)", state, int(state))
+}
+
+// stateTimeline Logs how long the machine stayed in each state observed during a wait
+type stateTimeline struct {
+	operation string
+	state     CdiMachineState
+	since     time.Time
+	observed  bool
+}
+
+// observe Record state seen at given time and log the duration of the previous state when it changed
+func (t *stateTimeline) observe(state CdiMachineState, now time.Time) {
+	if t.observed && state == t.state {
+		return
+	}
+	if t.observed {
+		slog.Info("Machine state changed: ", "operation", t.operation,
+			"from", describeState(t.state), "to", describeState(state), "lasted", now.Sub(t.since))
+	}
+	t.state = state
+	t.since = now
+	t.observed = true
+}
@@ -0,0 +1,81 @@
+package fsas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_machineOperation_allows(t *testing.T) {
+	tests := []struct {
+		op        machineOperation
+		allowed   []CdiMachineState
+		forbidden []CdiMachineState
+	}{
+		{
+			op:        opCreate,
+			allowed:   []CdiMachineState{BUILDING_BEFORE_QUEUE, BUILDING, ADDING_RESOURCE, BOOTING, ACTIVE_PON, POWERING_OFF, ACTIVE_POFF},
+			forbidden: []CdiMachineState{UNBUILDING, UNBUILDED, UNBUILDING_WAIT, ERASING, DELETING_RESOURCE, OS_INSTALLING},
+		},
+		{
+			op:        opInstallStart,
+			allowed:   []CdiMachineState{ACTIVE_POFF, OS_INSTALLING},
+			forbidden: []CdiMachineState{ACTIVE_PON, BOOTING, UNBUILDING, UNBUILDED, BUILDING},
+		},
+		{
+			op:        opInstall,
+			allowed:   []CdiMachineState{OS_INSTALLING, BOOTING, ACTIVE_PON, POWERING_OFF, ACTIVE_POFF},
+			forbidden: []CdiMachineState{UNBUILDING, UNBUILDED, ERASING, BUILDING},
+		},
+		{
+			op:        opPowerOn,
+			allowed:   []CdiMachineState{ACTIVE_POFF, BOOTING, ACTIVE_PON},
+			forbidden: []CdiMachineState{POWERING_OFF, UNBUILDING, UNBUILDED, OS_INSTALLING, ERASING},
+		},
+		{
+			op:        opShutdown,
+			allowed:   []CdiMachineState{ACTIVE_PON, BOOTING, POWERING_OFF, ACTIVE_POFF},
+			forbidden: []CdiMachineState{UNBUILDING, UNBUILDED, OS_INSTALLING, BUILDING},
+		},
+		{
+			op: opRemove,
+			allowed: []CdiMachineState{BUILDING_BEFORE_QUEUE, BUILDING, BOOTING, ACTIVE_PON, POWERING_OFF, ACTIVE_POFF,
+				UNBUILDING, UNBUILDED, OS_INSTALLING, ERASING, ADDING_RESOURCE, DELETING_RESOURCE, UNBUILDING_WAIT},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op.name, func(t *testing.T) {
+			for _, state := range tt.allowed {
+				assert.True(t, tt.op.allows(state), "state %s", state)
+			}
+			for _, state := range tt.forbidden {
+				assert.False(t, tt.op.allows(state), "state %s", state)
+			}
+			// Codes unknown to the driver never fail the wait
+			assert.True(t, tt.op.allows(CdiMachineState(99)))
+		})
+	}
+}
+
+func Test_describeState(t *testing.T) {
+	assert.Equal(t, "ACTIVE_PON (13)", describeState(ACTIVE_PON))
+	assert.Equal(t, "UNKNOWN (99)", describeState(CdiMachineState(99)))
+}
+
+func Test_stateTimeline_observe(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	timeline := stateTimeline{operation: "power on"}
+
+	timeline.observe(ACTIVE_POFF, start)
+	assert.Equal(t, ACTIVE_POFF, timeline.state)
+	assert.Equal(t, start, timeline.since)
+
+	timeline.observe(ACTIVE_POFF, start.Add(5*time.Second))
+	assert.Equal(t, start, timeline.since)
+
+	timeline.observe(BOOTING, start.Add(10*time.Second))
+	assert.Equal(t, BOOTING, timeline.state)
+	assert.Equal(t, start.Add(10*time.Second), timeline.since)
+}
@@ -0,0 +1,110 @@
+package fsas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/cfgutils"
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+)
+
+// Sources of user-data parts in the order of increasing precedence
+const (
+	userDataSourceDriver  = "driver"
+	userDataSourceCustom  = "custom"
+	userDataSourceRancher = "rancher"
+)
+
+// driverUserData Cloud-config generated by the driver. Password login is disabled by the harden phase of
+// Create anyway, doing it already at boot closes the window until then.
+const driverUserData = `#cloud-config
+ssh_pwauth: false
+`
+
+// customUserDataInline Returns true when user's own user-data is given inline instead of a path to a file
+func (d *Driver) customUserDataInline() bool {
+	return strings.HasPrefix(strings.TrimSpace(d.CustomUserData), "#")
+}
+
+// customUserDataForLog Returns path of user's own user-data or, when given inline, its content with secrets removed
+func (d *Driver) customUserDataForLog() string {
+	if !d.customUserDataInline() {
+		return d.CustomUserData
+	}
+	return cfgutils.RedactUserData(d.CustomUserData)
+}
+
+// readCustomUserData Returns user's own user-data given inline or as a path to a file
+func (d *Driver) readCustomUserData() (string, error) {
+	if d.CustomUserData == "" || d.customUserDataInline() {
+		return d.CustomUserData, nil
+	}
+
+	content, err := osReadFile(d.CustomUserData)
+	if err != nil {
+		return "", fmt.Errorf("cannot read custom user-data: %w", err)
+	}
+	return string(content), nil
+}
+
+// checkCustomUserData Verify that user's own user-data can be merged
+func (d *Driver) checkCustomUserData() error {
+	customUserData, err := d.readCustomUserData()
+	if err != nil || customUserData == "" {
+		return err
+	}
+
+	_, err = cfgutils.MergeUserData(cfgutils.UserDataPart{Source: userDataSourceCustom, Content: customUserData})
+	return err
+}
+
+/*
+prepareUserData Returns user-data uploaded to the machine or empty string when there is none.
+Driver generated cloud-config, user's own user-data (--fsas-custom-userdata) and user-data provided
+by Rancher (--fsas-userdata) are merged in this order of increasing precedence, so Rancher's settings
+needed to register the node cannot be overridden while commands of all parts run.
+The host key, if given, is installed regardless of the other parts, as the driver pins it.
+*/
+func (d *Driver) prepareUserData(hostKey *hostKeyPair) (string, error) {
+	customUserData, err := d.readCustomUserData()
+	if err != nil {
+		return "", err
+	}
+
+	var rancherUserData string
+	if d.UserDataFile != "" {
+		content, err := osReadFile(d.UserDataFile)
+		if err != nil {
+			return "", err
+		}
+		rancherUserData = string(content)
+	}
+
+	var hostKeyUserData string
+	if hostKey != nil {
+		if hostKeyUserData, err = hostKey.userData(); err != nil {
+			return "", err
+		}
+	}
+
+	if strings.TrimSpace(customUserData) == "" && strings.TrimSpace(rancherUserData) == "" && hostKeyUserData == "" {
+		return "", nil
+	}
+
+	userData, err := cfgutils.MergeUserData(
+		cfgutils.UserDataPart{Source: userDataSourceDriver, Content: driverUserData},
+		cfgutils.UserDataPart{Source: userDataSourceCustom, Content: customUserData},
+		cfgutils.UserDataPart{Source: userDataSourceRancher, Content: rancherUserData},
+		cfgutils.UserDataPart{Source: userDataSourceHostKey, Content: hostKeyUserData},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cfgutils.ValidateUserData(userData); err != nil {
+		return "", fmt.Errorf("merged user-data is not valid: %w", err)
+	}
+
+	slog.Info("Merged user-data: ", "user_data", cfgutils.RedactUserData(userData))
+	return userData, nil
+}
@@ -0,0 +1,115 @@
+package fsas
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// User-data provided by Rancher and the result of merging it with the driver generated cloud-config
+const (
+	rancherUserDataExample = "#cloud-config\nruncmd:\n- sh /usr/local/custom_script/install.sh\n"
+	mergedUserDataExample  = "#cloud-config\nruncmd:\n  - sh /usr/local/custom_script/install.sh\nssh_pwauth: false\n"
+)
+
+func mockOsReadFile(t *testing.T, files map[string]string) {
+	originalOsReadFile := osReadFile
+	t.Cleanup(func() { osReadFile = originalOsReadFile })
+	osReadFile = func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+}
+
+func Test_prepareUserData_none(t *testing.T) {
+	driver := &Driver{}
+
+	userData, err := driver.prepareUserData(nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, userData)
+}
+
+func Test_prepareUserData_rancher_only(t *testing.T) {
+	mockOsReadFile(t, map[string]string{"rancher-userdata": rancherUserDataExample})
+	driver := &Driver{UserDataFile: "rancher-userdata"}
+
+	userData, err := driver.prepareUserData(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, mergedUserDataExample, userData)
+}
+
+func Test_prepareUserData_precedence(t *testing.T) {
+	mockOsReadFile(t, map[string]string{
+		"rancher-userdata": "#cloud-config\nhostname: rancher-node\nruncmd:\n- sh install.sh\n",
+		"custom-userdata":  "#cloud-config\nhostname: custom-node\nssh_pwauth: true\nruncmd:\n- echo prepared\n",
+	})
+	driver := &Driver{UserDataFile: "rancher-userdata", CustomUserData: "custom-userdata"}
+
+	userData, err := driver.prepareUserData(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "#cloud-config\nhostname: rancher-node\nruncmd:\n  - echo prepared\n  - sh install.sh\nssh_pwauth: true\n", userData)
+}
+
+func Test_prepareUserData_custom_script_inline(t *testing.T) {
+	mockOsReadFile(t, map[string]string{"rancher-userdata": rancherUserDataExample})
+	driver := &Driver{UserDataFile: "rancher-userdata", CustomUserData: "#!/bin/sh\necho prepared\n"}
+
+	userData, err := driver.prepareUserData(nil)
+
+	require.NoError(t, err)
+	assert.Contains(t, userData, "Content-Type: multipart/mixed")
+	assert.Contains(t, userData, mergedUserDataExample)
+	assert.Contains(t, userData, "Content-Type: text/x-shellscript")
+	assert.Contains(t, userData, "#!/bin/sh\necho prepared\n")
+}
+
+func Test_prepareUserData_invalid_rancher_userdata(t *testing.T) {
+	mockOsReadFile(t, map[string]string{"rancher-userdata": "runcmd: [echo]"})
+	driver := &Driver{UserDataFile: "rancher-userdata"}
+
+	_, err := driver.prepareUserData(nil)
+
+	assert.EqualError(t, err, "unsupported rancher user-data: must start with '#cloud-config', '#cloud-boothook' or '#!'")
+}
+
+func Test_prepareUserData_read_fail(t *testing.T) {
+	mockOsReadFile(t, map[string]string{})
+	driver := &Driver{UserDataFile: "rancher-userdata"}
+
+	_, err := driver.prepareUserData(nil)
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_checkCustomUserData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom-userdata")
+	require.NoError(t, os.WriteFile(path, []byte("#cloud-config\npackages:\n- jq\n"), 0600))
+
+	assert.NoError(t, (&Driver{}).checkCustomUserData())
+	assert.NoError(t, (&Driver{CustomUserData: path}).checkCustomUserData())
+	assert.NoError(t, (&Driver{CustomUserData: "#cloud-boothook\necho early"}).checkCustomUserData())
+	assert.EqualError(t, (&Driver{CustomUserData: "#cloud-config\nruncmd: [\n"}).checkCustomUserData(),
+		"invalid custom cloud-config: yaml: line 2: did not find expected node content")
+	assert.ErrorContains(t, (&Driver{CustomUserData: filepath.Join(t.TempDir(), "missing")}).checkCustomUserData(),
+		"cannot read custom user-data")
+}
+
+func Test_customUserDataForLog(t *testing.T) {
+	driver := &Driver{CustomUserData: "/etc/rancher/custom-userdata.yaml"}
+	assert.Equal(t, "/etc/rancher/custom-userdata.yaml", driver.customUserDataForLog())
+
+	driver.CustomUserData = "#cloud-config\nchpasswd:\n  list: |\n    root:secret\nhostname: node-01\n"
+	logged := driver.customUserDataForLog()
+	assert.NotContains(t, logged, "root:secret")
+	assert.Contains(t, logged, "hostname: node-01")
+}
@@ -6,6 +6,10 @@ import (
 	fs "io/fs"
 
 	mock "github.com/stretchr/testify/mock"
+
+	ssh "golang.org/x/crypto/ssh"
+
+	models "github.com/fujitsu/docker-machine-driver-fsas/models"
 )
 
 // MockSshManager is an autogenerated mock type for the SshManager type
@@ -21,12 +25,67 @@ func (_m *MockSshManager) EXPECT() *MockSshManager_Expecter {
 	return &MockSshManager_Expecter{mock: &_m.Mock}
 }
 
-// DeregisterOS provides a mock function with no fields
-func (_m *MockSshManager) DeregisterOS() error {
+// BootId provides a mock function with no fields
+func (_m *MockSshManager) BootId() (string, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeregisterOS")
+		panic("no return value specified for BootId")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSshManager_BootId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BootId'
+type MockSshManager_BootId_Call struct {
+	*mock.Call
+}
+
+// BootId is a helper method to define mock.On call
+func (_e *MockSshManager_Expecter) BootId() *MockSshManager_BootId_Call {
+	return &MockSshManager_BootId_Call{Call: _e.mock.On("BootId")}
+}
+
+func (_c *MockSshManager_BootId_Call) Run(run func()) *MockSshManager_BootId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSshManager_BootId_Call) Return(_a0 string, _a1 error) *MockSshManager_BootId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSshManager_BootId_Call) RunAndReturn(run func() (string, error)) *MockSshManager_BootId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CheckHostKey provides a mock function with no fields
+func (_m *MockSshManager) CheckHostKey() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckHostKey")
 	}
 
 	var r0 error
@@ -39,19 +98,65 @@ func (_m *MockSshManager) DeregisterOS() error {
 	return r0
 }
 
+// MockSshManager_CheckHostKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckHostKey'
+type MockSshManager_CheckHostKey_Call struct {
+	*mock.Call
+}
+
+// CheckHostKey is a helper method to define mock.On call
+func (_e *MockSshManager_Expecter) CheckHostKey() *MockSshManager_CheckHostKey_Call {
+	return &MockSshManager_CheckHostKey_Call{Call: _e.mock.On("CheckHostKey")}
+}
+
+func (_c *MockSshManager_CheckHostKey_Call) Run(run func()) *MockSshManager_CheckHostKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSshManager_CheckHostKey_Call) Return(_a0 error) *MockSshManager_CheckHostKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSshManager_CheckHostKey_Call) RunAndReturn(run func() error) *MockSshManager_CheckHostKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeregisterOS provides a mock function with given fields: registration
+func (_m *MockSshManager) DeregisterOS(registration models.OsRegistration) error {
+	ret := _m.Called(registration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeregisterOS")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.OsRegistration) error); ok {
+		r0 = rf(registration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // MockSshManager_DeregisterOS_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeregisterOS'
 type MockSshManager_DeregisterOS_Call struct {
 	*mock.Call
 }
 
 // DeregisterOS is a helper method to define mock.On call
-func (_e *MockSshManager_Expecter) DeregisterOS() *MockSshManager_DeregisterOS_Call {
-	return &MockSshManager_DeregisterOS_Call{Call: _e.mock.On("DeregisterOS")}
+//   - registration models.OsRegistration
+func (_e *MockSshManager_Expecter) DeregisterOS(registration interface{}) *MockSshManager_DeregisterOS_Call {
+	return &MockSshManager_DeregisterOS_Call{Call: _e.mock.On("DeregisterOS", registration)}
 }
 
-func (_c *MockSshManager_DeregisterOS_Call) Run(run func()) *MockSshManager_DeregisterOS_Call {
+func (_c *MockSshManager_DeregisterOS_Call) Run(run func(registration models.OsRegistration)) *MockSshManager_DeregisterOS_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(models.OsRegistration))
 	})
 	return _c
 }
@@ -61,7 +166,7 @@ func (_c *MockSshManager_DeregisterOS_Call) Return(_a0 error) *MockSshManager_De
 	return _c
 }
 
-func (_c *MockSshManager_DeregisterOS_Call) RunAndReturn(run func() error) *MockSshManager_DeregisterOS_Call {
+func (_c *MockSshManager_DeregisterOS_Call) RunAndReturn(run func(models.OsRegistration) error) *MockSshManager_DeregisterOS_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -295,17 +400,17 @@ func (_c *MockSshManager_RebootCloudInit_Call) RunAndReturn(run func() error) *M
 	return _c
 }
 
-// RegisterOS provides a mock function with given fields: regcode, email
-func (_m *MockSshManager) RegisterOS(regcode string, email string) error {
-	ret := _m.Called(regcode, email)
+// RegisterOS provides a mock function with given fields: registration
+func (_m *MockSshManager) RegisterOS(registration models.OsRegistration) error {
+	ret := _m.Called(registration)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterOS")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(regcode, email)
+	if rf, ok := ret.Get(0).(func(models.OsRegistration) error); ok {
+		r0 = rf(registration)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -319,15 +424,14 @@ type MockSshManager_RegisterOS_Call struct {
 }
 
 // RegisterOS is a helper method to define mock.On call
-//   - regcode string
-//   - email string
-func (_e *MockSshManager_Expecter) RegisterOS(regcode interface{}, email interface{}) *MockSshManager_RegisterOS_Call {
-	return &MockSshManager_RegisterOS_Call{Call: _e.mock.On("RegisterOS", regcode, email)}
+//   - registration models.OsRegistration
+func (_e *MockSshManager_Expecter) RegisterOS(registration interface{}) *MockSshManager_RegisterOS_Call {
+	return &MockSshManager_RegisterOS_Call{Call: _e.mock.On("RegisterOS", registration)}
 }
 
-func (_c *MockSshManager_RegisterOS_Call) Run(run func(regcode string, email string)) *MockSshManager_RegisterOS_Call {
+func (_c *MockSshManager_RegisterOS_Call) Run(run func(registration models.OsRegistration)) *MockSshManager_RegisterOS_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(models.OsRegistration))
 	})
 	return _c
 }
@@ -337,7 +441,235 @@ func (_c *MockSshManager_RegisterOS_Call) Return(_a0 error) *MockSshManager_Regi
 	return _c
 }
 
-func (_c *MockSshManager_RegisterOS_Call) RunAndReturn(run func(string, string) error) *MockSshManager_RegisterOS_Call {
+func (_c *MockSshManager_RegisterOS_Call) RunAndReturn(run func(models.OsRegistration) error) *MockSshManager_RegisterOS_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoteFileChecksum provides a mock function with given fields: path
+func (_m *MockSshManager) RemoteFileChecksum(path string) (string, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoteFileChecksum")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockSshManager_RemoteFileChecksum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoteFileChecksum'
+type MockSshManager_RemoteFileChecksum_Call struct {
+	*mock.Call
+}
+
+// RemoteFileChecksum is a helper method to define mock.On call
+//   - path string
+func (_e *MockSshManager_Expecter) RemoteFileChecksum(path interface{}) *MockSshManager_RemoteFileChecksum_Call {
+	return &MockSshManager_RemoteFileChecksum_Call{Call: _e.mock.On("RemoteFileChecksum", path)}
+}
+
+func (_c *MockSshManager_RemoteFileChecksum_Call) Run(run func(path string)) *MockSshManager_RemoteFileChecksum_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockSshManager_RemoteFileChecksum_Call) Return(_a0 string, _a1 error) *MockSshManager_RemoteFileChecksum_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockSshManager_RemoteFileChecksum_Call) RunAndReturn(run func(string) (string, error)) *MockSshManager_RemoteFileChecksum_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetHostPublicKey provides a mock function with given fields: hostPublicKey
+func (_m *MockSshManager) SetHostPublicKey(hostPublicKey ssh.PublicKey) {
+	_m.Called(hostPublicKey)
+}
+
+// MockSshManager_SetHostPublicKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetHostPublicKey'
+type MockSshManager_SetHostPublicKey_Call struct {
+	*mock.Call
+}
+
+// SetHostPublicKey is a helper method to define mock.On call
+//   - hostPublicKey ssh.PublicKey
+func (_e *MockSshManager_Expecter) SetHostPublicKey(hostPublicKey interface{}) *MockSshManager_SetHostPublicKey_Call {
+	return &MockSshManager_SetHostPublicKey_Call{Call: _e.mock.On("SetHostPublicKey", hostPublicKey)}
+}
+
+func (_c *MockSshManager_SetHostPublicKey_Call) Run(run func(hostPublicKey ssh.PublicKey)) *MockSshManager_SetHostPublicKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(ssh.PublicKey))
+	})
+	return _c
+}
+
+func (_c *MockSshManager_SetHostPublicKey_Call) Return() *MockSshManager_SetHostPublicKey_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockSshManager_SetHostPublicKey_Call) RunAndReturn(run func(ssh.PublicKey)) *MockSshManager_SetHostPublicKey_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ShutdownOS provides a mock function with no fields
+func (_m *MockSshManager) ShutdownOS() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ShutdownOS")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSshManager_ShutdownOS_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShutdownOS'
+type MockSshManager_ShutdownOS_Call struct {
+	*mock.Call
+}
+
+// ShutdownOS is a helper method to define mock.On call
+func (_e *MockSshManager_Expecter) ShutdownOS() *MockSshManager_ShutdownOS_Call {
+	return &MockSshManager_ShutdownOS_Call{Call: _e.mock.On("ShutdownOS")}
+}
+
+func (_c *MockSshManager_ShutdownOS_Call) Run(run func()) *MockSshManager_ShutdownOS_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSshManager_ShutdownOS_Call) Return(_a0 error) *MockSshManager_ShutdownOS_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSshManager_ShutdownOS_Call) RunAndReturn(run func() error) *MockSshManager_ShutdownOS_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamFileToRemoteMachine provides a mock function with given fields: localPath, remotePath, fileMode, resume
+func (_m *MockSshManager) StreamFileToRemoteMachine(localPath string, remotePath string, fileMode fs.FileMode, resume bool) error {
+	ret := _m.Called(localPath, remotePath, fileMode, resume)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFileToRemoteMachine")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, fs.FileMode, bool) error); ok {
+		r0 = rf(localPath, remotePath, fileMode, resume)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSshManager_StreamFileToRemoteMachine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamFileToRemoteMachine'
+type MockSshManager_StreamFileToRemoteMachine_Call struct {
+	*mock.Call
+}
+
+// StreamFileToRemoteMachine is a helper method to define mock.On call
+//   - localPath string
+//   - remotePath string
+//   - fileMode fs.FileMode
+//   - resume bool
+func (_e *MockSshManager_Expecter) StreamFileToRemoteMachine(localPath interface{}, remotePath interface{}, fileMode interface{}, resume interface{}) *MockSshManager_StreamFileToRemoteMachine_Call {
+	return &MockSshManager_StreamFileToRemoteMachine_Call{Call: _e.mock.On("StreamFileToRemoteMachine", localPath, remotePath, fileMode, resume)}
+}
+
+func (_c *MockSshManager_StreamFileToRemoteMachine_Call) Run(run func(localPath string, remotePath string, fileMode fs.FileMode, resume bool)) *MockSshManager_StreamFileToRemoteMachine_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(fs.FileMode), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *MockSshManager_StreamFileToRemoteMachine_Call) Return(_a0 error) *MockSshManager_StreamFileToRemoteMachine_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSshManager_StreamFileToRemoteMachine_Call) RunAndReturn(run func(string, string, fs.FileMode, bool) error) *MockSshManager_StreamFileToRemoteMachine_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WaitCloudInit provides a mock function with no fields
+func (_m *MockSshManager) WaitCloudInit() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for WaitCloudInit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockSshManager_WaitCloudInit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WaitCloudInit'
+type MockSshManager_WaitCloudInit_Call struct {
+	*mock.Call
+}
+
+// WaitCloudInit is a helper method to define mock.On call
+func (_e *MockSshManager_Expecter) WaitCloudInit() *MockSshManager_WaitCloudInit_Call {
+	return &MockSshManager_WaitCloudInit_Call{Call: _e.mock.On("WaitCloudInit")}
+}
+
+func (_c *MockSshManager_WaitCloudInit_Call) Run(run func()) *MockSshManager_WaitCloudInit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockSshManager_WaitCloudInit_Call) Return(_a0 error) *MockSshManager_WaitCloudInit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockSshManager_WaitCloudInit_Call) RunAndReturn(run func() error) *MockSshManager_WaitCloudInit_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,333 @@
+package sshutils
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	OS_REGISTRAR_AUTO                 = "auto"
+	OS_REGISTRAR_SUSECONNECT          = "suseconnect"
+	OS_REGISTRAR_SUBSCRIPTION_MANAGER = "subscription-manager"
+	OS_REGISTRAR_PRO                  = "pro"
+	OS_REGISTRAR_NONE                 = "none"
+
+	cmdReadOsRelease           = "cat /etc/os-release"
+	cmdSuseConnectRegister     = "sudo -E SUSEConnect -r %s -e %s"
+	cmdSuseConnectRegisterRmt  = "sudo -E SUSEConnect"
+	argSuseConnectUrl          = " --url %s"
+	cmdSuseConnectStatus       = "sudo -E SUSEConnect -s"
+	cmdSuseConnectRegisterMod  = "sudo -E SUSEConnect -p %s"
+	cmdSuseConnectDeregister   = "sudo -E SUSEConnect -d"
+	cmdSubscriptionMgrRegister = "sudo subscription-manager register --org %s --activationkey %s"
+	cmdSubscriptionMgrIdentity = "sudo subscription-manager identity"
+	cmdSubscriptionMgrUnreg    = "sudo subscription-manager unregister"
+	cmdProAttach               = "sudo pro attach %s"
+	cmdProStatus               = "sudo pro status --format json"
+	cmdProDetach               = "sudo pro detach --assume-yes"
+)
+
+var (
+	ErrUnknownOsRegistrar  = errors.New("unknown OS registrar")
+	ErrOsRegistrarNotFound = errors.New("no OS registrar matches the operating system")
+	osRegistrars           = map[string]OsRegistrar{
+		OS_REGISTRAR_SUSECONNECT:          &suseConnectRegistrar{},
+		OS_REGISTRAR_SUBSCRIPTION_MANAGER: &subscriptionManagerRegistrar{},
+		OS_REGISTRAR_PRO:                  &proRegistrar{},
+		OS_REGISTRAR_NONE:                 &noneRegistrar{},
+	}
+	// osReleaseRegistrars maps IDs found in ID and ID_LIKE of /etc/os-release to registrars
+	osReleaseRegistrars = map[string]string{
+		"sles":      OS_REGISTRAR_SUSECONNECT,
+		"sled":      OS_REGISTRAR_SUSECONNECT,
+		"sle-micro": OS_REGISTRAR_SUSECONNECT,
+		"suse":      OS_REGISTRAR_SUSECONNECT,
+		"rhel":      OS_REGISTRAR_SUBSCRIPTION_MANAGER,
+		"ubuntu":    OS_REGISTRAR_PRO,
+	}
+)
+
+// CommandRunner runs command on the machine and returns its output
+type CommandRunner func(command string) (string, error)
+
+// OsRegistrar registers the OS of the machine with its vendor using commands run over SSH
+type OsRegistrar interface {
+	Name() string
+	Register(run CommandRunner, registration models.OsRegistration) error
+	Status(run CommandRunner) (bool, error)
+	Deregister(run CommandRunner, registration models.OsRegistration) error
+}
+
+// ValidateOsRegistrar Returns error when name is neither 'auto' nor a known registrar
+func ValidateOsRegistrar(name string) error {
+	if name == "" || name == OS_REGISTRAR_AUTO {
+		return nil
+	}
+	if _, ok := osRegistrars[name]; !ok {
+		return fmt.Errorf("%w '%s'", ErrUnknownOsRegistrar, name)
+	}
+	return nil
+}
+
+// NewOsRegistrar Returns registrar of given name
+func NewOsRegistrar(name string) (OsRegistrar, error) {
+	registrar, ok := osRegistrars[name]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownOsRegistrar, name)
+	}
+	return registrar, nil
+}
+
+// DetectOsRegistrar Returns registrar matching ID or ID_LIKE of the given /etc/os-release content
+func DetectOsRegistrar(osRelease string) (OsRegistrar, error) {
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(osRelease))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if found {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	ids := append([]string{fields["ID"]}, strings.Fields(fields["ID_LIKE"])...)
+	for _, id := range ids {
+		if name, ok := osReleaseRegistrars[strings.ToLower(id)]; ok {
+			slog.Info("Detected OS registrar: ", "registrar", name, "os", fields["ID"])
+			return osRegistrars[name], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: ID=%s, ID_LIKE=%s", ErrOsRegistrarNotFound, fields["ID"], fields["ID_LIKE"])
+}
+
+// shellQuote Returns value quoted for POSIX shell, so it reaches the command as a single literal argument
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// isExitError Tells whether command ran but exited with non-zero status
+func isExitError(err error) bool {
+	var exitErr *gossh.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// suseConnectRegistrar registers SLES and its modules using SUSEConnect
+type suseConnectRegistrar struct{}
+
+// Name Returns name of the registrar
+func (r *suseConnectRegistrar) Name() string {
+	return OS_REGISTRAR_SUSECONNECT
+}
+
+/*
+Register Registers SLES and then modules selected in registration or every module which is not registered yet.
+When registration server is given, its CA certificate is installed first and all modules are registered against it
+*/
+func (r *suseConnectRegistrar) Register(run CommandRunner, registration models.OsRegistration) error {
+	if registration.Code != "" && registration.Email == "" {
+		return errors.New("SUSEConnect registration requires registration email")
+	}
+
+	if registration.ServerUrl != "" {
+		if err := installRmtCaCert(run, registration); err != nil {
+			slog.Error("Error installing CA certificate of registration server: ", "url", registration.ServerUrl, "err", err)
+			return err
+		}
+	}
+
+	registerCommand := cmdSuseConnectRegisterRmt
+	if registration.Code != "" {
+		registerCommand = fmt.Sprintf(cmdSuseConnectRegister, registration.Code, registration.Email)
+	}
+
+	slog.Info("Attempting initial OS registration: ", "email", registration.Email, "url", registration.ServerUrl)
+	if _, err := run(r.withServer(registerCommand, registration)); err != nil {
+		slog.Error("Error executing initial OS registration: ", "err", err)
+		return err
+	}
+	slog.Info("Initial OS registration successful")
+
+	modules, err := ParseSuseModules(registration.Modules)
+	if err != nil {
+		return err
+	}
+	if modules != nil {
+		return r.registerModules(run, registration, modules)
+	}
+
+	slog.Info("Fetching status of all SUSE modules...")
+	products, err := r.products(run)
+	if err != nil {
+		slog.Error("Error fetching SUSE product status: ", "err", err)
+		return err
+	}
+
+	slog.Info("Checking for unregistered modules...")
+	for _, product := range products {
+		if product.Status == "Not Registered" {
+			productString := fmt.Sprintf("%s/%s/%s", product.Identifier, product.Version, product.Arch)
+			slog.Info("Found unregistered module. Attempting to register: ", "module", productString)
+
+			if _, err := run(r.withServer(fmt.Sprintf(cmdSuseConnectRegisterMod, productString), registration)); err != nil {
+				slog.Error("Error registering module: ", "module", productString, "err", err)
+				return err
+			}
+			slog.Info("Successfully registered module: ", "module", productString)
+		}
+	}
+	return nil
+}
+
+// Status Tells whether any SUSE product is registered
+func (r *suseConnectRegistrar) Status(run CommandRunner) (bool, error) {
+	products, err := r.products(run)
+	if err != nil {
+		return false, fmt.Errorf("could not get SUSE product status: %w", err)
+	}
+	for _, p := range products {
+		if p.Status == "Registered" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Deregister De-registers SLES and its modules from the server they were registered with
+func (r *suseConnectRegistrar) Deregister(run CommandRunner, registration models.OsRegistration) error {
+	_, err := run(r.withServer(cmdSuseConnectDeregister, registration))
+	return err
+}
+
+// withServer Returns SUSEConnect command directed to the registration server, if one is given
+func (r *suseConnectRegistrar) withServer(command string, registration models.OsRegistration) string {
+	if registration.ServerUrl == "" {
+		return command
+	}
+	return command + fmt.Sprintf(argSuseConnectUrl, shellQuote(registration.ServerUrl))
+}
+
+// products Returns SUSE products reported by SUSEConnect
+func (r *suseConnectRegistrar) products(run CommandRunner) ([]models.SuseProduct, error) {
+	jsonOutput, err := run(cmdSuseConnectStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []models.SuseProduct
+	if err := json.Unmarshal([]byte(jsonOutput), &products); err != nil {
+		return nil, fmt.Errorf("failed to parse SUSE status JSON: %w", err)
+	}
+	return products, nil
+}
+
+// subscriptionManagerRegistrar registers RHEL using subscription-manager and an activation key
+type subscriptionManagerRegistrar struct{}
+
+// Name Returns name of the registrar
+func (r *subscriptionManagerRegistrar) Name() string {
+	return OS_REGISTRAR_SUBSCRIPTION_MANAGER
+}
+
+// Register Registers the system with the activation key of the organization
+func (r *subscriptionManagerRegistrar) Register(run CommandRunner, registration models.OsRegistration) error {
+	if registration.Org == "" {
+		return errors.New("subscription-manager registration requires organization ID")
+	}
+
+	slog.Info("Attempting OS registration with subscription-manager: ", "org", registration.Org)
+	if _, err := run(fmt.Sprintf(cmdSubscriptionMgrRegister, shellQuote(registration.Org), shellQuote(registration.Code))); err != nil {
+		slog.Error("Error executing OS registration with subscription-manager: ", "err", err)
+		return err
+	}
+	return nil
+}
+
+// Status Tells whether the system has a subscription-manager identity
+func (r *subscriptionManagerRegistrar) Status(run CommandRunner) (bool, error) {
+	if _, err := run(cmdSubscriptionMgrIdentity); err != nil {
+		if isExitError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get subscription-manager identity: %w", err)
+	}
+	return true, nil
+}
+
+// Deregister Unregisters the system from Red Hat
+func (r *subscriptionManagerRegistrar) Deregister(run CommandRunner, _ models.OsRegistration) error {
+	_, err := run(cmdSubscriptionMgrUnreg)
+	return err
+}
+
+// proStatus is a part of 'pro status --format json' output
+type proStatus struct {
+	Attached bool `json:"attached"`
+}
+
+// proRegistrar attaches Ubuntu to an Ubuntu Pro subscription
+type proRegistrar struct{}
+
+// Name Returns name of the registrar
+func (r *proRegistrar) Name() string {
+	return OS_REGISTRAR_PRO
+}
+
+// Register Attaches the system using the Ubuntu Pro token
+func (r *proRegistrar) Register(run CommandRunner, registration models.OsRegistration) error {
+	slog.Info("Attempting OS registration with Ubuntu Pro")
+	if _, err := run(fmt.Sprintf(cmdProAttach, shellQuote(registration.Code))); err != nil {
+		slog.Error("Error executing OS registration with Ubuntu Pro: ", "err", err)
+		return err
+	}
+	return nil
+}
+
+// Status Tells whether the system is attached to Ubuntu Pro
+func (r *proRegistrar) Status(run CommandRunner) (bool, error) {
+	jsonOutput, err := run(cmdProStatus)
+	if err != nil {
+		return false, fmt.Errorf("could not get Ubuntu Pro status: %w", err)
+	}
+
+	var status proStatus
+	if err := json.Unmarshal([]byte(jsonOutput), &status); err != nil {
+		return false, fmt.Errorf("failed to parse Ubuntu Pro status JSON: %w", err)
+	}
+	return status.Attached, nil
+}
+
+// Deregister Detaches the system from Ubuntu Pro
+func (r *proRegistrar) Deregister(run CommandRunner, _ models.OsRegistration) error {
+	_, err := run(cmdProDetach)
+	return err
+}
+
+// noneRegistrar leaves the OS unregistered
+type noneRegistrar struct{}
+
+// Name Returns name of the registrar
+func (r *noneRegistrar) Name() string {
+	return OS_REGISTRAR_NONE
+}
+
+// Register Does nothing
+func (r *noneRegistrar) Register(_ CommandRunner, _ models.OsRegistration) error {
+	return nil
+}
+
+// Status Reports the OS as not registered
+func (r *noneRegistrar) Status(_ CommandRunner) (bool, error) {
+	return false, nil
+}
+
+// Deregister Does nothing
+func (r *noneRegistrar) Deregister(_ CommandRunner, _ models.OsRegistration) error {
+	return nil
+}
@@ -0,0 +1,326 @@
+package sshutils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	osReleaseSles = `NAME="SLES"
+VERSION="15-SP6"
+ID="sles"
+ID_LIKE="suse"
+`
+	osReleaseRocky = `NAME="Rocky Linux"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+`
+	osReleaseUbuntu = `NAME="Ubuntu"
+ID=ubuntu
+ID_LIKE=debian
+`
+)
+
+// recordingRunner returns runner which records commands and answers with outputs, unknown commands fail
+func recordingRunner(outputs map[string]string, errs map[string]error) (CommandRunner, *[]string) {
+	executed := []string{}
+	return func(command string) (string, error) {
+		executed = append(executed, command)
+		if err, ok := errs[command]; ok {
+			return "", err
+		}
+		if output, ok := outputs[command]; ok {
+			return output, nil
+		}
+		return "", fmt.Errorf("unexpected command: %s", command)
+	}, &executed
+}
+
+func TestValidateOsRegistrar(t *testing.T) {
+	for _, name := range []string{"", OS_REGISTRAR_AUTO, OS_REGISTRAR_SUSECONNECT, OS_REGISTRAR_SUBSCRIPTION_MANAGER, OS_REGISTRAR_PRO, OS_REGISTRAR_NONE} {
+		assert.NoError(t, ValidateOsRegistrar(name), name)
+	}
+	assert.ErrorIs(t, ValidateOsRegistrar("yast"), ErrUnknownOsRegistrar)
+}
+
+func TestNewOsRegistrar(t *testing.T) {
+	registrar, err := NewOsRegistrar(OS_REGISTRAR_PRO)
+	require.NoError(t, err)
+	assert.Equal(t, OS_REGISTRAR_PRO, registrar.Name())
+
+	_, err = NewOsRegistrar(OS_REGISTRAR_AUTO)
+	assert.ErrorIs(t, err, ErrUnknownOsRegistrar)
+}
+
+func TestDetectOsRegistrar(t *testing.T) {
+	testCases := []struct {
+		name      string
+		osRelease string
+		expected  string
+	}{
+		{name: "SLES by ID", osRelease: osReleaseSles, expected: OS_REGISTRAR_SUSECONNECT},
+		{name: "RHEL derivative by ID_LIKE", osRelease: osReleaseRocky, expected: OS_REGISTRAR_SUBSCRIPTION_MANAGER},
+		{name: "unquoted Ubuntu", osRelease: osReleaseUbuntu, expected: OS_REGISTRAR_PRO},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registrar, err := DetectOsRegistrar(tc.osRelease)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, registrar.Name())
+		})
+	}
+
+	_, err := DetectOsRegistrar("ID=debian\n")
+	assert.ErrorIs(t, err, ErrOsRegistrarNotFound)
+	assert.ErrorContains(t, err, "ID=debian")
+}
+
+func Test_shellQuote(t *testing.T) {
+	assert.Equal(t, "'rke2-key'", shellQuote("rke2-key"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, `'key; reboot'`, shellQuote("key; reboot"))
+	assert.Equal(t, `'it'\''s $(id)'`, shellQuote("it's $(id)"))
+}
+
+func TestSuseConnectRegistrar_RegisterRequiresEmail(t *testing.T) {
+	run, executed := recordingRunner(nil, nil)
+
+	err := (&suseConnectRegistrar{}).Register(run, models.OsRegistration{Code: "regcode"})
+
+	assert.ErrorContains(t, err, "registration email")
+	assert.Empty(t, *executed)
+}
+
+func TestSubscriptionManagerRegistrar(t *testing.T) {
+	registrar := &subscriptionManagerRegistrar{}
+	registerCmd := "sudo subscription-manager register --org '1234567' --activationkey 'rke2-key'"
+
+	t.Run("register", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{registerCmd: ""}, nil)
+
+		err := registrar.Register(run, models.OsRegistration{Code: "rke2-key", Org: "1234567"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{registerCmd}, *executed)
+	})
+
+	t.Run("register requires organization", func(t *testing.T) {
+		run, executed := recordingRunner(nil, nil)
+
+		err := registrar.Register(run, models.OsRegistration{Code: "rke2-key"})
+
+		assert.ErrorContains(t, err, "organization ID")
+		assert.Empty(t, *executed)
+	})
+
+	t.Run("status registered", func(t *testing.T) {
+		run, _ := recordingRunner(map[string]string{cmdSubscriptionMgrIdentity: "system identity: 5c1f"}, nil)
+
+		registered, err := registrar.Status(run)
+
+		assert.NoError(t, err)
+		assert.True(t, registered)
+	})
+
+	t.Run("status not registered", func(t *testing.T) {
+		run, _ := recordingRunner(nil, map[string]error{cmdSubscriptionMgrIdentity: &gossh.ExitError{}})
+
+		registered, err := registrar.Status(run)
+
+		assert.NoError(t, err)
+		assert.False(t, registered)
+	})
+
+	t.Run("status connection error", func(t *testing.T) {
+		run, _ := recordingRunner(nil, map[string]error{cmdSubscriptionMgrIdentity: MOCK_ERROR_FOR_OUTPUT_METHOD})
+
+		_, err := registrar.Status(run)
+
+		assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
+	})
+
+	t.Run("deregister", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{cmdSubscriptionMgrUnreg: ""}, nil)
+
+		assert.NoError(t, registrar.Deregister(run, models.OsRegistration{}))
+		assert.Equal(t, []string{cmdSubscriptionMgrUnreg}, *executed)
+	})
+}
+
+func TestProRegistrar(t *testing.T) {
+	registrar := &proRegistrar{}
+	attachCmd := "sudo pro attach 'C1token'"
+
+	t.Run("register", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{attachCmd: ""}, nil)
+
+		assert.NoError(t, registrar.Register(run, models.OsRegistration{Code: "C1token"}))
+		assert.Equal(t, []string{attachCmd}, *executed)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		run, _ := recordingRunner(map[string]string{cmdProStatus: `{"attached": true, "services": []}`}, nil)
+
+		registered, err := registrar.Status(run)
+
+		assert.NoError(t, err)
+		assert.True(t, registered)
+	})
+
+	t.Run("status invalid JSON", func(t *testing.T) {
+		run, _ := recordingRunner(map[string]string{cmdProStatus: "attached"}, nil)
+
+		_, err := registrar.Status(run)
+
+		assert.ErrorContains(t, err, "failed to parse Ubuntu Pro status JSON")
+	})
+
+	t.Run("deregister", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{cmdProDetach: ""}, nil)
+
+		assert.NoError(t, registrar.Deregister(run, models.OsRegistration{}))
+		assert.Equal(t, []string{cmdProDetach}, *executed)
+	})
+}
+
+func TestNoneRegistrar(t *testing.T) {
+	run, executed := recordingRunner(nil, nil)
+	registrar := &noneRegistrar{}
+
+	assert.NoError(t, registrar.Register(run, models.OsRegistration{Code: "code"}))
+	registered, err := registrar.Status(run)
+	assert.NoError(t, err)
+	assert.False(t, registered)
+	assert.NoError(t, registrar.Deregister(run, models.OsRegistration{}))
+	assert.Empty(t, *executed)
+}
+
+func TestRegisterOS_DetectsRegistrar(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	attachCmd := "sudo pro attach 'C1token'"
+	mockClient := &MockSSHClient{
+		OutputFunc: func(command string) (string, error) {
+			switch command {
+			case cmdReadOsRelease:
+				return osReleaseUbuntu, nil
+			case attachCmd:
+				return "", nil
+			default:
+				return "", fmt.Errorf("unexpected command: %s", command)
+			}
+		},
+	}
+	manager.Client = mockClient
+
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_AUTO, Code: "C1token"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cmdReadOsRelease, attachCmd}, mockClient.ExecutedCommands)
+}
+
+func TestRegisterOS_FailOnDetection(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	mockClient := &MockSSHClient{
+		OutputFunc: func(command string) (string, error) {
+			return "", MOCK_ERROR_FOR_OUTPUT_METHOD
+		},
+	}
+	manager.Client = mockClient
+
+	err = manager.RegisterOS(models.OsRegistration{Code: "C1token"})
+
+	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
+	assert.ErrorContains(t, err, "detect OS registrar")
+}
+
+func TestDeregisterOS_DetectsRegistrar(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	mockClient := &MockSSHClient{
+		OutputFunc: func(command string) (string, error) {
+			switch command {
+			case cmdReadOsRelease:
+				return osReleaseRocky, nil
+			case cmdSubscriptionMgrIdentity:
+				return "system identity: 5c1f", nil
+			case cmdSubscriptionMgrUnreg:
+				return "", nil
+			default:
+				return "", errors.New("unexpected command")
+			}
+		},
+	}
+	manager.Client = mockClient
+
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_AUTO})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cmdReadOsRelease, cmdSubscriptionMgrIdentity, cmdSubscriptionMgrUnreg}, mockClient.ExecutedCommands)
+}
+
+func TestDeregisterOS_NoneRegistrar(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+	mockClient := &MockSSHClient{}
+	manager.Client = mockClient
+
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_NONE})
+
+	assert.NoError(t, err)
+	assert.Empty(t, mockClient.ExecutedCommands)
+}
+
+func TestSuseConnectRegistrar_RegisterWithRmt(t *testing.T) {
+	caCert, _ := testRmtCaCert(t)
+	registration := models.OsRegistration{ServerUrl: "https://rmt.example.com", ServerCaCert: caCert}
+	installCmd := fmt.Sprintf(cmdInstallRmtCaCert, base64.StdEncoding.EncodeToString([]byte(caCert)), rmtCaCertPath)
+	registerCmd := "sudo -E SUSEConnect --url 'https://rmt.example.com'"
+	moduleCmd := "sudo -E SUSEConnect -p sle-module-containers/15.6/x86_64 --url 'https://rmt.example.com'"
+	status := `[{"identifier":"sle-module-containers","version":"15.6","arch":"x86_64","status":"Not Registered"}]`
+
+	run, executed := recordingRunner(map[string]string{installCmd: "", registerCmd: "", cmdSuseConnectStatus: status, moduleCmd: ""}, nil)
+
+	err := (&suseConnectRegistrar{}).Register(run, registration)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{installCmd, registerCmd, cmdSuseConnectStatus, moduleCmd}, *executed)
+}
+
+func TestSuseConnectRegistrar_RegisterWithRmtAndCode(t *testing.T) {
+	registration := models.OsRegistration{Code: "regcode", Email: "hoge@example.com", ServerUrl: "https://rmt.example.com"}
+	registerCmd := "sudo -E SUSEConnect -r regcode -e hoge@example.com --url 'https://rmt.example.com'"
+
+	run, executed := recordingRunner(map[string]string{registerCmd: "", cmdSuseConnectStatus: "[]"}, nil)
+
+	err := (&suseConnectRegistrar{}).Register(run, registration)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{registerCmd, cmdSuseConnectStatus}, *executed)
+}
+
+func TestSuseConnectRegistrar_DeregisterFromRmt(t *testing.T) {
+	deregisterCmd := "sudo -E SUSEConnect -d --url 'https://rmt.example.com'"
+	run, executed := recordingRunner(map[string]string{deregisterCmd: ""}, nil)
+
+	err := (&suseConnectRegistrar{}).Deregister(run, models.OsRegistration{ServerUrl: "https://rmt.example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{deregisterCmd}, *executed)
+}
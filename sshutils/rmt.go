@@ -0,0 +1,116 @@
+package sshutils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+)
+
+const (
+	rmtCaCertPath       = "/etc/pki/trust/anchors/fsas-rmt-server.pem"
+	cmdFetchRmtCaCert   = "curl -fsSL %s"
+	cmdInstallRmtCaCert = "echo %s | base64 -d | sudo tee %s > /dev/null && sudo update-ca-certificates"
+)
+
+var ErrCaFingerprintMismatch = errors.New("CA certificate does not match the fingerprint")
+
+// ValidateRmtUrl Returns error when URL of the registration server is not an absolute http(s) URL
+func ValidateRmtUrl(serverUrl string) error {
+	parsed, err := url.Parse(serverUrl)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("'%s' is not an http(s) URL of the registration server", serverUrl)
+	}
+	return nil
+}
+
+// rmtCaCertUrl Returns URL the RMT server publishes its CA certificate at; plain http as the CA is not trusted yet
+func rmtCaCertUrl(serverUrl string) (string, error) {
+	parsed, err := url.Parse(serverUrl)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s/rmt.crt", parsed.Host), nil
+}
+
+/*
+VerifyCaCertFingerprint Checks that caCert is a PEM encoded certificate and, when fingerprint is given,
+that its SHA-256 or SHA-1 fingerprint matches it. Fingerprint may contain colons and is case-insensitive
+*/
+func VerifyCaCertFingerprint(caCert, fingerprint string) error {
+	block, _ := pem.Decode([]byte(caCert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return errors.New("CA certificate is not a PEM encoded certificate")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return fmt.Errorf("cannot parse CA certificate: %w", err)
+	}
+
+	if fingerprint == "" {
+		return nil
+	}
+
+	var sum []byte
+	expected := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+	switch len(expected) {
+	case hex.EncodedLen(sha256.Size):
+		digest := sha256.Sum256(block.Bytes)
+		sum = digest[:]
+	case hex.EncodedLen(sha1.Size):
+		digest := sha1.Sum(block.Bytes)
+		sum = digest[:]
+	default:
+		return fmt.Errorf("'%s' is neither SHA-256 nor SHA-1 fingerprint", fingerprint)
+	}
+
+	if hex.EncodeToString(sum) != expected {
+		return fmt.Errorf("%w %s", ErrCaFingerprintMismatch, fingerprint)
+	}
+	return nil
+}
+
+/*
+installRmtCaCert Installs CA certificate of the registration server into the trust store of the machine.
+Without the certificate but with its fingerprint, the certificate published by the server is fetched and verified;
+it is fetched over plain http, so it is never fetched without the fingerprint
+*/
+func installRmtCaCert(run CommandRunner, registration models.OsRegistration) error {
+	caCert := registration.ServerCaCert
+	if caCert == "" {
+		if registration.ServerCaFingerprint == "" {
+			return nil
+		}
+
+		caCertUrl, err := rmtCaCertUrl(registration.ServerUrl)
+		if err != nil {
+			return err
+		}
+		slog.Info("Fetching CA certificate of registration server: ", "url", caCertUrl)
+		if caCert, err = run(fmt.Sprintf(cmdFetchRmtCaCert, shellQuote(caCertUrl))); err != nil {
+			return fmt.Errorf("cannot fetch CA certificate of registration server: %w", err)
+		}
+	}
+
+	if err := VerifyCaCertFingerprint(caCert, registration.ServerCaFingerprint); err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(caCert))
+	if _, err := run(fmt.Sprintf(cmdInstallRmtCaCert, encoded, rmtCaCertPath)); err != nil {
+		return fmt.Errorf("cannot install CA certificate of registration server: %w", err)
+	}
+	slog.Info("CA certificate of registration server installed: ", "path", rmtCaCertPath)
+	return nil
+}
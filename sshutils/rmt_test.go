@@ -0,0 +1,137 @@
+package sshutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRmtCaCert Returns self-signed PEM CA certificate with its SHA-256 fingerprint in colon separated form
+func testRmtCaCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RMT Certificate Authority"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(der)
+	hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+	pairs := []string{}
+	for i := 0; i < len(hexSum); i += 2 {
+		pairs = append(pairs, hexSum[i:i+2])
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), strings.Join(pairs, ":")
+}
+
+func TestValidateRmtUrl(t *testing.T) {
+	assert.NoError(t, ValidateRmtUrl("https://rmt.example.com"))
+	assert.NoError(t, ValidateRmtUrl("http://10.0.0.5:8080"))
+	assert.Error(t, ValidateRmtUrl("rmt.example.com"))
+	assert.Error(t, ValidateRmtUrl("ftp://rmt.example.com"))
+	assert.Error(t, ValidateRmtUrl("https://"))
+}
+
+func TestVerifyCaCertFingerprint(t *testing.T) {
+	caCert, fingerprint := testRmtCaCert(t)
+	block, _ := pem.Decode([]byte(caCert))
+	sha1Sum := sha1.Sum(block.Bytes)
+
+	testCases := []struct {
+		name        string
+		caCert      string
+		fingerprint string
+		expected    string
+	}{
+		{name: "no fingerprint", caCert: caCert},
+		{name: "SHA-256 with colons", caCert: caCert, fingerprint: fingerprint},
+		{name: "SHA-256 lowercase without colons", caCert: caCert, fingerprint: strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))},
+		{name: "SHA-1", caCert: caCert, fingerprint: hex.EncodeToString(sha1Sum[:])},
+		{name: "not PEM", caCert: "certificate", expected: "not a PEM encoded certificate"},
+		{name: "invalid fingerprint", caCert: caCert, fingerprint: "AB:CD", expected: "neither SHA-256 nor SHA-1 fingerprint"},
+		{name: "mismatch", caCert: caCert, fingerprint: strings.Repeat("0", 64), expected: ErrCaFingerprintMismatch.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyCaCertFingerprint(tc.caCert, tc.fingerprint)
+			if tc.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestInstallRmtCaCert(t *testing.T) {
+	caCert, fingerprint := testRmtCaCert(t)
+	installCmd := fmt.Sprintf(cmdInstallRmtCaCert, base64.StdEncoding.EncodeToString([]byte(caCert)), rmtCaCertPath)
+	fetchCmd := "curl -fsSL 'http://rmt.example.com/rmt.crt'"
+
+	t.Run("nothing to install", func(t *testing.T) {
+		run, executed := recordingRunner(nil, nil)
+
+		assert.NoError(t, installRmtCaCert(run, models.OsRegistration{ServerUrl: "https://rmt.example.com"}))
+		assert.Empty(t, *executed)
+	})
+
+	t.Run("given certificate", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{installCmd: ""}, nil)
+
+		err := installRmtCaCert(run, models.OsRegistration{ServerUrl: "https://rmt.example.com", ServerCaCert: caCert})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{installCmd}, *executed)
+	})
+
+	t.Run("fetched certificate verified by fingerprint", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{fetchCmd: caCert, installCmd: ""}, nil)
+
+		err := installRmtCaCert(run, models.OsRegistration{ServerUrl: "https://rmt.example.com/", ServerCaFingerprint: fingerprint})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{fetchCmd, installCmd}, *executed)
+	})
+
+	t.Run("fetched certificate does not match fingerprint", func(t *testing.T) {
+		run, executed := recordingRunner(map[string]string{fetchCmd: caCert}, nil)
+
+		err := installRmtCaCert(run, models.OsRegistration{ServerUrl: "https://rmt.example.com", ServerCaFingerprint: strings.Repeat("0", 64)})
+
+		assert.ErrorIs(t, err, ErrCaFingerprintMismatch)
+		assert.Equal(t, []string{fetchCmd}, *executed)
+	})
+
+	t.Run("fetch fails", func(t *testing.T) {
+		run, _ := recordingRunner(nil, map[string]error{fetchCmd: MOCK_ERROR_FOR_OUTPUT_METHOD})
+
+		err := installRmtCaCert(run, models.OsRegistration{ServerUrl: "https://rmt.example.com", ServerCaFingerprint: fingerprint})
+
+		assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
+		assert.ErrorContains(t, err, "cannot fetch CA certificate")
+	})
+}
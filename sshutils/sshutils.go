@@ -3,11 +3,11 @@ package sshutils
 import (
 	"bytes"
 	"crypto/rand"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,18 +24,23 @@ import (
 const (
 	port                                    = 22
 	cmdRebootCloudInit                      = "sudo cloud-init clean --logs --reboot"
-	cmdRegisterOS                           = "sudo -E SUSEConnect -r %s -e %s"
-	cmdGetStatusOS                          = "sudo -E SUSEConnect -s"
-	cmdRegisterModuleOS                     = "sudo -E SUSEConnect -p %s"
-	cmdDeregisterOS                         = "sudo -E SUSEConnect -d"
+	cmdShutdownOS                           = "sudo shutdown -h now"
+	cmdCloudInitStatusWait                  = "sudo cloud-init status --wait"
+	cmdCloudInitStatusLong                  = "sudo cloud-init status --long || true"
+	cmdReadBootId                           = "cat /proc/sys/kernel/random/boot_id"
 	remoteScriptDir                         = "/tmp/fsas-nodedriver"
 	SSH_CONNECT_ATTEMPT_DELAY time.Duration = 5 * time.Second
+	SSH_DIAL_TIMEOUT          time.Duration = 10 * time.Second
 )
 
 var (
 	ErrNoneOfConstructorArgsCanBeEmpty = errors.New("none of the arguments can be empty; neither 'hostName', 'userName', 'sshPassword', 'sshKeyPath', 'hostPublicKey'")
+	ErrPasswordOrBootstrapKeyRequired  = errors.New("either 'sshPassword' or 'bootstrapKeyPath' must be set")
+	ErrHostKeyMismatch                 = errors.New("host key of the machine does not match the pinned host key")
+	ErrCloudInitFailed                 = errors.New("cloud-init reported errors")
 	isInit                             = false
 	publicKeyIsValid                   = false
+	sshDial                            = gossh.Dial
 )
 
 // SSHKeyParser defines the interface for parsing an SSH key from a path.
@@ -80,21 +85,30 @@ type SshManager interface {
 	ExchangeKeys() error
 	ExecuteScript(scriptPath, scriptContent string, postRemove bool, runWithSudo bool) error
 	WriteFileOnRemoteMachine(path, fileContent string, fileMode os.FileMode) error
+	StreamFileToRemoteMachine(localPath, remotePath string, fileMode os.FileMode, resume bool) error
+	RemoteFileChecksum(path string) (string, error)
 	DisablePasswordSSHLogin() error
 	RebootCloudInit() error
-	RegisterOS(regcode, email string) error
-	DeregisterOS() error
+	BootId() (string, error)
+	CheckHostKey() error
+	SetHostPublicKey(hostPublicKey gossh.PublicKey)
+	WaitCloudInit() error
+	ShutdownOS() error
+	RegisterOS(registration models.OsRegistration) error
+	DeregisterOS(registration models.OsRegistration) error
 }
 
 // StandardSshManager struct holds configuration for SSH Manager interaction.
 type StandardSshManager struct {
-	HostName      string
-	UserName      string
-	SshPassword   string
-	SshKeyPath    string
-	Client        ssh.Client
-	HostPublicKey gossh.PublicKey
-	keyParser     SSHKeyParser
+	HostName    string
+	UserName    string
+	SshPassword string
+	SshKeyPath  string
+	// Private key authorized on the OS image, used instead of the password before the driver key is exchanged
+	BootstrapKeyPath string
+	Client           ssh.Client
+	HostPublicKey    gossh.PublicKey
+	keyParser        SSHKeyParser
 }
 
 var _ SshManager = (*StandardSshManager)(nil)
@@ -131,24 +145,30 @@ func ParseSSHPublicKey(key string) (gossh.PublicKey, error) {
 
 // NewStandardSshManager Returns new instance of Standard SSH Manager and error.
 // The hostPublicKey parameter must be a pre-parsed gossh.PublicKey (use ParseSSHPublicKey to obtain one).
-func NewStandardSshManager(hostName, userName, sshPassword, sshKeyPath string, hostPublicKey gossh.PublicKey) (*StandardSshManager, error) {
-	slog.Debug("Standard SSH Manager constructor: ", "host", hostName, "user", userName)
+// When bootstrapKeyPath is set the password may be empty, the OS image is then accessed with the bootstrap key only.
+func NewStandardSshManager(hostName, userName, sshPassword, sshKeyPath, bootstrapKeyPath string, hostPublicKey gossh.PublicKey) (*StandardSshManager, error) {
+	slog.Debug("Standard SSH Manager constructor: ", "host", hostName, "user", userName, "bootstrap_key", bootstrapKeyPath)
 
-	if hostName == "" || userName == "" || sshPassword == "" || sshKeyPath == "" || hostPublicKey == nil {
+	if hostName == "" || userName == "" || sshKeyPath == "" || hostPublicKey == nil {
 
 		slog.Error(ErrNoneOfConstructorArgsCanBeEmpty.Error())
 		return nil, ErrNoneOfConstructorArgsCanBeEmpty
 	}
+	if sshPassword == "" && bootstrapKeyPath == "" {
+		slog.Error(ErrPasswordOrBootstrapKeyRequired.Error())
+		return nil, ErrPasswordOrBootstrapKeyRequired
+	}
 
 	isInit = true
 	return &StandardSshManager{
-		HostName:      hostName,
-		UserName:      userName,
-		SshPassword:   sshPassword,
-		Client:        &ssh.NativeClient{},
-		HostPublicKey: hostPublicKey,
-		SshKeyPath:    sshKeyPath,
-		keyParser:     NewFileSSHKeyParser(),
+		HostName:         hostName,
+		UserName:         userName,
+		SshPassword:      sshPassword,
+		Client:           &ssh.NativeClient{},
+		HostPublicKey:    hostPublicKey,
+		SshKeyPath:       sshKeyPath,
+		BootstrapKeyPath: bootstrapKeyPath,
+		keyParser:        NewFileSSHKeyParser(),
 	}, nil
 }
 
@@ -156,7 +176,8 @@ func (sc *StandardSshManager) String() string {
 	return "{" +
 		fmt.Sprintf("HostName: %s, ", sc.HostName) +
 		fmt.Sprintf("UserName: %s, ", sc.UserName) +
-		fmt.Sprintf("SshKeyPath: %s", sc.SshKeyPath) +
+		fmt.Sprintf("SshKeyPath: %s, ", sc.SshKeyPath) +
+		fmt.Sprintf("BootstrapKeyPath: %s", sc.BootstrapKeyPath) +
 		"}"
 }
 
@@ -166,11 +187,23 @@ func (sc *StandardSshManager) IsInit() bool {
 }
 
 func (sc *StandardSshManager) getSshClientConfig() *gossh.ClientConfig {
-	authMethods := []gossh.AuthMethod{
-		gossh.Password(sc.SshPassword),
+	var authMethods []gossh.AuthMethod
+	if sc.SshPassword != "" {
+		authMethods = append(authMethods, gossh.Password(sc.SshPassword))
 	}
-	if sshPrivateKey := sc.keyParser.Parse(sc.SshKeyPath); sshPrivateKey != nil {
-		authMethods = append(authMethods, gossh.PublicKeys(sshPrivateKey))
+
+	// Driver key is tried first, the bootstrap key is needed only until the driver key is exchanged
+	var signers []gossh.Signer
+	for _, keyPath := range []string{sc.SshKeyPath, sc.BootstrapKeyPath} {
+		if keyPath == "" {
+			continue
+		}
+		if sshPrivateKey := sc.keyParser.Parse(keyPath); sshPrivateKey != nil {
+			signers = append(signers, sshPrivateKey)
+		}
+	}
+	if len(signers) > 0 {
+		authMethods = append(authMethods, gossh.PublicKeys(signers...))
 	}
 
 	config := &gossh.ClientConfig{
@@ -222,11 +255,17 @@ func (sc *StandardSshManager) hostPublicKeyIsValid() error {
 func (sc *StandardSshManager) initNativeClient() error {
 	if _, ok := sc.Client.(*ssh.NativeClient); ok {
 
-		slog.Debug("Authenticating using password and SSH key: ", "path", sc.SshKeyPath)
-		auth := &ssh.Auth{
-			Passwords: []string{sc.SshPassword},
-			Keys:      []string{sc.SshKeyPath},
+		auth := &ssh.Auth{}
+		if sc.SshPassword != "" {
+			auth.Passwords = []string{sc.SshPassword}
+		}
+		// Native client fails on key files which do not exist, e.g. the driver key before exchange
+		for _, keyPath := range []string{sc.SshKeyPath, sc.BootstrapKeyPath} {
+			if _, err := os.Stat(keyPath); keyPath != "" && err == nil {
+				auth.Keys = append(auth.Keys, keyPath)
+			}
 		}
+		slog.Debug("Authenticating using SSH keys: ", "paths", auth.Keys, "password", len(auth.Passwords) > 0)
 
 		nativeClient, err := ssh.NewNativeClient(sc.UserName, sc.HostName, port, auth)
 		if err != nil {
@@ -328,6 +367,115 @@ func (sc *StandardSshManager) RebootCloudInit() error {
 	return nil
 }
 
+/*
+BootId Returns ID the kernel generates on every boot, so a reboot of the machine can be told apart from the running system.
+Dials the machine only once so that callers polling for a reboot decide themselves how long to wait.
+*/
+func (sc *StandardSshManager) BootId() (string, error) {
+	client, err := sc.dialPinnedHost()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	output, err := session.Output(cmdReadBootId)
+	if err != nil {
+		return "", fmt.Errorf("failed to read boot ID: %w", err)
+	}
+
+	bootId := strings.TrimSpace(string(output))
+	if bootId == "" {
+		return "", errors.New("no boot ID received")
+	}
+	return bootId, nil
+}
+
+// CheckHostKey Dials the machine once and verifies that it presents the pinned host key.
+// Returns ErrHostKeyMismatch when the machine answers with a different host key.
+func (sc *StandardSshManager) CheckHostKey() error {
+	client, err := sc.dialPinnedHost()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	slog.Debug("SSH server presents the pinned host key: ", "host", sc.HostName)
+	return nil
+}
+
+// dialPinnedHost Dials the machine once with bounded timeout, accepting only the pinned host key
+func (sc *StandardSshManager) dialPinnedHost() (*gossh.Client, error) {
+	config := sc.getSshClientConfig()
+	config.Timeout = SSH_DIAL_TIMEOUT
+	config.HostKeyCallback = sc.pinnedHostKeyCallback
+
+	client, err := sshDial("tcp", fmt.Sprintf("%s:%d", sc.HostName, port), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial SSH server: %w", err)
+	}
+	return client, nil
+}
+
+// SetHostPublicKey Pin another host key for later connections, e.g. after the machine got a new host key.
+// The host key is verified again on the next command.
+func (sc *StandardSshManager) SetHostPublicKey(hostPublicKey gossh.PublicKey) {
+	sc.HostPublicKey = hostPublicKey
+	publicKeyIsValid = false
+	slog.Info("Pinned SSH host key changed: ", "host", sc.HostName, "fingerprint", gossh.FingerprintSHA256(hostPublicKey))
+}
+
+// pinnedHostKeyCallback Accepts only the pinned host key
+func (sc *StandardSshManager) pinnedHostKeyCallback(_ string, _ net.Addr, key gossh.PublicKey) error {
+	if !bytes.Equal(key.Marshal(), sc.HostPublicKey.Marshal()) {
+		return ErrHostKeyMismatch
+	}
+	return nil
+}
+
+/*
+WaitCloudInit Waits until cloud-init finishes its run on the machine.
+When cloud-init reports errors, returns ErrCloudInitFailed with output of 'cloud-init status --long';
+any other error means the status could not be read, e.g. because the machine is still rebooting.
+*/
+func (sc *StandardSshManager) WaitCloudInit() error {
+	output, err := sc.runCommand(cmdCloudInitStatusWait)
+
+	var exitErr *gossh.ExitError
+	switch {
+	case err == nil && strings.Contains(output, "status: done"):
+		slog.Info("Cloud-init finished successfully")
+		return nil
+	case err == nil && strings.TrimSpace(output) == "":
+		return errors.New("no cloud-init status received")
+	case err != nil && !errors.As(err, &exitErr):
+		return err
+	}
+
+	details, longErr := sc.runCommand(cmdCloudInitStatusLong)
+	if longErr != nil {
+		details = output
+	}
+	slog.Error("Cloud-init reported errors: ", "status", details)
+	return fmt.Errorf("%w:\n%s", ErrCloudInitFailed, strings.TrimSpace(details))
+}
+
+// ShutdownOS powers off the operating system of the machine
+func (sc *StandardSshManager) ShutdownOS() error {
+	_, err := sc.runCommand(cmdShutdownOS)
+	if err != nil {
+		slog.Error("Error executing OS shutdown: ", "err", err)
+		return err
+	}
+	slog.Info("OS shutdown executed successfully")
+	return nil
+}
+
 // transferSSHKeyToMachine is responsible for transferring existing SSH key to newly created machine
 func (sc *StandardSshManager) transferSSHKeyToMachine() error {
 
@@ -437,44 +585,52 @@ func (sc *StandardSshManager) ExecuteScript(scriptPath, scriptContent string, po
 func (sc *StandardSshManager) WriteFileOnRemoteMachine(path, fileContent string, fileMode os.FileMode) error {
 
 	if _, ok := sc.Client.(*ssh.NativeClient); ok {
-		config := sc.getSshClientConfig()
-		address := fmt.Sprintf("%s:%d", sc.HostName, port)
-		conn, err := gossh.Dial("tcp", address, config)
+		sftpClient, closeSftp, err := sftpConnect(sc)
 		if err != nil {
-			slog.Error("Failed to dial SSH host: ", "host", sc.HostName, "err", err)
 			return err
 		}
+		defer closeSftp()
 
-		sftpClient, err := sftp.NewClient(conn)
-		if err != nil {
-			slog.Error("Failed to create SFTP client: ", "host", sc.HostName, "err", err)
+		if err := sc.writeRemoteFile(sftpClient, path, bytes.NewBufferString(fileContent), 0, fileMode); err != nil {
 			return err
 		}
-		defer sftpClient.Close()
+	}
 
-		dstFile, err := sftpClient.Create(path)
-		if err != nil {
-			slog.Error("Failed to create remote file: ", "host", sc.HostName, "path", path, "err", err)
-			return err
-		}
-		defer dstFile.Close()
+	return nil
+}
 
-		err = dstFile.Chmod(fileMode)
-		if err != nil {
-			slog.Error("Failed to change permissions: ", "permissions", fileMode.String(), "err", err)
-			return err
-		}
-		slog.Info("Successfully added permissions to file: ", "file", dstFile.Name(), "permissions", fileMode.String())
+// writeRemoteFile Streams content of src to file defined in path starting at offset; file is truncated when offset is 0
+func (sc *StandardSshManager) writeRemoteFile(sftpClient *sftp.Client, path string, src io.Reader, offset int64, fileMode os.FileMode) error {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
 
-		srcBuffer := bytes.NewBuffer([]byte(fileContent))
-		_, err = io.Copy(dstFile, srcBuffer)
-		if err != nil {
-			slog.Error("Failed to copy data: ", "host", sc.HostName, "err", err)
-			return err
-		}
-		slog.Info("File content successfully written to remote destination: ", "dstFile", dstFile.Name())
+	dstFile, err := sftpClient.OpenFile(path, flags)
+	if err != nil {
+		slog.Error("Failed to create remote file: ", "host", sc.HostName, "path", path, "err", err)
+		return err
+	}
+	defer dstFile.Close()
+
+	err = dstFile.Chmod(fileMode)
+	if err != nil {
+		slog.Error("Failed to change permissions: ", "permissions", fileMode.String(), "err", err)
+		return err
 	}
+	slog.Info("Successfully added permissions to file: ", "file", dstFile.Name(), "permissions", fileMode.String())
 
+	if _, err := dstFile.Seek(offset, io.SeekStart); err != nil {
+		slog.Error("Failed to seek in remote file: ", "path", path, "offset", offset, "err", err)
+		return err
+	}
+
+	_, err = io.Copy(dstFile, src)
+	if err != nil {
+		slog.Error("Failed to copy data: ", "host", sc.HostName, "err", err)
+		return err
+	}
+	slog.Info("File content successfully written to remote destination: ", "dstFile", dstFile.Name())
 	return nil
 }
 
@@ -534,91 +690,64 @@ func (sc *StandardSshManager) removeRemoteFile(path string, runWithSudo bool) er
 	return nil
 }
 
-// RegisterOS - Registers SLES OS license using SUSEConnect
-func (sc *StandardSshManager) RegisterOS(regcode, email string) error {
-	if regcode == "" {
-		slog.Info("OS registration skipped: no registration code provided.")
-		return nil
+// osRegistrar Returns registrar of given name; 'auto' or empty name detects it from /etc/os-release of the machine
+func (sc *StandardSshManager) osRegistrar(name string) (OsRegistrar, error) {
+	if name != "" && name != OS_REGISTRAR_AUTO {
+		return NewOsRegistrar(name)
 	}
 
-	// Step 1: Perform the initial base registration.
-	slog.Info("Attempting initial OS registration: ", "email", email)
-	initialRegCommand := fmt.Sprintf(cmdRegisterOS, regcode, email)
-	if _, err := sc.runCommand(initialRegCommand); err != nil {
-		slog.Error("Error executing initial OS registration: ", "err", err)
-		return err
+	osRelease, err := sc.runCommand(cmdReadOsRelease)
+	if err != nil {
+		return nil, fmt.Errorf("could not read /etc/os-release to detect OS registrar: %w", err)
 	}
-	slog.Info("Initial OS registration successful")
+	return DetectOsRegistrar(osRelease)
+}
 
-	// Step 2: Get the status of all products in JSON format.
-	slog.Info("Fetching status of all SUSE modules...")
-	jsonOutput, err := sc.runCommand(cmdGetStatusOS)
-	if err != nil {
-		slog.Error("Error fetching SUSE product status: ", "err", err)
-		return err
+// RegisterOS - Registers OS license using registrar chosen in registration or detected from the OS
+func (sc *StandardSshManager) RegisterOS(registration models.OsRegistration) error {
+	if registration.Code == "" && registration.ServerUrl == "" {
+		slog.Info("OS registration skipped: no registration code or server provided.")
+		return nil
 	}
 
-	// Step 3: Parse the JSON response.
-	var products []models.SuseProduct
-	if err := json.Unmarshal([]byte(jsonOutput), &products); err != nil {
-		slog.Error("Error parsing SUSE product status JSON: ", "err", err)
+	registrar, err := sc.osRegistrar(registration.Registrar)
+	if err != nil {
+		slog.Error("Error choosing OS registrar: ", "err", err)
 		return err
 	}
 
-	// Step 4: Loop through products and register any that are not registered.
-	slog.Info("Checking for unregistered modules...")
-	for _, product := range products {
-		if product.Status == "Not Registered" {
-			productString := fmt.Sprintf("%s/%s/%s", product.Identifier, product.Version, product.Arch)
-			slog.Info("Found unregistered module. Attempting to register: ", "module", productString)
-
-			moduleRegCommand := fmt.Sprintf(cmdRegisterModuleOS, productString)
-			if _, err := sc.runCommand(moduleRegCommand); err != nil {
-				slog.Error("Error registering module: ", "module", productString, "err", err)
-				return err
-			}
-			slog.Info("Successfully registered module: ", "module", productString)
-		}
+	if err := registrar.Register(sc.runCommand, registration); err != nil {
+		return err
 	}
 
-	slog.Info("OS and all modules registered successfully")
-
+	slog.Info("OS registered successfully: ", "registrar", registrar.Name())
 	return nil
 }
 
-// DeregisterOS - De-registers SLES OS using SUSEConnect
-func (sc *StandardSshManager) DeregisterOS() error {
-	jsonOutput, err := sc.runCommand(cmdGetStatusOS)
+// DeregisterOS - De-registers OS using registrar chosen in registration or detected from the OS, if it is registered
+func (sc *StandardSshManager) DeregisterOS(registration models.OsRegistration) error {
+	registrar, err := sc.osRegistrar(registration.Registrar)
 	if err != nil {
-		slog.Error("Could not get SUSE product status before deregistration:", "err", err)
-		return fmt.Errorf("could not get SUSE product status before deregistration: %w", err)
-	}
-
-	var products []models.SuseProduct
-	if err := json.Unmarshal([]byte(jsonOutput), &products); err != nil {
-		slog.Error("Failed to parse SUSE status JSON before deregistration:", "err", err)
-		return fmt.Errorf("failed to parse SUSE status JSON before deregistration: %w", err)
+		slog.Error("Error choosing OS registrar before deregistration: ", "err", err)
+		return err
 	}
 
-	anyRegistered := false
-	for _, p := range products {
-		if p.Status == "Registered" {
-			anyRegistered = true
-			break
-		}
+	registered, err := registrar.Status(sc.runCommand)
+	if err != nil {
+		slog.Error("Could not get OS registration status before deregistration:", "registrar", registrar.Name(), "err", err)
+		return fmt.Errorf("could not get OS registration status before deregistration: %w", err)
 	}
 
 	// Only deregister if something was registered
-	if !anyRegistered {
-		slog.Info("Skipping OS deregistration: no products registered.")
+	if !registered {
+		slog.Info("Skipping OS deregistration: OS is not registered.", "registrar", registrar.Name())
 		return nil
 	}
 
-	_, err = sc.runCommand(cmdDeregisterOS)
-	if err != nil {
-		slog.Error("Error executing SLES OS deregistration:", "err", err)
-		return fmt.Errorf("error executing SLES OS deregistration: %w", err)
+	if err := registrar.Deregister(sc.runCommand, registration); err != nil {
+		slog.Error("Error executing OS deregistration:", "registrar", registrar.Name(), "err", err)
+		return fmt.Errorf("error executing OS deregistration: %w", err)
 	}
-	slog.Info("SLES OS deregistered successfully")
+	slog.Info("OS deregistered successfully", "registrar", registrar.Name())
 	return nil
 }
@@ -7,8 +7,10 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -151,13 +153,26 @@ func TestParseSSHPublicKeyBase64PartDoubleQuoted(t *testing.T) {
 }
 
 func TestNewStandardSshManagerNilHostPublicKey(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", nil)
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", nil)
 	assert.Nil(t, manager)
 	assert.ErrorIs(t, err, ErrNoneOfConstructorArgsCanBeEmpty)
 }
 
+func TestNewStandardSshManagerWithoutPasswordAndBootstrapKey(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "", "mock/path", "", parsedHostPublicKey(t))
+	assert.Nil(t, manager)
+	assert.ErrorIs(t, err, ErrPasswordOrBootstrapKeyRequired)
+}
+
+func TestNewStandardSshManagerBootstrapKeyWithoutPassword(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "", "mock/path", "mock/bootstrap", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	assert.Equal(t, "mock/bootstrap", manager.BootstrapKeyPath)
+	assert.Empty(t, manager.SshPassword)
+}
+
 func Test_runCommand_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
@@ -173,7 +188,7 @@ func Test_runCommand_Success(t *testing.T) {
 }
 
 func Test_runCommand_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{
@@ -191,7 +206,7 @@ func Test_runCommand_Fail(t *testing.T) {
 }
 
 func Test_runCommand_Success_Missing_Exit(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{
@@ -232,7 +247,7 @@ func Test_createSSHKey(t *testing.T) {
 }
 
 func Test_transferSSHKeyToMachineOpenFail(t *testing.T) {
-	sc, err := NewStandardSshManager("host", "user", "password", "mock/path/to/key", parsedHostPublicKey(t))
+	sc, err := NewStandardSshManager("host", "user", "password", "mock/path/to/key", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	err = sc.transferSSHKeyToMachine()
@@ -242,7 +257,7 @@ func Test_transferSSHKeyToMachineOpenFail(t *testing.T) {
 }
 
 func Test_transferSSHKeyToMachine(t *testing.T) {
-	sc, err := NewStandardSshManager("host", "user", "password", "mock/path/to/key", parsedHostPublicKey(t))
+	sc, err := NewStandardSshManager("host", "user", "password", "mock/path/to/key", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{}
@@ -268,7 +283,7 @@ func Test_transferSSHKeyToMachine(t *testing.T) {
 }
 
 func TestWriteFileOnRemoteMachine_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.Client = &MockSSHClient{}
 	manager.SshKeyPath = ""
@@ -278,7 +293,7 @@ func TestWriteFileOnRemoteMachine_Success(t *testing.T) {
 }
 
 func Test_executeRemoteFile_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
@@ -291,7 +306,7 @@ func Test_executeRemoteFile_Success(t *testing.T) {
 }
 
 func Test_executeRemoteFile_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
@@ -308,7 +323,7 @@ func Test_executeRemoteFile_Fail(t *testing.T) {
 }
 
 func Test_removeRemoteFile_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
@@ -321,7 +336,7 @@ func Test_removeRemoteFile_Success(t *testing.T) {
 }
 
 func Test_removeRemoteFile_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
@@ -351,7 +366,7 @@ func TestGenerateSecureRandomInt_InvalidRange(t *testing.T) {
 }
 
 func Test_ExecuteScript_RemovesScriptAndDirectory(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{
@@ -379,7 +394,7 @@ func Test_ExecuteScript_RemovesScriptAndDirectory(t *testing.T) {
 }
 
 func Test_createRemoteDir(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
@@ -392,7 +407,7 @@ func Test_createRemoteDir(t *testing.T) {
 }
 
 func Test_getRandomScriptPath(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
@@ -406,7 +421,7 @@ func Test_getRandomScriptPath(t *testing.T) {
 }
 
 func Test_removeRemoteDir(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{}
@@ -420,7 +435,7 @@ func Test_removeRemoteDir(t *testing.T) {
 }
 
 func Test_DisablePasswordSSHLogin_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{}
@@ -440,7 +455,7 @@ func Test_DisablePasswordSSHLogin_Success(t *testing.T) {
 }
 
 func Test_DisablePasswordSSHLogin_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{
@@ -462,7 +477,7 @@ func Test_DisablePasswordSSHLogin_Fail(t *testing.T) {
 }
 
 func Test_RebootCloudInit_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{}
@@ -477,7 +492,7 @@ func Test_RebootCloudInit_Success(t *testing.T) {
 }
 
 func Test_RebootCloudInit_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 
 	mockClient := &MockSSHClient{
@@ -492,15 +507,134 @@ func Test_RebootCloudInit_Fail(t *testing.T) {
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
 }
 
+// dialBootIdServer Returns sshDial replacement connecting to local SSH server answering every command with output
+func dialBootIdServer(t *testing.T, hostSigner gossh.Signer, output string) func(string, string, *gossh.ClientConfig) (*gossh.Client, error) {
+	t.Helper()
+	serverConfig := &gossh.ServerConfig{
+		PasswordCallback: func(gossh.ConnMetadata, []byte) (*gossh.Permissions, error) { return nil, nil },
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return func(network, _ string, config *gossh.ClientConfig) (*gossh.Client, error) {
+		go func() {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, channels, requests, err := gossh.NewServerConn(serverConn, serverConfig)
+			if err != nil {
+				return
+			}
+			go gossh.DiscardRequests(requests)
+			for newChannel := range channels {
+				channel, channelRequests, err := newChannel.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					for request := range channelRequests {
+						_ = request.Reply(request.Type == "exec", nil)
+						if request.Type == "exec" {
+							_, _ = channel.Write([]byte(output))
+							_, _ = channel.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{0}))
+							_ = channel.Close()
+						}
+					}
+				}()
+			}
+		}()
+
+		return gossh.Dial(network, listener.Addr().String(), config)
+	}
+}
+
+func Test_BootId(t *testing.T) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := gossh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", hostSigner.PublicKey())
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	originalSshDial := sshDial
+	t.Cleanup(func() { sshDial = originalSshDial })
+
+	sshDial = dialBootIdServer(t, hostSigner, "8c1f1d6e-5d3b-4c7a-9a43-4f5ed0b1e8a2\n")
+	bootId, err := manager.BootId()
+	assert.NoError(t, err)
+	assert.Equal(t, "8c1f1d6e-5d3b-4c7a-9a43-4f5ed0b1e8a2", bootId)
+
+	sshDial = dialBootIdServer(t, hostSigner, "")
+	_, err = manager.BootId()
+	assert.EqualError(t, err, "no boot ID received")
+}
+
+func Test_BootId_DialsOnce(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	originalSshDial := sshDial
+	t.Cleanup(func() { sshDial = originalSshDial })
+	dials := 0
+	sshDial = func(network, address string, config *gossh.ClientConfig) (*gossh.Client, error) {
+		dials++
+		assert.Equal(t, SSH_DIAL_TIMEOUT, config.Timeout)
+		return nil, errors.New("connection refused")
+	}
+
+	_, err = manager.BootId()
+
+	assert.ErrorContains(t, err, "failed to dial SSH server: connection refused")
+	assert.Equal(t, 1, dials)
+}
+
+func Test_ShutdownOS_Success(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+
+	mockClient := &MockSSHClient{}
+	manager.Client = mockClient
+	manager.SshKeyPath = ""
+
+	err = manager.ShutdownOS()
+	assert.NoError(t, err)
+
+	require.Len(t, mockClient.ExecutedCommands, 1)
+	assert.Equal(t, cmdShutdownOS, mockClient.ExecutedCommands[0])
+}
+
+func Test_ShutdownOS_Fail(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+
+	mockClient := &MockSSHClient{
+		OutputFunc: func(cmd string) (string, error) {
+			return "", MOCK_ERROR_FOR_OUTPUT_METHOD
+		},
+	}
+	manager.Client = mockClient
+	manager.SshKeyPath = ""
+
+	err = manager.ShutdownOS()
+	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
+}
+
 func TestRegisterOS_SuccessWithUnregisteredModules(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
 	mockRegcode := "somecode0101001"
 	mockEmail := "hoge@example.com"
-	initialRegCmd := fmt.Sprintf(cmdRegisterOS, mockRegcode, mockEmail)
-	getStatusCmd := cmdGetStatusOS
+	initialRegCmd := fmt.Sprintf(cmdSuseConnectRegister, mockRegcode, mockEmail)
+	getStatusCmd := cmdSuseConnectStatus
 	moduleRegCmd := "sudo -E SUSEConnect -p sle-module-public-cloud/15.6/x86_64"
 
 	products := []models.SuseProduct{
@@ -524,7 +658,7 @@ func TestRegisterOS_SuccessWithUnregisteredModules(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS(mockRegcode, mockEmail)
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: mockRegcode, Email: mockEmail})
 
 	assert.NoError(t, err)
 	expectedCommands := []string{initialRegCmd, getStatusCmd, moduleRegCmd}
@@ -532,7 +666,7 @@ func TestRegisterOS_SuccessWithUnregisteredModules(t *testing.T) {
 }
 
 func TestRegisterOS_SuccessAllModulesRegistered(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
@@ -544,7 +678,7 @@ func TestRegisterOS_SuccessAllModulesRegistered(t *testing.T) {
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
-			if command == cmdGetStatusOS {
+			if command == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
 			return "", nil
@@ -552,32 +686,32 @@ func TestRegisterOS_SuccessAllModulesRegistered(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS(mockRegcode, mockEmail)
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: mockRegcode, Email: mockEmail})
 
 	assert.NoError(t, err)
 	expectedCommands := []string{
-		fmt.Sprintf(cmdRegisterOS, mockRegcode, mockEmail),
-		cmdGetStatusOS,
+		fmt.Sprintf(cmdSuseConnectRegister, mockRegcode, mockEmail),
+		cmdSuseConnectStatus,
 	}
 	assert.Equal(t, expectedCommands, mockClient.ExecutedCommands)
 }
 
 func TestRegisterOS_SkipWithNoRegcode(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
 	mockClient := &MockSSHClient{}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS("", "hoge@example.com")
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: "", Email: "hoge@example.com"})
 
 	assert.NoError(t, err)
 	assert.Empty(t, mockClient.ExecutedCommands)
 }
 
 func TestRegisterOS_FailOnInitialRegistration(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
@@ -588,21 +722,21 @@ func TestRegisterOS_FailOnInitialRegistration(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS("somecode", "email")
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: "somecode", Email: "email"})
 
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
 	require.Len(t, mockClient.ExecutedCommands, 1)
-	assert.Equal(t, fmt.Sprintf(cmdRegisterOS, "somecode", "email"), mockClient.ExecutedCommands[0])
+	assert.Equal(t, fmt.Sprintf(cmdSuseConnectRegister, "somecode", "email"), mockClient.ExecutedCommands[0])
 }
 
 func TestRegisterOS_FailOnGetStatus(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
-			if command == cmdGetStatusOS {
+			if command == cmdSuseConnectStatus {
 				return "", MOCK_ERROR_FOR_OUTPUT_METHOD
 			}
 			return "", nil
@@ -610,20 +744,20 @@ func TestRegisterOS_FailOnGetStatus(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS("somecode", "email")
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: "somecode", Email: "email"})
 
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
 	assert.Len(t, mockClient.ExecutedCommands, 2)
 }
 
 func TestRegisterOS_FailOnInvalidJSON(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
-			if command == cmdGetStatusOS {
+			if command == cmdSuseConnectStatus {
 				return "this is not valid json", nil
 			}
 			return "", nil
@@ -631,16 +765,16 @@ func TestRegisterOS_FailOnInvalidJSON(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS("somecode", "email")
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: "somecode", Email: "email"})
 
 	require.Error(t, err)
-	_, isJsonError := err.(*json.SyntaxError)
-	assert.True(t, isJsonError, "error should be a JSON syntax error")
+	var syntaxErr *json.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr, "error should be a JSON syntax error")
 	assert.Len(t, mockClient.ExecutedCommands, 2)
 }
 
 func TestRegisterOS_FailOnModuleRegistration(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	require.NoError(t, err)
 	manager.SshKeyPath = ""
 
@@ -650,7 +784,7 @@ func TestRegisterOS_FailOnModuleRegistration(t *testing.T) {
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
-			if command == cmdGetStatusOS {
+			if command == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
 			if strings.HasPrefix(command, "sudo -E SUSEConnect -p") {
@@ -661,7 +795,7 @@ func TestRegisterOS_FailOnModuleRegistration(t *testing.T) {
 	}
 	manager.Client = mockClient
 
-	err = manager.RegisterOS("somecode", "email")
+	err = manager.RegisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT, Code: "somecode", Email: "email"})
 
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
 	assert.Len(t, mockClient.ExecutedCommands, 3)
@@ -792,14 +926,44 @@ func Test_getSshClientConfig_PassAndKey(t *testing.T) {
 	mockParser.AssertExpectations(t)
 }
 
+func Test_getSshClientConfig_BootstrapKeyOnly(t *testing.T) {
+	mockParser := mock.NewMockSSHKeyParser(t)
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPub, err := gossh.NewPublicKey(pub)
+	require.NoError(t, err)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	// Driver key does not exist before the key exchange
+	mockParser.On("Parse", "/path/to/driver/key").Return(nil)
+	mockParser.On("Parse", "/path/to/bootstrap/key").Return(signer)
+
+	manager := &StandardSshManager{
+		UserName:         "testuser",
+		SshKeyPath:       "/path/to/driver/key",
+		BootstrapKeyPath: "/path/to/bootstrap/key",
+		HostPublicKey:    sshPub,
+		keyParser:        mockParser,
+	}
+
+	config := manager.getSshClientConfig()
+
+	// Only public key authentication, password login is disabled on the image
+	assert.Len(t, config.Auth, 1)
+	mockParser.AssertExpectations(t)
+}
+
 func Test_DeregisterOS_Success(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 	emptyProducts := []models.SuseProduct{}
 	jsonOutput, _ := json.Marshal(emptyProducts)
 	mockClient := &MockSSHClient{
 		OutputFunc: func(cmd string) (string, error) {
-			if cmd == cmdGetStatusOS {
+			if cmd == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
 			return "", fmt.Errorf("unexpected command")
@@ -808,14 +972,14 @@ func Test_DeregisterOS_Success(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.NoError(t, err)
 
-	assert.Equal(t, []string{cmdGetStatusOS}, mockClient.ExecutedCommands)
+	assert.Equal(t, []string{cmdSuseConnectStatus}, mockClient.ExecutedCommands)
 }
 
 func Test_DeregisterOS_Fail(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 	mockClient := &MockSSHClient{
 		OutputFunc: func(command string) (string, error) {
@@ -825,14 +989,14 @@ func Test_DeregisterOS_Fail(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
-	assert.ErrorContains(t, err, "could not get SUSE product status before deregistration")
-	assert.Equal(t, []string{cmdGetStatusOS}, mockClient.ExecutedCommands)
+	assert.ErrorContains(t, err, "could not get OS registration status before deregistration")
+	assert.Equal(t, []string{cmdSuseConnectStatus}, mockClient.ExecutedCommands)
 }
 
 func Test_DeregisterOS_Skip_When_NoRegisteredProducts(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 	products := []models.SuseProduct{
 		{Identifier: "SLES", Version: "15.6", Arch: "x86_64", Status: "Not Registered"},
@@ -841,7 +1005,7 @@ func Test_DeregisterOS_Skip_When_NoRegisteredProducts(t *testing.T) {
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(cmd string) (string, error) {
-			if cmd == cmdGetStatusOS {
+			if cmd == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
 			return "", fmt.Errorf("unexpected command")
@@ -850,14 +1014,14 @@ func Test_DeregisterOS_Skip_When_NoRegisteredProducts(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.NoError(t, err)
 
-	assert.Equal(t, []string{cmdGetStatusOS}, mockClient.ExecutedCommands)
+	assert.Equal(t, []string{cmdSuseConnectStatus}, mockClient.ExecutedCommands)
 }
 
 func Test_DeregisterOS_Run_WhenRegistered(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 	products := []models.SuseProduct{
 		{Identifier: "SLES", Version: "15.6", Arch: "x86_64", Status: "Registered"},
@@ -866,10 +1030,10 @@ func Test_DeregisterOS_Run_WhenRegistered(t *testing.T) {
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(cmd string) (string, error) {
-			if cmd == cmdGetStatusOS {
+			if cmd == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
-			if cmd == cmdDeregisterOS {
+			if cmd == cmdSuseConnectDeregister {
 				return "", nil
 			}
 			return "", fmt.Errorf("unexpected command")
@@ -878,19 +1042,19 @@ func Test_DeregisterOS_Run_WhenRegistered(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.NoError(t, err)
 
-	assert.Equal(t, []string{cmdGetStatusOS, cmdDeregisterOS}, mockClient.ExecutedCommands)
+	assert.Equal(t, []string{cmdSuseConnectStatus, cmdSuseConnectDeregister}, mockClient.ExecutedCommands)
 }
 
 func Test_DeregisterOS_Fail_JsonParse(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(cmd string) (string, error) {
-			if cmd == cmdGetStatusOS {
+			if cmd == cmdSuseConnectStatus {
 				return "invalid-json", nil
 			}
 			return "", fmt.Errorf("unexpected command")
@@ -899,14 +1063,14 @@ func Test_DeregisterOS_Fail_JsonParse(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.Error(t, err)
-	assert.ErrorContains(t, err, "failed to parse SUSE status JSON before deregistration")
-	assert.Equal(t, []string{cmdGetStatusOS}, mockClient.ExecutedCommands)
+	assert.ErrorContains(t, err, "failed to parse SUSE status JSON")
+	assert.Equal(t, []string{cmdSuseConnectStatus}, mockClient.ExecutedCommands)
 }
 
 func Test_DeregisterOS_Fail_DeregistrationCommand(t *testing.T) {
-	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", parsedHostPublicKey(t))
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
 	assert.NoError(t, err)
 	products := []models.SuseProduct{
 		{Identifier: "SLES", Version: "15.6", Arch: "x86_64", Status: "Registered"},
@@ -915,10 +1079,10 @@ func Test_DeregisterOS_Fail_DeregistrationCommand(t *testing.T) {
 
 	mockClient := &MockSSHClient{
 		OutputFunc: func(cmd string) (string, error) {
-			if cmd == cmdGetStatusOS {
+			if cmd == cmdSuseConnectStatus {
 				return string(jsonOutput), nil
 			}
-			if cmd == cmdDeregisterOS {
+			if cmd == cmdSuseConnectDeregister {
 				return "", MOCK_ERROR_FOR_OUTPUT_METHOD
 			}
 			return "", fmt.Errorf("unexpected command")
@@ -927,8 +1091,110 @@ func Test_DeregisterOS_Fail_DeregistrationCommand(t *testing.T) {
 	manager.Client = mockClient
 	manager.SshKeyPath = ""
 
-	err = manager.DeregisterOS()
+	err = manager.DeregisterOS(models.OsRegistration{Registrar: OS_REGISTRAR_SUSECONNECT})
 	assert.ErrorIs(t, err, MOCK_ERROR_FOR_OUTPUT_METHOD)
-	assert.ErrorContains(t, err, "error executing SLES OS deregistration")
-	assert.Equal(t, []string{cmdGetStatusOS, cmdDeregisterOS}, mockClient.ExecutedCommands)
+	assert.ErrorContains(t, err, "error executing OS deregistration")
+	assert.Equal(t, []string{cmdSuseConnectStatus, cmdSuseConnectDeregister}, mockClient.ExecutedCommands)
+}
+
+func Test_pinnedHostKeyCallback(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	assert.NoError(t, manager.pinnedHostKeyCallback("host1:22", nil, parsedHostPublicKey(t)))
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := gossh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+	assert.ErrorIs(t, manager.pinnedHostKeyCallback("host1:22", nil, otherSigner.PublicKey()), ErrHostKeyMismatch)
+}
+
+func TestCheckHostKey_DialFails(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	originalSshDial := sshDial
+	t.Cleanup(func() { sshDial = originalSshDial })
+	var dialedAddress string
+	sshDial = func(network, address string, config *gossh.ClientConfig) (*gossh.Client, error) {
+		dialedAddress = address
+		assert.Equal(t, SSH_DIAL_TIMEOUT, config.Timeout)
+		return nil, fmt.Errorf("ssh: handshake failed: %w", ErrHostKeyMismatch)
+	}
+
+	err = manager.CheckHostKey()
+
+	assert.ErrorIs(t, err, ErrHostKeyMismatch)
+	assert.Equal(t, "host1:22", dialedAddress)
+}
+
+func TestWaitCloudInit(t *testing.T) {
+	tests := []struct {
+		name         string
+		waitOutput   string
+		waitErr      error
+		longOutput   string
+		wantErr      string
+		wantFailed   bool
+		wantCommands []string
+	}{
+		{name: "done", waitOutput: "status: done\n", wantCommands: []string{cmdCloudInitStatusWait}},
+		{name: "errors", waitOutput: "status: error\n", waitErr: &gossh.ExitError{},
+			longOutput: "status: error\nerrors:\n\t- runcmd failed\n", wantFailed: true,
+			wantErr:      "cloud-init reported errors:\nstatus: error\nerrors:\n\t- runcmd failed",
+			wantCommands: []string{cmdCloudInitStatusWait, cmdCloudInitStatusLong}},
+		{name: "disabled", waitOutput: "status: disabled\n", longOutput: "status: disabled\n", wantFailed: true,
+			wantErr:      "cloud-init reported errors:\nstatus: disabled",
+			wantCommands: []string{cmdCloudInitStatusWait, cmdCloudInitStatusLong}},
+		{name: "machine not reachable", wantErr: "no cloud-init status received", wantCommands: []string{cmdCloudInitStatusWait}},
+		{name: "connection dropped", waitErr: MOCK_ERROR_FOR_OUTPUT_METHOD, wantErr: MOCK_ERROR_FOR_OUTPUT_METHOD.Error(),
+			wantCommands: []string{cmdCloudInitStatusWait}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+			require.NoError(t, err)
+			manager.SshKeyPath = ""
+			mockClient := &MockSSHClient{
+				OutputFunc: func(command string) (string, error) {
+					if command == cmdCloudInitStatusLong {
+						return tt.longOutput, nil
+					}
+					return tt.waitOutput, tt.waitErr
+				},
+			}
+			manager.Client = mockClient
+
+			err = manager.WaitCloudInit()
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantFailed, errors.Is(err, ErrCloudInitFailed))
+			assert.Equal(t, tt.wantCommands, mockClient.ExecutedCommands)
+		})
+	}
+}
+
+func TestSetHostPublicKey(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { publicKeyIsValid = true })
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := gossh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+
+	manager.SetHostPublicKey(otherSigner.PublicKey())
+
+	assert.Equal(t, otherSigner.PublicKey(), manager.HostPublicKey)
+	assert.False(t, publicKeyIsValid)
+	assert.ErrorIs(t, manager.pinnedHostKeyCallback("host1:22", nil, parsedHostPublicKey(t)), ErrHostKeyMismatch)
 }
@@ -0,0 +1,201 @@
+package sshutils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+)
+
+const (
+	SUSE_MODULES_ALL  = "all"
+	SUSE_MODULES_NONE = "none"
+
+	cmdSuseConnectListExtensions  = "sudo -E SUSEConnect --list-extensions"
+	cmdSuseConnectRegisterModCode = "sudo -E SUSEConnect -r %s -p %s"
+)
+
+var (
+	// suseModuleProfiles lists modules registered for a profile; modules they require are added from the extension tree
+	suseModuleProfiles = map[string][]string{
+		"containers": {"sle-module-containers"},
+		"hpc":        {"sle-module-hpc"},
+	}
+	suseModuleIdentifier = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+	// suseExtensionLine matches activation hint of an extension in 'SUSEConnect --list-extensions' output,
+	// its indentation tells which extension it requires
+	suseExtensionLine = regexp.MustCompile(`^(\s*)(Activate|Deactivate) with: SUSEConnect (?:-d )?-p (\S+)`)
+)
+
+// suseExtension is a module or extension found in 'SUSEConnect --list-extensions' output
+type suseExtension struct {
+	Product   string // identifier/version/arch
+	Requires  string // Identifier of the extension it is nested under
+	Activated bool
+}
+
+/*
+ParseSuseModules Parses comma separated modules and profiles to register. A module is given by its identifier,
+optionally followed by '=<regcode>' for paid extensions. Returns nil when all modules should be registered
+('all' or empty spec) and an empty slice for 'none'
+*/
+func ParseSuseModules(spec string) ([]models.SuseModule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == SUSE_MODULES_ALL {
+		return nil, nil
+	}
+	if spec == SUSE_MODULES_NONE {
+		return []models.SuseModule{}, nil
+	}
+
+	modules := []models.SuseModule{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == SUSE_MODULES_ALL || item == SUSE_MODULES_NONE {
+			return nil, fmt.Errorf("'%s' cannot be combined with other modules", item)
+		}
+
+		if profile, ok := suseModuleProfiles[item]; ok {
+			for _, identifier := range profile {
+				modules = append(modules, models.SuseModule{Identifier: identifier})
+			}
+			continue
+		}
+
+		identifier, regcode, _ := strings.Cut(item, "=")
+		if !suseModuleIdentifier.MatchString(identifier) {
+			return nil, fmt.Errorf("'%s' is neither a module identifier nor a profile", identifier)
+		}
+		modules = append(modules, models.SuseModule{Identifier: identifier, Regcode: strings.TrimSpace(regcode)})
+	}
+	return modules, nil
+}
+
+// RedactSuseModules Returns modules spec with registration codes of paid extensions hidden
+func RedactSuseModules(spec string) string {
+	items := strings.Split(spec, ",")
+	for i, item := range items {
+		if identifier, _, found := strings.Cut(item, "="); found {
+			items[i] = identifier + "=<hidden-for-security-reasons>"
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// parseSuseExtensions Returns extensions by identifier from 'SUSEConnect --list-extensions' output
+func parseSuseExtensions(output string) map[string]suseExtension {
+	type parent struct {
+		indent     int
+		identifier string
+	}
+
+	extensions := map[string]suseExtension{}
+	parents := []parent{}
+	for _, line := range strings.Split(output, "\n") {
+		match := suseExtensionLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		indent := len(match[1])
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+
+		identifier, _, _ := strings.Cut(match[3], "/")
+		extension := suseExtension{Product: match[3], Activated: match[2] == "Deactivate"}
+		if len(parents) > 0 {
+			extension.Requires = parents[len(parents)-1].identifier
+		}
+		extensions[identifier] = extension
+		parents = append(parents, parent{indent: indent, identifier: identifier})
+	}
+	return extensions
+}
+
+// orderSuseModules Returns modules preceded by extensions they require, each listed once
+func orderSuseModules(modules []models.SuseModule, extensions map[string]suseExtension) ([]models.SuseModule, error) {
+	ordered := []models.SuseModule{}
+	seen := map[string]int{}
+	for _, module := range modules {
+		chain := []models.SuseModule{module}
+		for identifier := module.Identifier; ; {
+			extension, ok := extensions[identifier]
+			if !ok {
+				return nil, fmt.Errorf("module '%s' is not available for the registered product", identifier)
+			}
+			if extension.Requires == "" {
+				break
+			}
+			identifier = extension.Requires
+			chain = append(chain, models.SuseModule{Identifier: identifier})
+		}
+
+		slices.Reverse(chain)
+		for _, m := range chain {
+			if i, ok := seen[m.Identifier]; ok {
+				if m.Regcode != "" {
+					ordered[i].Regcode = m.Regcode
+				}
+				continue
+			}
+			seen[m.Identifier] = len(ordered)
+			ordered = append(ordered, m)
+		}
+	}
+	return ordered, nil
+}
+
+// registerModules Registers selected modules and extensions they require in the order of dependency
+func (r *suseConnectRegistrar) registerModules(run CommandRunner, registration models.OsRegistration, modules []models.SuseModule) error {
+	if len(modules) == 0 {
+		slog.Info("Registration of SUSE modules skipped: no modules selected")
+		return nil
+	}
+
+	output, err := run(r.withServer(cmdSuseConnectListExtensions, registration))
+	if err != nil {
+		slog.Error("Error listing SUSE extensions: ", "err", err)
+		return err
+	}
+
+	extensions := parseSuseExtensions(output)
+	ordered, err := orderSuseModules(modules, extensions)
+	if err != nil {
+		return err
+	}
+
+	products, err := r.products(run)
+	if err != nil {
+		slog.Error("Error fetching SUSE product status: ", "err", err)
+		return err
+	}
+	registered := map[string]bool{}
+	for _, product := range products {
+		registered[product.Identifier] = product.Status == "Registered"
+	}
+
+	for _, module := range ordered {
+		extension := extensions[module.Identifier]
+		if registered[module.Identifier] || extension.Activated {
+			slog.Info("SUSE module already registered: ", "module", extension.Product)
+			continue
+		}
+
+		command := fmt.Sprintf(cmdSuseConnectRegisterMod, extension.Product)
+		if module.Regcode != "" {
+			command = fmt.Sprintf(cmdSuseConnectRegisterModCode, module.Regcode, extension.Product)
+		}
+
+		slog.Info("Attempting to register module: ", "module", extension.Product)
+		if _, err := run(r.withServer(command, registration)); err != nil {
+			slog.Error("Error registering module: ", "module", extension.Product, "err", err)
+			return err
+		}
+		slog.Info("Successfully registered module: ", "module", extension.Product)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package sshutils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fujitsu/docker-machine-driver-fsas/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const suseListExtensions = `
+AVAILABLE EXTENSIONS AND MODULES
+
+    Basesystem Module 15 SP6 x86_64 (Activated)
+    Deactivate with: SUSEConnect -d -p sle-module-basesystem/15.6/x86_64
+
+        Containers Module 15 SP6 x86_64
+        Activate with: SUSEConnect -p sle-module-containers/15.6/x86_64
+
+        Desktop Applications Module 15 SP6 x86_64
+        Activate with: SUSEConnect -p sle-module-desktop-applications/15.6/x86_64
+
+            Development Tools Module 15 SP6 x86_64
+            Activate with: SUSEConnect -p sle-module-development-tools/15.6/x86_64
+
+        Server Applications Module 15 SP6 x86_64
+        Activate with: SUSEConnect -p sle-module-server-applications/15.6/x86_64
+
+            SUSE Linux Enterprise High Availability Extension 15 SP6 x86_64
+            Activate with: SUSEConnect -p sle-ha/15.6/x86_64 -r ADDITIONAL REGCODE
+
+    HPC Module 15 SP6 x86_64
+    Activate with: SUSEConnect -p sle-module-hpc/15.6/x86_64
+
+REMARKS
+
+(Not available) The module/extension is not enabled on your RMT/SMT
+(Activated)     The module/extension is activated on your system
+`
+
+func TestParseSuseModules(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		expected []models.SuseModule
+	}{
+		{name: "empty means all", spec: "", expected: nil},
+		{name: "all", spec: "all", expected: nil},
+		{name: "none", spec: "none", expected: []models.SuseModule{}},
+		{name: "profile and paid extension", spec: "containers, sle-ha=REGCODE",
+			expected: []models.SuseModule{{Identifier: "sle-module-containers"}, {Identifier: "sle-ha", Regcode: "REGCODE"}}},
+		{name: "hpc profile", spec: "hpc", expected: []models.SuseModule{{Identifier: "sle-module-hpc"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			modules, err := ParseSuseModules(tc.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, modules)
+		})
+	}
+}
+
+func TestParseSuseModules_Fail(t *testing.T) {
+	_, err := ParseSuseModules("containers,none")
+	assert.ErrorContains(t, err, "'none' cannot be combined with other modules")
+
+	_, err = ParseSuseModules("sle-module-containers/15.6/x86_64")
+	assert.ErrorContains(t, err, "neither a module identifier nor a profile")
+}
+
+func TestRedactSuseModules(t *testing.T) {
+	assert.Equal(t, "containers,sle-ha=<hidden-for-security-reasons>", RedactSuseModules("containers,sle-ha=REGCODE"))
+}
+
+func Test_parseSuseExtensions(t *testing.T) {
+	extensions := parseSuseExtensions(suseListExtensions)
+
+	assert.Len(t, extensions, 7)
+	assert.Equal(t, suseExtension{Product: "sle-module-basesystem/15.6/x86_64", Activated: true}, extensions["sle-module-basesystem"])
+	assert.Equal(t, suseExtension{Product: "sle-module-development-tools/15.6/x86_64", Requires: "sle-module-desktop-applications"},
+		extensions["sle-module-development-tools"])
+	assert.Equal(t, "sle-module-server-applications", extensions["sle-ha"].Requires)
+	assert.Equal(t, "", extensions["sle-module-hpc"].Requires)
+}
+
+func Test_orderSuseModules(t *testing.T) {
+	extensions := parseSuseExtensions(suseListExtensions)
+	modules := []models.SuseModule{
+		{Identifier: "sle-ha", Regcode: "REGCODE"},
+		{Identifier: "sle-module-development-tools"},
+		{Identifier: "sle-module-server-applications"},
+	}
+
+	ordered, err := orderSuseModules(modules, extensions)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.SuseModule{
+		{Identifier: "sle-module-basesystem"},
+		{Identifier: "sle-module-server-applications"},
+		{Identifier: "sle-ha", Regcode: "REGCODE"},
+		{Identifier: "sle-module-desktop-applications"},
+		{Identifier: "sle-module-development-tools"},
+	}, ordered)
+
+	_, err = orderSuseModules([]models.SuseModule{{Identifier: "sle-module-live-patching"}}, extensions)
+	assert.ErrorContains(t, err, "module 'sle-module-live-patching' is not available")
+}
+
+func TestSuseConnectRegistrar_RegisterSelectedModules(t *testing.T) {
+	registration := models.OsRegistration{Code: "regcode", Email: "hoge@example.com", Modules: "sle-ha=HAREGCODE"}
+	registerCmd := "sudo -E SUSEConnect -r regcode -e hoge@example.com"
+	serverAppsCmd := "sudo -E SUSEConnect -p sle-module-server-applications/15.6/x86_64"
+	haCmd := "sudo -E SUSEConnect -r HAREGCODE -p sle-ha/15.6/x86_64"
+	status, err := json.Marshal([]models.SuseProduct{
+		{Identifier: "SLES", Version: "15.6", Arch: "x86_64", Status: "Registered"},
+		{Identifier: "sle-module-basesystem", Version: "15.6", Arch: "x86_64", Status: "Registered"},
+	})
+	require.NoError(t, err)
+
+	run, executed := recordingRunner(map[string]string{
+		registerCmd:                  "",
+		cmdSuseConnectListExtensions: suseListExtensions,
+		cmdSuseConnectStatus:         string(status),
+		serverAppsCmd:                "",
+		haCmd:                        "",
+	}, nil)
+
+	err = (&suseConnectRegistrar{}).Register(run, registration)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{registerCmd, cmdSuseConnectListExtensions, cmdSuseConnectStatus, serverAppsCmd, haCmd}, *executed)
+}
+
+func TestSuseConnectRegistrar_RegisterNoModules(t *testing.T) {
+	registration := models.OsRegistration{Code: "regcode", Email: "hoge@example.com", Modules: SUSE_MODULES_NONE}
+	registerCmd := "sudo -E SUSEConnect -r regcode -e hoge@example.com"
+	run, executed := recordingRunner(map[string]string{registerCmd: ""}, nil)
+
+	err := (&suseConnectRegistrar{}).Register(run, registration)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{registerCmd}, *executed)
+}
+
+func TestSuseConnectRegistrar_RegisterUnavailableModule(t *testing.T) {
+	registration := models.OsRegistration{Code: "regcode", Email: "hoge@example.com", Modules: "sle-module-live-patching"}
+	registerCmd := "sudo -E SUSEConnect -r regcode -e hoge@example.com"
+	run, executed := recordingRunner(map[string]string{registerCmd: "", cmdSuseConnectListExtensions: suseListExtensions}, nil)
+
+	err := (&suseConnectRegistrar{}).Register(run, registration)
+
+	assert.ErrorContains(t, err, "is not available for the registered product")
+	assert.Equal(t, []string{registerCmd, cmdSuseConnectListExtensions}, *executed)
+}
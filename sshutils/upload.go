@@ -0,0 +1,94 @@
+package sshutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	slog "github.com/fujitsu/docker-machine-driver-fsas/logger"
+
+	"github.com/pkg/sftp"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const cmdRemoteFileChecksum = "sudo sha256sum %s"
+
+// sftpConnect Opens SFTP session to the machine, returned function closes it
+var sftpConnect = func(sc *StandardSshManager) (*sftp.Client, func(), error) {
+	address := fmt.Sprintf("%s:%d", sc.HostName, port)
+	conn, err := gossh.Dial("tcp", address, sc.getSshClientConfig())
+	if err != nil {
+		slog.Error("Failed to dial SSH host: ", "host", sc.HostName, "err", err)
+		return nil, nil, err
+	}
+
+	sftpClient, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		slog.Error("Failed to create SFTP client: ", "host", sc.HostName, "err", err)
+		return nil, nil, err
+	}
+	return sftpClient, func() { sftpClient.Close(); conn.Close() }, nil
+}
+
+/*
+StreamFileToRemoteMachine Streams local file to remotePath without loading it into memory, creating missing directories.
+With resume, a partially uploaded remote file is continued from its size; a remote file larger than the local one
+is uploaded again
+*/
+func (sc *StandardSshManager) StreamFileToRemoteMachine(localPath, remotePath string, fileMode os.FileMode, resume bool) error {
+	srcFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("cannot open file to upload: %w", err)
+	}
+	defer srcFile.Close()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot read size of file to upload: %w", err)
+	}
+
+	sftpClient, closeSftp, err := sftpConnect(sc)
+	if err != nil {
+		return err
+	}
+	defer closeSftp()
+
+	if err := sftpClient.MkdirAll(filepath.Dir(remotePath)); err != nil {
+		slog.Error("Failed to create remote directory: ", "host", sc.HostName, "path", filepath.Dir(remotePath), "err", err)
+		return err
+	}
+
+	var offset int64
+	if dstInfo, err := sftpClient.Stat(remotePath); resume && err == nil && dstInfo.Size() <= srcInfo.Size() {
+		offset = dstInfo.Size()
+	}
+	if offset == srcInfo.Size() && offset > 0 {
+		slog.Info("Remote file already uploaded: ", "path", remotePath, "size", offset)
+		return nil
+	}
+	if offset > 0 {
+		slog.Info("Resuming upload of file: ", "path", remotePath, "offset", offset, "size", srcInfo.Size())
+	}
+
+	if _, err := srcFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("cannot seek in file to upload: %w", err)
+	}
+	return sc.writeRemoteFile(sftpClient, remotePath, srcFile, offset, fileMode)
+}
+
+// RemoteFileChecksum Returns SHA-256 checksum of the file on the machine in hex
+func (sc *StandardSshManager) RemoteFileChecksum(path string) (string, error) {
+	output, err := sc.runCommand(fmt.Sprintf(cmdRemoteFileChecksum, path))
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no checksum of remote file %s received", path)
+	}
+	return fields[0], nil
+}
@@ -0,0 +1,132 @@
+package sshutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeReadWriteCloser joins ends of two pipes into connection of the in-process SFTP server
+type pipeReadWriteCloser struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+// Close closes both pipes
+func (p pipeReadWriteCloser) Close() error {
+	p.PipeReader.Close()
+	return p.PipeWriter.Close()
+}
+
+// mockSftpConnect Replaces SFTP connection with an in-process SFTP server serving the local file system
+func mockSftpConnect(t *testing.T) {
+	originalSftpConnect := sftpConnect
+	t.Cleanup(func() { sftpConnect = originalSftpConnect })
+	sftpConnect = func(_ *StandardSshManager) (*sftp.Client, func(), error) {
+		clientReader, serverWriter := io.Pipe()
+		serverReader, clientWriter := io.Pipe()
+
+		server, err := sftp.NewServer(pipeReadWriteCloser{serverReader, serverWriter})
+		require.NoError(t, err)
+		go server.Serve()
+
+		client, err := sftp.NewClientPipe(clientReader, clientWriter)
+		require.NoError(t, err)
+		return client, func() { server.Close(); client.Close() }, nil
+	}
+}
+
+// newUploadManager Returns SSH manager uploading through the in-process SFTP server and a local file to upload
+func newUploadManager(t *testing.T, content string) (*StandardSshManager, string) {
+	mockSftpConnect(t)
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+
+	localPath := filepath.Join(t.TempDir(), "rke2-images.linux-amd64.tar.zst")
+	require.NoError(t, os.WriteFile(localPath, []byte(content), 0600))
+	return manager, localPath
+}
+
+func TestStreamFileToRemoteMachine(t *testing.T) {
+	testCases := []struct {
+		name          string
+		remoteContent string
+		resume        bool
+	}{
+		{name: "new file", resume: true},
+		{name: "resume partial upload", remoteContent: "0123", resume: true},
+		{name: "already uploaded", remoteContent: "0123456789", resume: true},
+		{name: "restart larger remote file", remoteContent: "0123456789abcdef", resume: true},
+		{name: "restart without resume", remoteContent: "xxxx", resume: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manager, localPath := newUploadManager(t, "0123456789")
+			remotePath := filepath.Join(t.TempDir(), "staging", "rke2-images.linux-amd64.tar.zst")
+			if tc.remoteContent != "" {
+				require.NoError(t, os.MkdirAll(filepath.Dir(remotePath), 0700))
+				require.NoError(t, os.WriteFile(remotePath, []byte(tc.remoteContent), 0600))
+			}
+
+			err := manager.StreamFileToRemoteMachine(localPath, remotePath, 0644, tc.resume)
+
+			require.NoError(t, err)
+			content, err := os.ReadFile(remotePath)
+			require.NoError(t, err)
+			assert.Equal(t, "0123456789", string(content))
+		})
+	}
+}
+
+func TestStreamFileToRemoteMachine_ResumeKeepsUploadedPart(t *testing.T) {
+	manager, localPath := newUploadManager(t, "0123456789")
+	remotePath := filepath.Join(t.TempDir(), "rke2-images.linux-amd64.tar.zst")
+	// Content differs from the local file, so only the missing part is written
+	require.NoError(t, os.WriteFile(remotePath, []byte("abcd"), 0600))
+
+	err := manager.StreamFileToRemoteMachine(localPath, remotePath, 0644, true)
+
+	require.NoError(t, err)
+	content, err := os.ReadFile(remotePath)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd456789", string(content))
+}
+
+func TestStreamFileToRemoteMachine_MissingLocalFile(t *testing.T) {
+	manager, _ := newUploadManager(t, "")
+
+	err := manager.StreamFileToRemoteMachine("/missing/rke2.linux-amd64.tar.gz", "/tmp/rke2.linux-amd64.tar.gz", 0644, true)
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRemoteFileChecksum(t *testing.T) {
+	manager, err := NewStandardSshManager("host1", "user1", "password1", "mock/path", "", parsedHostPublicKey(t))
+	require.NoError(t, err)
+	manager.SshKeyPath = ""
+	path := "/root/rke2-artifacts/rke2.linux-amd64.tar.gz"
+	mockClient := &MockSSHClient{
+		OutputFunc: func(command string) (string, error) {
+			if command == fmt.Sprintf(cmdRemoteFileChecksum, path) {
+				return "4f0bc7ef0a  " + path + "\n", nil
+			}
+			return "", nil
+		},
+	}
+	manager.Client = mockClient
+
+	checksum, err := manager.RemoteFileChecksum(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "4f0bc7ef0a", checksum)
+
+	_, err = manager.RemoteFileChecksum("/empty")
+	assert.ErrorContains(t, err, "no checksum of remote file /empty received")
+}
@@ -60,4 +60,23 @@ func TestNewRealClock(t *testing.T) {
 	if _, ok := clock.(*RealClock); !ok {
 		t.Errorf("NewRealClock() returned type %T, expected *RealClock", clock)
 	}
-}
\ No newline at end of file
+}
+func TestManualClock_SleepAdvancesTime(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	realStart := time.Now()
+	clock.Sleep(30 * time.Minute)
+	if time.Since(realStart) > 50*time.Millisecond {
+		t.Errorf("ManualClock.Sleep() blocked for %v, expected to return immediately", time.Since(realStart))
+	}
+
+	if got := clock.Since(start); got != 30*time.Minute {
+		t.Errorf("ManualClock.Since() returned %v, expected 30m", got)
+	}
+
+	clock.Advance(time.Second)
+	if got := clock.Now(); !got.Equal(start.Add(30*time.Minute + time.Second)) {
+		t.Errorf("ManualClock.Now() returned %v after Advance, expected %v", got, start.Add(30*time.Minute+time.Second))
+	}
+}
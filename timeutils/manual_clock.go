@@ -0,0 +1,43 @@
+package timeutils
+
+import (
+	"sync"
+	"time"
+)
+
+// ManualClock implements Clock with time that only moves when told to.
+// Sleep advances the clock instead of blocking, so code polling with Sleep
+// runs instantly in tests while still observing time passing.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ Clock = (*ManualClock)(nil)
+
+// NewManualClock returns new instance of ManualClock set to given time
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep advances the clock by d without blocking
+func (c *ManualClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}